@@ -3,6 +3,7 @@ package apiserver
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -13,6 +14,10 @@ import (
 
 	_ "github.com/lib/pq"
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/apiserver/config"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/services"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/services/notifier"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/services/purger"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/services/scheduler"
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository"
 )
 
@@ -28,6 +33,33 @@ func Run(config *config.Config, logger *slog.Logger) error {
 
 	router := newServer(store, logger, config)
 
+	n, err := newNotifier(config.Reminders, logger)
+	if err != nil {
+		return err
+	}
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	schedulerDone := make(chan struct{})
+
+	go func() {
+		defer close(schedulerDone)
+		scheduler.New(store, n, config.Reminders.Interval, logger).Run(workersCtx)
+	}()
+
+	dispatcherDone := make(chan struct{})
+
+	go func() {
+		defer close(dispatcherDone)
+		router.dispatcher.Run(workersCtx)
+	}()
+
+	purgerDone := make(chan struct{})
+
+	go func() {
+		defer close(purgerDone)
+		purger.New(store, router.blobs, config.Trash.Retention, config.Trash.PurgeInterval, logger).Run(workersCtx)
+	}()
+
 	
 	s := &http.Server{
 		Addr: config.HTTPAddr,
@@ -59,11 +91,36 @@ func Run(config *config.Config, logger *slog.Logger) error {
 		logger.Error(fmt.Sprintf("failed to stop server: %s", err))
 	}
 
+	stopWorkers()
+	for _, done := range []chan struct{}{schedulerDone, dispatcherDone, purgerDone} {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			logger.Error("failed to stop background workers")
+		}
+	}
+
 	logger.Info("server stopped")
 
 	return nil
 }
 
+func newNotifier(cfg config.Reminders, logger *slog.Logger) (services.Notifier, error) {
+	switch cfg.Notifier {
+	case "log":
+		return notifier.NewLogNotifier(logger), nil
+	case "webhook":
+		if cfg.WebhookURL == "" {
+			return nil, errors.New("reminder webhook url is not set")
+		}
+		return notifier.NewWebhookNotifier(cfg.WebhookURL, nil), nil
+	case "outbox":
+		return notifier.NewOutboxNotifier(cfg.OutboxPath), nil
+	default:
+		return nil, fmt.Errorf("unknown reminder notifier %q", cfg.Notifier)
+	}
+}
+
 func initDB(databaseURL string) (*sql.DB, error) {
 	db, err := sql.Open("postgres", databaseURL)
 	if err != nil {
@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
@@ -12,6 +13,60 @@ type Config struct {
 	HTTPAddr 	string `yaml:"httpaddr" env-default:"localhost:8080" env-required:"true"`
 	DatabaseURL string `yaml:"databaseurl" env-required:"true"`
 	JWTSecret   string `yaml:"jwt_secret"`
+	Reminders   Reminders `yaml:"reminders"`
+	Webhooks    Webhooks  `yaml:"webhooks"`
+	Trash       Trash     `yaml:"trash"`
+	Concurrency Concurrency `yaml:"concurrency"`
+	Feed        Feed        `yaml:"feed"`
+	Attachments Attachments `yaml:"attachments"`
+}
+
+// Reminders configures the scheduler that sends task reminders. Notifier is
+// one of log, webhook or outbox.
+type Reminders struct {
+	Interval   time.Duration `yaml:"interval" env-default:"1m"`
+	Notifier   string        `yaml:"notifier" env-default:"log"`
+	WebhookURL string        `yaml:"webhook_url"`
+	OutboxPath string        `yaml:"outbox_path" env-default:"outbox/reminders.jsonl"`
+}
+
+// Webhooks configures the delivery of task events, a failed delivery is
+// retried MaxAttempts times in total with an exponential backoff. Webhooks
+// may point to loopback, private and link-local addresses only with
+// AllowPrivateTargets, meant for development.
+type Webhooks struct {
+	MaxAttempts         int           `yaml:"max_attempts" env-default:"5"`
+	Backoff             time.Duration `yaml:"backoff" env-default:"1s"`
+	AllowPrivateTargets bool          `yaml:"allow_private_targets" env-default:"false"`
+}
+
+// Trash configures how long deleted tasks are kept before they are purged
+// and how often the purge runs.
+type Trash struct {
+	Retention     time.Duration `yaml:"retention" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+// Concurrency configures the optimistic locking of tasks. With
+// RequireIfMatch a write that does not send If-Match is refused.
+type Concurrency struct {
+	RequireIfMatch bool `yaml:"require_if_match" env-default:"false"`
+}
+
+// Feed configures the calendar feed, each feed URL may be read RateLimit
+// times per RatePeriod.
+type Feed struct {
+	RateLimit  int           `yaml:"rate_limit" env-default:"30"`
+	RatePeriod time.Duration `yaml:"rate_period" env-default:"1m"`
+}
+
+// Attachments configures the files attached to tasks. The files are kept
+// in Dir, each file may be at most MaxSize bytes and a signed download URL
+// is valid for URLTTL.
+type Attachments struct {
+	Dir     string        `yaml:"dir" env-default:"attachments"`
+	MaxSize int64         `yaml:"max_size" env-default:"10485760"`
+	URLTTL  time.Duration `yaml:"url_ttl" env-default:"15m"`
 }
 
 func InitConfig() *Config {
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/middleware"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/list"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/timeentry"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/todo"
+)
+
+var errAccessDenied = errors.New("access denied")
+
+// authorizeTask checks that the authenticated user may act on a task of
+// the owner with the role. The owner may do anything with their own tasks,
+// another user needs an accepted share of the task, of one of its parents
+// or of its list. In a team workspace the members also have the role of
+// their membership on the tasks of each other. With a taskID of 0 it is
+// about all the tasks of the owner, only the owner and the members of the
+// workspace have them. A task that is not found in the active workspace is
+// denied too, so that the other users cannot tell which tasks exist.
+func authorizeTask(s store.Store, r *http.Request, ownerID int, taskID int, role string) error {
+	u := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+	if u.ID == ownerID {
+		return nil
+	}
+
+	granted := workspaceRole(r)
+
+	if taskID == 0 {
+		return allow(granted, role)
+	}
+
+	grants, err := s.Share().FindGrants(u.ID, ownerID)
+	if err != nil {
+		return err
+	}
+
+	if len(grants) == 0 && granted == "" {
+		return errAccessDenied
+	}
+
+	for id := taskID; id != 0; {
+		t, err := todos(s, r).FindByID(ownerID, id)
+		if err != nil {
+			if errors.Is(err, store.ErrRecordNotFound) {
+				return errAccessDenied
+			}
+			return err
+		}
+
+		for _, g := range grants {
+			if (g.TaskID != nil && *g.TaskID == t.TaskID) || (g.ListID != nil && t.ListID != nil && *g.ListID == *t.ListID) {
+				granted = model.MaxRole(granted, g.Role)
+			}
+		}
+
+		id = 0
+		if t.ParentTaskID != nil {
+			id = *t.ParentTaskID
+		}
+	}
+
+	return allow(granted, role)
+}
+
+// authorizeList checks that the authenticated user may act on a list of
+// the owner with the role, like authorizeTask does for tasks.
+func authorizeList(s store.Store, r *http.Request, ownerID int, listID int, role string) error {
+	u := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+	if u.ID == ownerID {
+		return nil
+	}
+
+	granted := workspaceRole(r)
+
+	if listID == 0 {
+		return allow(granted, role)
+	}
+
+	if _, err := lists(s, r).FindByID(ownerID, listID); err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			return errAccessDenied
+		}
+		return err
+	}
+
+	grants, err := s.Share().FindGrants(u.ID, ownerID)
+	if err != nil {
+		return err
+	}
+
+	for _, g := range grants {
+		if g.ListID != nil && *g.ListID == listID {
+			granted = model.MaxRole(granted, g.Role)
+		}
+	}
+
+	return allow(granted, role)
+}
+
+func allow(granted string, required string) error {
+	if !model.RoleAllows(granted, required) {
+		return errAccessDenied
+	}
+
+	return nil
+}
+
+// workspaceMember returns the membership of the authenticated user in the
+// active workspace of the request, nil in the personal workspace.
+func workspaceMember(r *http.Request) *model.WorkspaceMember {
+	m, _ := r.Context().Value(middleware.CtxKeyWorkspace).(*model.WorkspaceMember)
+
+	return m
+}
+
+// workspaceRole returns the role of the authenticated user in the active
+// workspace, none in the personal workspace.
+func workspaceRole(r *http.Request) string {
+	if m := workspaceMember(r); m != nil {
+		return m.Role
+	}
+
+	return ""
+}
+
+func workspaceID(r *http.Request) int {
+	if m := workspaceMember(r); m != nil {
+		return m.WorkspaceID
+	}
+
+	return model.PersonalWorkspaceID
+}
+
+// todos returns the repository of the tasks of the active workspace.
+func todos(s store.Store, r *http.Request) todo.TodoRepository {
+	return s.Todo().InWorkspace(workspaceID(r))
+}
+
+// lists returns the repository of the lists of the active workspace.
+func lists(s store.Store, r *http.Request) list.ListRepository {
+	return s.List().InWorkspace(workspaceID(r))
+}
+
+// timeEntries returns the repository of the time entries of the active
+// workspace.
+func timeEntries(s store.Store, r *http.Request) timeentry.TimeEntryRepository {
+	return s.TimeEntry().InWorkspace(workspaceID(r))
+}
+
+// authorize checks that the authenticated user may act on a task of the
+// user with the role, see authorizeTask.
+func (h *TaskHandler) authorize(r *http.Request, userID int, taskID int, role string) error {
+	return authorizeTask(h.Store, r, userID, taskID, role)
+}
+
+// authorizePlace checks that the authenticated user may put the task under
+// its parent or in its list. Another user than the owner needs to be an
+// editor of one of them.
+func (h *TaskHandler) authorizePlace(r *http.Request, t *model.Task) error {
+	authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+	if authUser.ID == t.UserID {
+		return nil
+	}
+
+	err := error(errAccessDenied)
+
+	if t.ParentTaskID != nil {
+		if err = authorizeTask(h.Store, r, t.UserID, *t.ParentTaskID, model.RoleEditor); err == nil {
+			return nil
+		}
+	}
+
+	if t.ListID != nil && errors.Is(err, errAccessDenied) {
+		err = authorizeList(h.Store, r, t.UserID, *t.ListID, model.RoleEditor)
+	}
+
+	return err
+}
+
+// authorizeMove checks that the authenticated user may move the stored task
+// to the parent and list of t. A nil parent or list of a partial update
+// does not move the task.
+func (h *TaskHandler) authorizeMove(r *http.Request, t *model.Task, partial bool) error {
+	authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+	if authUser.ID == t.UserID {
+		return nil
+	}
+
+	stored, err := todos(h.Store, r).FindByID(t.UserID, t.TaskID)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			return errAccessDenied
+		}
+		return err
+	}
+
+	moved := *stored
+	if t.ParentTaskID != nil || t.ClearParent || !partial {
+		moved.ParentTaskID = t.ParentTaskID
+	}
+	if t.ListID != nil || !partial {
+		moved.ListID = t.ListID
+	}
+
+	if sameID(moved.ParentTaskID, stored.ParentTaskID) && sameID(moved.ListID, stored.ListID) {
+		return nil
+	}
+
+	return h.authorizePlace(r, &moved)
+}
+
+// authorize checks that the authenticated user may act on a list of the
+// user with the role, see authorizeList.
+func (h *ListHandler) authorize(r *http.Request, userID int, listID int, role string) error {
+	return authorizeList(h.Store, r, userID, listID, role)
+}
+
+// accessErrorCode maps errors of the authorization to response codes.
+func accessErrorCode(err error) int {
+	switch {
+	case errors.Is(err, errAccessDenied):
+		return http.StatusForbidden
+	case errors.Is(err, store.ErrRecordNotFound):
+		return http.StatusNotFound
+	}
+
+	return http.StatusInternalServerError
+}
+
+func sameID(a *int, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
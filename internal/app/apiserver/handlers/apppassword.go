@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/middleware"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+// AppPasswordHandler manages the app passwords of a user. A password is
+// only returned when it is created.
+type AppPasswordHandler struct {
+	Store   store.Store
+	Respond func(http.ResponseWriter, *http.Request, int, interface{})
+	Error   func(http.ResponseWriter, *http.Request, int, error)
+}
+
+func (h *AppPasswordHandler) GetAppPasswords(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Error(w, r, http.StatusForbidden, errors.New("access denied"))
+			return
+		}
+
+		passwords, err := h.Store.AppPassword().FindAll(userID)
+		if err != nil {
+			h.Error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, passwords)
+	}
+}
+
+func (h *AppPasswordHandler) CreateAppPassword(userID int) http.HandlerFunc {
+	type request struct {
+		Name string `json:"name"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Error(w, r, http.StatusForbidden, errors.New("access denied"))
+			return
+		}
+
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		p := &model.AppPassword{
+			UserID: userID,
+			Name:   req.Name,
+		}
+
+		if err := h.Store.AppPassword().Create(p); err != nil {
+			h.Error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusCreated, p)
+	}
+}
+
+func (h *AppPasswordHandler) DeleteAppPassword(userID int, passwordID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Error(w, r, http.StatusForbidden, errors.New("access denied"))
+			return
+		}
+
+		if err := h.Store.AppPassword().Delete(userID, passwordID); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusNoContent, nil)
+	}
+}
@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/middleware"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/services"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+// maxMultipartOverhead is what an upload may send besides the file, the
+// boundaries and the headers of the parts.
+const maxMultipartOverhead = 64 << 10
+
+var (
+	errNoFile           = errors.New("the upload has no file part")
+	errFileTooLarge     = errors.New("the file is too large")
+	errInvalidSignature = errors.New("invalid signature")
+	errLinkExpired      = errors.New("the download link has expired")
+)
+
+// AttachmentHandler manages the files attached to tasks. The files are kept
+// in Blobs, each one at most MaxSize bytes. Besides the endpoints of the
+// API, a file is served without a bearer token at a URL signed with
+// SigningKey that expires after URLTTL.
+type AttachmentHandler struct {
+	Store      store.Store
+	Blobs      services.BlobStore
+	MaxSize    int64
+	URLTTL     time.Duration
+	SigningKey []byte
+	Respond    func(http.ResponseWriter, *http.Request, int, interface{})
+	Error      func(http.ResponseWriter, *http.Request, int, error)
+}
+
+func (h *AttachmentHandler) GetAttachments(userID int, taskID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := authorizeTask(h.Store, r, userID, taskID, model.RoleViewer); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		// the attachments of the tasks of the other workspaces are not found
+		if _, err := todos(h.Store, r).FindByID(userID, taskID); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		attachments, err := h.Store.Attachment().FindByTask(userID, taskID)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		for _, a := range attachments {
+			a.URL = h.signedURL(r, a)
+		}
+
+		h.Respond(w, r, http.StatusOK, attachments)
+	}
+}
+
+// UploadAttachment attaches the file part of a multipart form to the task.
+// The content type of the file is sniffed from its first bytes, the type
+// the client claims is not trusted.
+func (h *AttachmentHandler) UploadAttachment(userID int, taskID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := authorizeTask(h.Store, r, userID, taskID, model.RoleEditor); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		// the attachments of the tasks of the other workspaces are not found
+		if _, err := todos(h.Store, r).FindByID(userID, taskID); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, h.MaxSize+maxMultipartOverhead)
+
+		mr, err := r.MultipartReader()
+		if err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		var part io.Reader
+		var name string
+
+		for part == nil {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				h.Error(w, r, http.StatusBadRequest, errNoFile)
+				return
+			}
+			if err != nil {
+				h.Error(w, r, uploadErrorCode(err, http.StatusBadRequest), err)
+				return
+			}
+
+			if p.FormName() == "file" {
+				part, name = p, p.FileName()
+			}
+		}
+
+		br := bufio.NewReaderSize(part, 512)
+		head, err := br.Peek(512)
+		if err != nil && err != io.EOF {
+			h.Error(w, r, uploadErrorCode(err, http.StatusBadRequest), err)
+			return
+		}
+
+		contentType := http.DetectContentType(head)
+		if !model.AttachmentTypeAllowed(contentType) {
+			h.Error(w, r, http.StatusUnsupportedMediaType, fmt.Errorf("files of type %s cannot be attached", contentType))
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		a := &model.Attachment{
+			UserID:      userID,
+			TaskID:      taskID,
+			UploadedBy:  authUser.ID,
+			Name:        name,
+			ContentType: contentType,
+		}
+
+		if err := a.BeforeCreate(); err != nil {
+			h.Error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := a.Validation(); err != nil {
+			h.Error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		// one byte more than the limit tells that the file is too large
+		lr := &io.LimitedReader{R: br, N: h.MaxSize + 1}
+
+		if err := h.Blobs.Put(r.Context(), a.Key, lr); err != nil {
+			h.Error(w, r, uploadErrorCode(err, http.StatusInternalServerError), err)
+			return
+		}
+
+		a.Size = h.MaxSize + 1 - lr.N
+
+		if a.Size > h.MaxSize {
+			h.Blobs.Delete(r.Context(), a.Key)
+			h.Error(w, r, http.StatusRequestEntityTooLarge, errFileTooLarge)
+			return
+		}
+
+		if err := h.Store.Attachment().Create(a); err != nil {
+			h.Blobs.Delete(r.Context(), a.Key)
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		a.URL = h.signedURL(r, a)
+
+		h.Respond(w, r, http.StatusCreated, a)
+	}
+}
+
+// GetAttachment downloads the file of the attachment.
+func (h *AttachmentHandler) GetAttachment(userID int, taskID int, attachmentID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := authorizeTask(h.Store, r, userID, taskID, model.RoleViewer); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		// the attachments of the tasks of the other workspaces are not found
+		if _, err := todos(h.Store, r).FindByID(userID, taskID); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		a, err := h.Store.Attachment().FindByID(userID, taskID, attachmentID)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.serveFile(w, r, a)
+	}
+}
+
+// DeleteAttachment deletes the attachment and its file.
+func (h *AttachmentHandler) DeleteAttachment(userID int, taskID int, attachmentID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := authorizeTask(h.Store, r, userID, taskID, model.RoleEditor); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		// the attachments of the tasks of the other workspaces are not found
+		if _, err := todos(h.Store, r).FindByID(userID, taskID); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		a, err := h.Store.Attachment().FindByID(userID, taskID, attachmentID)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		if err := h.Store.Attachment().Delete(userID, taskID, attachmentID); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		// the attachment is gone already, a file that is left behind is
+		// never served
+		h.Blobs.Delete(r.Context(), a.Key)
+
+		h.Respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// ServeAttachment serves the file of the attachment at a signed URL, the
+// signature and its expiry are the credential.
+func (h *AttachmentHandler) ServeAttachment(attachmentID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		expires, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+		if err != nil || !hmac.Equal([]byte(r.URL.Query().Get("signature")), []byte(h.sign(attachmentID, expires))) {
+			h.Error(w, r, http.StatusForbidden, errInvalidSignature)
+			return
+		}
+
+		if time.Now().Unix() > expires {
+			h.Error(w, r, http.StatusForbidden, errLinkExpired)
+			return
+		}
+
+		a, err := h.Store.Attachment().Find(attachmentID)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.serveFile(w, r, a)
+	}
+}
+
+// serveFile writes the file of the attachment. It is always offered as a
+// download and the browser may not sniff another type from it.
+func (h *AttachmentHandler) serveFile(w http.ResponseWriter, r *http.Request, a *model.Attachment) {
+	rc, err := h.Blobs.Get(r.Context(), a.Key)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			h.Error(w, r, http.StatusNotFound, store.ErrRecordNotFound)
+			return
+		}
+		h.Error(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	defer rc.Close()
+
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": a.Name})
+	if disposition == "" {
+		disposition = "attachment"
+	}
+
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+	w.Header().Set("Content-Disposition", disposition)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	io.Copy(w, rc)
+}
+
+func (h *AttachmentHandler) signedURL(r *http.Request, a *model.Attachment) string {
+	expires := time.Now().Add(h.URLTTL).Unix()
+
+	return fmt.Sprintf("%s/attachment/%d?expires=%d&signature=%s", baseURL(r), a.ID, expires, h.sign(a.ID, expires))
+}
+
+func (h *AttachmentHandler) sign(attachmentID int, expires int64) string {
+	mac := hmac.New(sha256.New, h.SigningKey)
+	fmt.Fprintf(mac, "attachment:%d:%d", attachmentID, expires)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// uploadErrorCode maps errors of reading an upload to response codes. An
+// upload that is too large or cut off is the fault of the client, other
+// errors get the fallback code.
+func uploadErrorCode(err error, fallback int) int {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return http.StatusBadRequest
+	}
+
+	return fallback
+}
@@ -117,9 +117,12 @@ func (h *AuthHandler) Login() http.HandlerFunc{
 	}
 }
 
+// Refresh issues a new access token. A client that has switched to a team
+// workspace sends its workspace_id to stay in it.
 func (h *AuthHandler) Refresh() http.HandlerFunc {
 	type request struct {
 		RefreshToken string `json:"refresh_token"`
+		WorkspaceID  int    `json:"workspace_id"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -140,7 +143,14 @@ func (h *AuthHandler) Refresh() http.HandlerFunc {
 			return
 		}
 
-		newAccessToken, err := h.TokenService.GenerateAccessToken(u.ID)
+		if req.WorkspaceID != model.PersonalWorkspaceID {
+			if _, err := h.Store.Workspace().FindMember(req.WorkspaceID, u.ID); err != nil {
+				h.Error(w, r, http.StatusForbidden, errors.New("not a member of the workspace"))
+				return
+			}
+		}
+
+		newAccessToken, err := h.TokenService.GenerateWorkspaceAccessToken(u.ID, req.WorkspaceID)
 		if err != nil {
 			h.Error(w, r, http.StatusInternalServerError, err)
 			return
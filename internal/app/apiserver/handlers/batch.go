@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/middleware"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/todo"
+)
+
+var (
+	errBatchFailed = errors.New("an operation of the batch failed")
+	errNotApplied  = errors.New("not applied, the batch was rolled back")
+	errNoVersion   = errors.New("the operation must be conditional: send the version of the task")
+)
+
+// BatchTasks runs the operations of a model.TaskBatch in one transaction
+// and answers with a result per operation. The events of the batch are
+// published once it is committed.
+func (h *TaskHandler) BatchTasks(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if err := h.authorize(r, userID, 0, model.RoleEditor); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		batch := &model.TaskBatch{}
+
+		if err := json.NewDecoder(r.Body).Decode(batch); err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if batch.Mode == "" {
+			batch.Mode = model.BatchAtomic
+		}
+
+		if err := batch.Validation(); err != nil {
+			h.Error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		res := &model.TaskBatchResult{Results: make([]*model.TaskOperationResult, len(batch.Operations))}
+		var events []func()
+
+		err := todos(h.Store, r).InTx(func(repo todo.TodoRepository) error {
+			for i, op := range batch.Operations {
+				result, publish := h.runOperation(repo, userID, authUser.ID, op)
+				res.Results[i] = result
+
+				if result.Error == "" {
+					events = append(events, publish...)
+					continue
+				}
+
+				if batch.Mode == model.BatchAtomic {
+					for j := i + 1; j < len(batch.Operations); j++ {
+						res.Results[j] = &model.TaskOperationResult{
+							Op:     batch.Operations[j].Op,
+							TaskID: batch.Operations[j].TaskID,
+							Status: http.StatusFailedDependency,
+							Error:  errNotApplied.Error(),
+						}
+					}
+					return errBatchFailed
+				}
+			}
+
+			return nil
+		})
+		if err != nil && !errors.Is(err, errBatchFailed) {
+			h.Error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err != nil {
+			h.Respond(w, r, http.StatusUnprocessableEntity, res)
+			return
+		}
+
+		res.Committed = true
+
+		for _, publish := range events {
+			publish()
+		}
+
+		h.Respond(w, r, http.StatusOK, res)
+	}
+}
+
+// runOperation runs an operation of a batch with the repository of its
+// transaction. It returns the result and the events to publish once the
+// batch is committed.
+func (h *TaskHandler) runOperation(repo todo.TodoRepository, userID int, actorID int, op *model.TaskOperation) (*model.TaskOperationResult, []func()) {
+	res := &model.TaskOperationResult{Op: op.Op, TaskID: op.TaskID}
+
+	fail := func(code int, err error) (*model.TaskOperationResult, []func()) {
+		res.Status = code
+		res.Error = err.Error()
+		return res, nil
+	}
+
+	if err := op.Validation(); err != nil {
+		return fail(http.StatusUnprocessableEntity, err)
+	}
+
+	// the version stands in for If-Match
+	if op.Op != model.OpCreate && op.Version == nil && h.RequireIfMatch {
+		return fail(http.StatusPreconditionRequired, errNoVersion)
+	}
+
+	switch op.Op {
+	case model.OpCreate:
+		req := &createTaskRequest{}
+		if err := json.Unmarshal(op.Task, req); err != nil {
+			return fail(http.StatusBadRequest, err)
+		}
+
+		t := req.task(userID, actorID)
+		if code, err := h.createTask(repo, t); err != nil {
+			return fail(code, err)
+		}
+
+		res.TaskID, res.Status, res.Task = t.TaskID, http.StatusCreated, t
+
+		return res, []func(){func() { h.publish(model.EventTaskCreated, t) }}
+
+	case model.OpUpdate, model.OpComplete:
+		req := &updateTaskRequest{}
+		if op.Op == model.OpUpdate {
+			if err := json.Unmarshal(op.Task, req); err != nil {
+				return fail(http.StatusBadRequest, err)
+			}
+		} else {
+			complete := true
+			req.Complete = &complete
+		}
+
+		t := req.task(userID, op.TaskID, actorID)
+		t.IfVersion = op.Version
+
+		before, code, err := h.updateTask(repo, t)
+		if err != nil {
+			return fail(code, err)
+		}
+
+		stored, err := repo.FindByID(userID, op.TaskID)
+		if err != nil {
+			return fail(storeErrorCode(err), err)
+		}
+
+		res.Status, res.Task = http.StatusOK, stored
+
+		// the events are published after the commit, without the transaction
+		published := h.Store.Todo().InWorkspace(repo.Workspace())
+
+		return res, []func(){func() { h.publishUpdate(published, t, completes(before, t)) }}
+
+	default:
+		t, err := repo.FindByID(userID, op.TaskID)
+		if err != nil {
+			return fail(storeErrorCode(err), err)
+		}
+
+		var count int64
+		if op.Version != nil {
+			count, err = repo.DeleteVersion(userID, op.TaskID, *op.Version, actorID)
+		} else {
+			count, err = repo.Delete(userID, []int{op.TaskID}, actorID)
+		}
+		if err != nil {
+			return fail(storeErrorCode(err), err)
+		}
+
+		if count == 0 {
+			return fail(http.StatusNotFound, store.ErrRecordNotFound)
+		}
+
+		res.Status = http.StatusNoContent
+
+		return res, []func(){func() { h.publish(model.EventTaskDeleted, t) }}
+	}
+}
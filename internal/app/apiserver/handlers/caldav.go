@@ -0,0 +1,697 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/ical"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/middleware"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+const (
+	// DAVRoot is the path of the CalDAV server. The principal of a user is
+	// at principals/{user_id}/ under it, the calendar home at
+	// calendars/{user_id}/ and the task calendar at calendars/{user_id}/tasks/.
+	DAVRoot = "/dav/"
+
+	calendarName    = "Tasks"
+	calendarType    = "text/calendar; charset=utf-8; component=VTODO"
+	maxCalendarSize = 1 << 20
+
+	// syncTokenPrefix makes the sync tokens URIs, the number after it is
+	// the token of the store.
+	syncTokenPrefix = "urn:taskmanager-api:sync:"
+)
+
+var (
+	errInvalidSyncToken = errors.New("invalid sync token")
+	errNoDue            = errors.New("the VTODO needs a DUE, every task has a deadline")
+	errUIDChanged       = errors.New("the UID of a task cannot be changed")
+)
+
+// CalDAVHandler serves the tasks of a user as a CalDAV calendar of VTODOs.
+// It supports the subset of CalDAV that task clients use to sync: PROPFIND
+// for discovery, the calendar-query, calendar-multiget and sync-collection
+// reports, and GET, PUT and DELETE of the tasks. The writes go through the
+// task handler, so they are validated and publish events like the writes
+// of the API. The calendar holds the tasks of the personal workspace.
+type CalDAVHandler struct {
+	Tasks *TaskHandler
+}
+
+// Options announces the CalDAV support of a resource.
+func (h *CalDAVHandler) Options() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("DAV", "1, 3, calendar-access")
+		w.Header().Set("Allow", "OPTIONS, PROPFIND, REPORT, GET, HEAD, PUT, DELETE")
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// PropfindRoot answers PROPFIND on the root of the server, it leads a
+// client to the principal of its user.
+func (h *CalDAVHandler) PropfindRoot() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		req, err := parsePropfind(r)
+		if err != nil {
+			h.Tasks.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		props := h.principalProps(authUser)
+		props[davName("resourcetype")] = staticProp("<D:collection/>")
+
+		writeMultistatus(w, []*davResponse{req.response(DAVRoot, props)}, "")
+	}
+}
+
+func (h *CalDAVHandler) PropfindPrincipal(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Tasks.Error(w, r, http.StatusForbidden, errAccessDenied)
+			return
+		}
+
+		req, err := parsePropfind(r)
+		if err != nil {
+			h.Tasks.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		writeMultistatus(w, []*davResponse{req.response(principalHref(userID), h.principalProps(authUser))}, "")
+	}
+}
+
+// PropfindHome answers PROPFIND on the calendar home of the user, its only
+// calendar is the task calendar.
+func (h *CalDAVHandler) PropfindHome(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Tasks.Error(w, r, http.StatusForbidden, errAccessDenied)
+			return
+		}
+
+		req, err := parsePropfind(r)
+		if err != nil {
+			h.Tasks.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		props := davProps{
+			davName("resourcetype"):           staticProp("<D:collection/>"),
+			davName("displayname"):            staticProp(xmlText(authUser.Email)),
+			davName("current-user-principal"): hrefProp(principalHref(userID)),
+			davName("owner"):                  hrefProp(principalHref(userID)),
+		}
+
+		responses := []*davResponse{req.response(homeHref(userID), props)}
+
+		if depth(r) > 0 {
+			props, err := h.calendarProps(userID)
+			if err != nil {
+				h.Tasks.Error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			responses = append(responses, req.response(calendarHref(userID), props))
+		}
+
+		writeMultistatus(w, responses, "")
+	}
+}
+
+// PropfindCalendar answers PROPFIND on the task calendar, with Depth 1 the
+// tasks are listed too.
+func (h *CalDAVHandler) PropfindCalendar(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Tasks.Error(w, r, http.StatusForbidden, errAccessDenied)
+			return
+		}
+
+		req, err := parsePropfind(r)
+		if err != nil {
+			h.Tasks.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		props, err := h.calendarProps(userID)
+		if err != nil {
+			h.Tasks.Error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		responses := []*davResponse{req.response(calendarHref(userID), props)}
+
+		if depth(r) > 0 {
+			err := h.Tasks.Store.Todo().Export(userID, func(t *model.Task) error {
+				responses = append(responses, req.response(taskHref(t), taskProps(t)))
+				return nil
+			})
+			if err != nil {
+				h.Tasks.Error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		writeMultistatus(w, responses, "")
+	}
+}
+
+func (h *CalDAVHandler) PropfindTask(userID int, name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Tasks.Error(w, r, http.StatusForbidden, errAccessDenied)
+			return
+		}
+
+		req, err := parsePropfind(r)
+		if err != nil {
+			h.Tasks.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		t, err := h.findTask(userID, name)
+		if err != nil {
+			h.Tasks.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		writeMultistatus(w, []*davResponse{req.response(taskHref(t), taskProps(t))}, "")
+	}
+}
+
+// Report answers the calendar-query, calendar-multiget and sync-collection
+// reports of the task calendar.
+func (h *CalDAVHandler) Report(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Tasks.Error(w, r, http.StatusForbidden, errAccessDenied)
+			return
+		}
+
+		req := &reportRequest{}
+
+		if err := xml.NewDecoder(http.MaxBytesReader(w, r.Body, maxCalendarSize)).Decode(req); err != nil {
+			h.Tasks.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		switch req.XMLName {
+		case calDAVName("calendar-query"):
+			h.calendarQuery(w, r, userID, req)
+		case calDAVName("calendar-multiget"):
+			h.calendarMultiget(w, r, userID, req)
+		case davName("sync-collection"):
+			h.syncCollection(w, r, userID, req)
+		default:
+			w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, xml.Header+`<D:error xmlns:D="DAV:"><D:supported-report/></D:error>`)
+		}
+	}
+}
+
+func (h *CalDAVHandler) calendarQuery(w http.ResponseWriter, r *http.Request, userID int, req *reportRequest) {
+	responses := []*davResponse{}
+
+	err := h.Tasks.Store.Todo().Export(userID, func(t *model.Task) error {
+		if req.Filter == nil || req.Filter.match(t) {
+			responses = append(responses, req.response(taskHref(t), taskProps(t)))
+		}
+		return nil
+	})
+	if err != nil {
+		h.Tasks.Error(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeMultistatus(w, responses, "")
+}
+
+func (h *CalDAVHandler) calendarMultiget(w http.ResponseWriter, r *http.Request, userID int, req *reportRequest) {
+	responses := []*davResponse{}
+
+	for _, href := range req.Hrefs {
+		href = strings.TrimSpace(href)
+
+		name, ok := taskName(userID, href)
+		if !ok {
+			responses = append(responses, &davResponse{Href: href, Status: http.StatusNotFound})
+			continue
+		}
+
+		t, err := h.findTask(userID, name)
+		if err != nil {
+			if !errors.Is(err, store.ErrRecordNotFound) {
+				h.Tasks.Error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			responses = append(responses, &davResponse{Href: href, Status: http.StatusNotFound})
+			continue
+		}
+
+		responses = append(responses, req.response(taskHref(t), taskProps(t)))
+	}
+
+	writeMultistatus(w, responses, "")
+}
+
+// syncCollection reports the tasks changed since the sync token of the
+// request, all the tasks without a token.
+func (h *CalDAVHandler) syncCollection(w http.ResponseWriter, r *http.Request, userID int, req *reportRequest) {
+	since, err := parseSyncToken(req.SyncToken)
+	if err == nil && since > 0 {
+		current, tokenErr := h.Tasks.Store.Todo().SyncToken(userID)
+		if tokenErr != nil {
+			h.Tasks.Error(w, r, http.StatusInternalServerError, tokenErr)
+			return
+		}
+		if since > current {
+			err = errInvalidSyncToken
+		}
+	}
+	if err != nil {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, xml.Header+`<D:error xmlns:D="DAV:"><D:valid-sync-token/></D:error>`)
+		return
+	}
+
+	changes, err := h.Tasks.Store.Todo().Changes(userID, since)
+	if err != nil {
+		h.Tasks.Error(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses := []*davResponse{}
+
+	for _, t := range changes.Changed {
+		responses = append(responses, req.response(taskHref(t), taskProps(t)))
+	}
+
+	// a client that syncs for the first time has nothing to delete
+	if since > 0 {
+		for _, t := range changes.Deleted {
+			responses = append(responses, &davResponse{Href: taskHref(t), Status: http.StatusNotFound})
+		}
+	}
+
+	writeMultistatus(w, responses, formatSyncToken(changes.Token))
+}
+
+func (h *CalDAVHandler) GetTask(userID int, name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Tasks.Error(w, r, http.StatusForbidden, errAccessDenied)
+			return
+		}
+
+		t, err := h.findTask(userID, name)
+		if err != nil {
+			h.Tasks.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		w.Header().Set("Content-Type", calendarType)
+		setETag(w, t)
+		w.WriteHeader(http.StatusOK)
+
+		if r.Method == http.MethodHead {
+			return
+		}
+
+		w.Write(calendarData(t))
+	}
+}
+
+// PutTask creates or replaces a task from the VTODO of the request. The
+// fields of the task that a VTODO has no property for, like its list, are
+// kept, and so are its tags when the VTODO has no CATEGORIES. A new task
+// is stored with the UID of its client.
+func (h *CalDAVHandler) PutTask(userID int, name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Tasks.Error(w, r, http.StatusForbidden, errAccessDenied)
+			return
+		}
+
+		todo, err := ical.Decode(http.MaxBytesReader(w, r.Body, maxCalendarSize))
+		if err != nil {
+			h.Tasks.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		stored, err := h.findTask(userID, name)
+		if err != nil && !errors.Is(err, store.ErrRecordNotFound) {
+			h.Tasks.Error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if code, err := h.putPreconditions(r, stored); err != nil {
+			h.Tasks.Error(w, r, code, err)
+			return
+		}
+
+		t := todo.Task
+		t.UserID = userID
+		t.ActorID = authUser.ID
+		// a client sends the tasks it already has, overdue or not
+		t.PastDeadline = true
+
+		if t.Deadline == nil {
+			h.Tasks.Error(w, r, http.StatusUnprocessableEntity, errNoDue)
+			return
+		}
+
+		if stored == nil {
+			if code, err := h.checkUID(userID, todo.UID); err != nil {
+				h.Tasks.Error(w, r, code, err)
+				return
+			}
+
+			t.ICalUID = &todo.UID
+
+			if code, err := h.Tasks.createTask(h.Tasks.Store.Todo(), t); err != nil {
+				h.Tasks.Error(w, r, code, err)
+				return
+			}
+
+			h.Tasks.publish(model.EventTaskCreated, t)
+
+			w.Header().Set("Location", taskHref(t))
+			setETag(w, t)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+
+		if todo.UID != ical.TaskUID(stored) {
+			h.Tasks.Error(w, r, http.StatusConflict, errUIDChanged)
+			return
+		}
+
+		t.TaskID = stored.TaskID
+		t.ListID = stored.ListID
+		t.ParentTaskID = stored.ParentTaskID
+		t.RRule = stored.RRule
+		t.IfVersion = &stored.Version
+
+		if !todo.HasCategories {
+			t.Tags = stored.Tags
+		}
+
+		if err := h.Tasks.placeInTree(h.Tasks.Store.Todo(), t, true); err != nil {
+			h.Tasks.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		workflow, err := h.Tasks.workflow(h.Tasks.Store.Todo(), userID, t.ListID)
+		if err != nil {
+			h.Tasks.Error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		// the status sets the complete flag that Validation checks
+		if err := workflow.Apply(t, stored); err != nil {
+			h.Tasks.Error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		if err := t.Validation(http.MethodPut); err != nil {
+			h.Tasks.Error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		if err := h.Tasks.Store.Todo().Replace(t); err != nil {
+			h.Tasks.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Tasks.publishUpdate(h.Tasks.Store.Todo(), t, completes(stored, t))
+
+		setETag(w, t)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *CalDAVHandler) DeleteTask(userID int, name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Tasks.Error(w, r, http.StatusForbidden, errAccessDenied)
+			return
+		}
+
+		t, err := h.findTask(userID, name)
+		if err != nil {
+			h.Tasks.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		version, err := h.Tasks.ifMatch(r)
+		if err != nil {
+			h.Tasks.Error(w, r, ifMatchErrorCode(err), err)
+			return
+		}
+
+		var count int64
+		if version != nil {
+			count, err = h.Tasks.Store.Todo().DeleteVersion(userID, t.TaskID, *version, authUser.ID)
+		} else {
+			count, err = h.Tasks.Store.Todo().Delete(userID, []int{t.TaskID}, authUser.ID)
+		}
+		if err != nil {
+			h.Tasks.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		if count == 0 {
+			h.Tasks.Error(w, r, http.StatusNotFound, store.ErrRecordNotFound)
+			return
+		}
+
+		h.Tasks.publish(model.EventTaskDeleted, t)
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// findTask finds the task of a resource name, the UID of the task followed
+// by .ics.
+func (h *CalDAVHandler) findTask(userID int, name string) (*model.Task, error) {
+	uid, ok := strings.CutSuffix(name, ".ics")
+	if !ok || uid == "" {
+		return nil, store.ErrRecordNotFound
+	}
+
+	if taskID, ok := ical.ParseUID(uid); ok {
+		t, err := h.Tasks.Store.Todo().FindByID(userID, taskID)
+		if err != nil {
+			return nil, err
+		}
+
+		// a task of a client is only found by the UID of its client
+		if t.ICalUID != nil {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return t, nil
+	}
+
+	return h.Tasks.Store.Todo().FindByICalUID(userID, uid)
+}
+
+// checkUID makes sure that the UID of a new task is not taken, the UIDs of
+// the server are taken by its tasks.
+func (h *CalDAVHandler) checkUID(userID int, uid string) (int, error) {
+	if _, ok := ical.ParseUID(uid); ok {
+		return http.StatusConflict, store.ErrUIDConflict
+	}
+
+	_, err := h.Tasks.Store.Todo().FindByICalUID(userID, uid)
+	switch {
+	case err == nil:
+		return http.StatusConflict, store.ErrUIDConflict
+	case !errors.Is(err, store.ErrRecordNotFound):
+		return http.StatusInternalServerError, err
+	}
+
+	return 0, nil
+}
+
+func (h *CalDAVHandler) principalProps(u *model.User) davProps {
+	return davProps{
+		davName("resourcetype"):                 staticProp("<D:principal/>"),
+		davName("displayname"):                  staticProp(xmlText(u.Email)),
+		davName("current-user-principal"):       hrefProp(principalHref(u.ID)),
+		davName("principal-URL"):                hrefProp(principalHref(u.ID)),
+		calDAVName("calendar-home-set"):         hrefProp(homeHref(u.ID)),
+		calDAVName("calendar-user-address-set"): hrefProp("mailto:" + u.Email),
+	}
+}
+
+func (h *CalDAVHandler) calendarProps(userID int) (davProps, error) {
+	token, err := h.Tasks.Store.Todo().SyncToken(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return davProps{
+		davName("resourcetype"):                        staticProp("<D:collection/><C:calendar/>"),
+		davName("displayname"):                         staticProp(calendarName),
+		davName("current-user-principal"):              hrefProp(principalHref(userID)),
+		davName("owner"):                               hrefProp(principalHref(userID)),
+		davName("sync-token"):                          staticProp(formatSyncToken(token)),
+		calServerName("getctag"):                       staticProp(formatSyncToken(token)),
+		calDAVName("supported-calendar-component-set"): staticProp(`<C:comp name="VTODO"/>`),
+		davName("supported-report-set"): staticProp(
+			"<D:supported-report><D:report><C:calendar-query/></D:report></D:supported-report>" +
+				"<D:supported-report><D:report><C:calendar-multiget/></D:report></D:supported-report>" +
+				"<D:supported-report><D:report><D:sync-collection/></D:report></D:supported-report>",
+		),
+		davName("current-user-privilege-set"): staticProp(
+			"<D:privilege><D:read/></D:privilege><D:privilege><D:write/></D:privilege>" +
+				"<D:privilege><D:write-content/></D:privilege><D:privilege><D:bind/></D:privilege>" +
+				"<D:privilege><D:unbind/></D:privilege>",
+		),
+	}, nil
+}
+
+func taskProps(t *model.Task) davProps {
+	return davProps{
+		davName("resourcetype"):   staticProp(""),
+		davName("getetag"):        staticProp(xmlText(fmt.Sprintf(`"%d"`, t.Version))),
+		davName("getcontenttype"): staticProp(calendarType),
+		calDAVName("calendar-data"): func() string {
+			return xmlText(string(calendarData(t)))
+		},
+	}
+}
+
+func calendarData(t *model.Task) []byte {
+	b := &bytes.Buffer{}
+
+	enc := ical.NewEncoder(b, calendarName)
+	enc.Encode(t)
+	enc.Close()
+
+	return b.Bytes()
+}
+
+// putPreconditions checks If-Match and If-None-Match of a PUT against the
+// stored task, it is nil when the task is new. Replacing a stored task
+// needs If-Match when RequireIfMatch is set, creating one does not.
+func (h *CalDAVHandler) putPreconditions(r *http.Request, stored *model.Task) (int, error) {
+	if strings.TrimSpace(r.Header.Get("If-None-Match")) == "*" && stored != nil {
+		return http.StatusPreconditionFailed, errors.New("the task already exists")
+	}
+
+	if stored == nil {
+		if r.Header.Get("If-Match") != "" {
+			return http.StatusPreconditionFailed, store.ErrRecordNotFound
+		}
+		return 0, nil
+	}
+
+	version, err := h.Tasks.ifMatch(r)
+	if err != nil {
+		return ifMatchErrorCode(err), err
+	}
+
+	if version != nil && *version != stored.Version {
+		return http.StatusPreconditionFailed, store.ErrVersionConflict
+	}
+
+	return 0, nil
+}
+
+// depth returns the Depth header of a request, infinity is read as 1.
+func depth(r *http.Request) int {
+	if strings.TrimSpace(r.Header.Get("Depth")) == "0" {
+		return 0
+	}
+
+	return 1
+}
+
+func formatSyncToken(token int) string {
+	return syncTokenPrefix + strconv.Itoa(token)
+}
+
+// parseSyncToken returns the token of the store of a sync token, 0 for the
+// empty token of a first sync.
+func parseSyncToken(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	n, ok := strings.CutPrefix(s, syncTokenPrefix)
+	if !ok {
+		return 0, errInvalidSyncToken
+	}
+
+	token, err := strconv.Atoi(n)
+	if err != nil || token < 0 {
+		return 0, errInvalidSyncToken
+	}
+
+	return token, nil
+}
+
+func principalHref(userID int) string {
+	return fmt.Sprintf("%sprincipals/%d/", DAVRoot, userID)
+}
+
+func homeHref(userID int) string {
+	return fmt.Sprintf("%scalendars/%d/", DAVRoot, userID)
+}
+
+func calendarHref(userID int) string {
+	return homeHref(userID) + "tasks/"
+}
+
+func taskHref(t *model.Task) string {
+	return calendarHref(t.UserID) + url.PathEscape(ical.TaskUID(t)+".ics")
+}
+
+// taskName returns the resource name of a task href of the calendar of the
+// user.
+func taskName(userID int, href string) (string, bool) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", false
+	}
+
+	dir, name := path.Split(u.Path)
+	if dir != calendarHref(userID) || name == "" {
+		return "", false
+	}
+
+	return name, true
+}
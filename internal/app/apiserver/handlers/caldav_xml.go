@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+)
+
+const (
+	nsDAV       = "DAV:"
+	nsCalDAV    = "urn:ietf:params:xml:ns:caldav"
+	nsCalServer = "http://calendarserver.org/ns/"
+
+	timeRangeLayout = "20060102T150405Z"
+)
+
+// davPrefixes are the prefixes of the namespaces declared on a multistatus.
+var davPrefixes = map[string]string{
+	nsDAV:       "D",
+	nsCalDAV:    "C",
+	nsCalServer: "CS",
+}
+
+func davName(local string) xml.Name {
+	return xml.Name{Space: nsDAV, Local: local}
+}
+
+func calDAVName(local string) xml.Name {
+	return xml.Name{Space: nsCalDAV, Local: local}
+}
+
+func calServerName(local string) xml.Name {
+	return xml.Name{Space: nsCalServer, Local: local}
+}
+
+// davProps are the properties of a resource. A property returns its value
+// as XML, it is only called when the property is asked for.
+type davProps map[xml.Name]func() string
+
+func staticProp(value string) func() string {
+	return func() string {
+		return value
+	}
+}
+
+func hrefProp(href string) func() string {
+	return staticProp("<D:href>" + xmlText(href) + "</D:href>")
+}
+
+func xmlText(s string) string {
+	b := &strings.Builder{}
+	xml.EscapeText(b, []byte(s))
+
+	return b.String()
+}
+
+type davElement struct {
+	XMLName xml.Name
+}
+
+// propSelection is the part of PROPFIND and REPORT requests that tells which
+// properties to return. Without prop and propname all the properties are
+// returned.
+type propSelection struct {
+	PropName *struct{} `xml:"DAV: propname"`
+	Prop     *struct {
+		Names []davElement `xml:",any"`
+	} `xml:"DAV: prop"`
+}
+
+// response returns the properties of a resource that were asked for, the
+// ones the resource does not have are not found. The calendar data is only
+// returned when it is asked for.
+func (s *propSelection) response(href string, props davProps) *davResponse {
+	resp := &davResponse{Href: href}
+
+	switch {
+	case s.PropName != nil:
+		for name := range props {
+			resp.Found = append(resp.Found, davProp{Name: name})
+		}
+	case s.Prop == nil:
+		for name, value := range props {
+			if name != calDAVName("calendar-data") {
+				resp.Found = append(resp.Found, davProp{Name: name, Value: value()})
+			}
+		}
+	default:
+		for _, el := range s.Prop.Names {
+			if value, ok := props[el.XMLName]; ok {
+				resp.Found = append(resp.Found, davProp{Name: el.XMLName, Value: value()})
+			} else {
+				resp.Missing = append(resp.Missing, davProp{Name: el.XMLName})
+			}
+		}
+	}
+
+	sort.Slice(resp.Found, func(i, j int) bool {
+		return resp.Found[i].Name.Space+resp.Found[i].Name.Local < resp.Found[j].Name.Space+resp.Found[j].Name.Local
+	})
+
+	return resp
+}
+
+type propfindRequest struct {
+	XMLName xml.Name `xml:"DAV: propfind"`
+	propSelection
+}
+
+// parsePropfind reads the body of a PROPFIND, an empty body asks for all
+// the properties.
+func parsePropfind(r *http.Request) (*propfindRequest, error) {
+	req := &propfindRequest{}
+
+	b, err := io.ReadAll(io.LimitReader(r.Body, maxCalendarSize))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bytes.TrimSpace(b)) == 0 {
+		return req, nil
+	}
+
+	if err := xml.Unmarshal(b, req); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// reportRequest is the body of the calendar-query, calendar-multiget and
+// sync-collection reports, XMLName tells which one it is.
+type reportRequest struct {
+	XMLName xml.Name
+	propSelection
+	Hrefs     []string        `xml:"DAV: href"`
+	SyncToken string          `xml:"DAV: sync-token"`
+	Filter    *calendarFilter `xml:"urn:ietf:params:xml:ns:caldav filter"`
+}
+
+// calendarFilter is the filter of a calendar-query. The filters of the
+// components and properties of a VTODO are matched against the tasks, the
+// other components are never found.
+type calendarFilter struct {
+	Comp compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+type compFilter struct {
+	Name         string       `xml:"name,attr"`
+	IsNotDefined *struct{}    `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+	TimeRange    *timeRange   `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+	Comps        []compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	Props        []propFilter `xml:"urn:ietf:params:xml:ns:caldav prop-filter"`
+}
+
+type timeRange struct {
+	Start string `xml:"start,attr"`
+	End   string `xml:"end,attr"`
+}
+
+type propFilter struct {
+	Name         string     `xml:"name,attr"`
+	IsNotDefined *struct{}  `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+	TextMatch    *textMatch `xml:"urn:ietf:params:xml:ns:caldav text-match"`
+}
+
+type textMatch struct {
+	Text   string `xml:",chardata"`
+	Negate string `xml:"negate-condition,attr"`
+}
+
+func (f *calendarFilter) match(t *model.Task) bool {
+	if !strings.EqualFold(f.Comp.Name, "VCALENDAR") || f.Comp.IsNotDefined != nil {
+		return false
+	}
+
+	for _, c := range f.Comp.Comps {
+		if !strings.EqualFold(c.Name, "VTODO") {
+			if c.IsNotDefined == nil {
+				return false
+			}
+			continue
+		}
+
+		if !c.matchTodo(t) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *compFilter) matchTodo(t *model.Task) bool {
+	if c.IsNotDefined != nil {
+		return false
+	}
+
+	if c.TimeRange != nil && !c.TimeRange.match(t) {
+		return false
+	}
+
+	// a task has no alarms or other components
+	for _, sub := range c.Comps {
+		if sub.IsNotDefined == nil {
+			return false
+		}
+	}
+
+	for _, p := range c.Props {
+		if !p.match(t) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// match checks the deadline of the task against the range, a task without
+// a deadline is in every range.
+func (tr *timeRange) match(t *model.Task) bool {
+	if t.Deadline == nil {
+		return true
+	}
+
+	if start, err := time.Parse(timeRangeLayout, tr.Start); err == nil && t.Deadline.Before(start) {
+		return false
+	}
+
+	if end, err := time.Parse(timeRangeLayout, tr.End); err == nil && !t.Deadline.Before(end) {
+		return false
+	}
+
+	return true
+}
+
+func (p *propFilter) match(t *model.Task) bool {
+	value, defined := todoProperty(t, strings.ToUpper(p.Name))
+
+	if p.IsNotDefined != nil {
+		return !defined
+	}
+
+	if p.TextMatch == nil {
+		return defined
+	}
+
+	found := defined && strings.Contains(strings.ToLower(value), strings.ToLower(p.TextMatch.Text))
+
+	return found != (p.TextMatch.Negate == "yes")
+}
+
+// todoProperty returns the value of a property of the VTODO of the task.
+func todoProperty(t *model.Task, name string) (string, bool) {
+	complete := t.Complete != nil && *t.Complete
+
+	switch name {
+	case "SUMMARY":
+		return derefString(t.Title), t.Title != nil
+	case "DESCRIPTION":
+		return derefString(t.Description), t.Description != nil && *t.Description != ""
+	case "STATUS":
+		if complete {
+			return "COMPLETED", true
+		}
+		return "NEEDS-ACTION", true
+	case "COMPLETED":
+		return "", complete
+	case "DUE":
+		if t.Deadline == nil {
+			return "", false
+		}
+		return t.Deadline.UTC().Format(timeRangeLayout), true
+	case "CATEGORIES":
+		return strings.Join(t.Tags, ","), len(t.Tags) > 0
+	}
+
+	return "", false
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
+
+type davProp struct {
+	Name  xml.Name
+	Value string
+}
+
+// davResponse is a response of a multistatus, a resource that was not
+// found has only a status.
+type davResponse struct {
+	Href    string
+	Status  int
+	Found   []davProp
+	Missing []davProp
+}
+
+func writeMultistatus(w http.ResponseWriter, responses []*davResponse, syncToken string) {
+	b := &strings.Builder{}
+
+	b.WriteString(xml.Header)
+	fmt.Fprintf(b, `<D:multistatus xmlns:D=%q xmlns:C=%q xmlns:CS=%q>`, nsDAV, nsCalDAV, nsCalServer)
+
+	for _, resp := range responses {
+		b.WriteString("<D:response><D:href>" + xmlText(resp.Href) + "</D:href>")
+
+		if resp.Status != 0 {
+			writeStatus(b, resp.Status)
+		}
+
+		writePropstat(b, resp.Found, http.StatusOK)
+		writePropstat(b, resp.Missing, http.StatusNotFound)
+
+		b.WriteString("</D:response>")
+	}
+
+	if syncToken != "" {
+		b.WriteString("<D:sync-token>" + xmlText(syncToken) + "</D:sync-token>")
+	}
+
+	b.WriteString("</D:multistatus>")
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	io.WriteString(w, b.String())
+}
+
+func writePropstat(b *strings.Builder, props []davProp, status int) {
+	if len(props) == 0 {
+		return
+	}
+
+	b.WriteString("<D:propstat><D:prop>")
+
+	for _, p := range props {
+		name, decl := elementName(p.Name)
+
+		if p.Value == "" {
+			b.WriteString("<" + name + decl + "/>")
+			continue
+		}
+
+		b.WriteString("<" + name + decl + ">" + p.Value + "</" + name + ">")
+	}
+
+	b.WriteString("</D:prop>")
+	writeStatus(b, status)
+	b.WriteString("</D:propstat>")
+}
+
+func writeStatus(b *strings.Builder, status int) {
+	fmt.Fprintf(b, "<D:status>HTTP/1.1 %d %s</D:status>", status, http.StatusText(status))
+}
+
+// elementName returns the prefixed name of an element and the declaration
+// of its namespace when it is not one of davPrefixes.
+func elementName(name xml.Name) (string, string) {
+	if prefix, ok := davPrefixes[name.Space]; ok {
+		return prefix + ":" + name.Local, ""
+	}
+
+	if name.Space == "" {
+		return name.Local, ""
+	}
+
+	return "X:" + name.Local, fmt.Sprintf(` xmlns:X="%s"`, xmlText(name.Space))
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/middleware"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+)
+
+var errNotCommentAuthor = errors.New("only the author may edit the comment")
+
+type commentRequest struct {
+	Body string `json:"body"`
+}
+
+// GetComments returns a page of the comments of the task, the oldest ones
+// first.
+func (h *TaskHandler) GetComments(userID int, taskID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, taskID, model.RoleViewer); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		// the comments of the tasks of the other workspaces are not found
+		if _, err := todos(h.Store, r).FindByID(userID, taskID); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		q, err := parseCommentQuery(r.URL.Query())
+		if err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		page, err := h.Store.Comment().FindByTask(userID, taskID, q)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		w.Header().Set("Link", pageLinks(r.URL, page.NextCursor))
+
+		h.Respond(w, r, http.StatusOK, page)
+	}
+}
+
+// CreateComment adds a comment of the authenticated user to the task, the
+// editors of the task may comment on it.
+func (h *TaskHandler) CreateComment(userID int, taskID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, taskID, model.RoleEditor); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		// the comments of the tasks of the other workspaces are not found
+		if _, err := todos(h.Store, r).FindByID(userID, taskID); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		req := &commentRequest{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		c := &model.Comment{
+			UserID:   userID,
+			TaskID:   taskID,
+			AuthorID: authUser.ID,
+			Body:     req.Body,
+		}
+
+		if err := h.Store.Comment().Create(c); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusCreated, c)
+	}
+}
+
+// UpdateComment changes the body of a comment, only its author may edit it.
+func (h *TaskHandler) UpdateComment(userID int, taskID int, commentID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, taskID, model.RoleEditor); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		c, err := h.findComment(r, userID, taskID, commentID)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if c.AuthorID != authUser.ID {
+			h.Error(w, r, http.StatusForbidden, errNotCommentAuthor)
+			return
+		}
+
+		req := &commentRequest{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		c.Body = req.Body
+
+		if err := h.Store.Comment().Update(c); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, c)
+	}
+}
+
+// DeleteComment deletes a comment. The author may delete it and so may the
+// owners of the task, to moderate the discussion.
+func (h *TaskHandler) DeleteComment(userID int, taskID int, commentID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, taskID, model.RoleEditor); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		c, err := h.findComment(r, userID, taskID, commentID)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if c.AuthorID != authUser.ID {
+			if err := h.authorize(r, userID, taskID, model.RoleOwner); err != nil {
+				h.Error(w, r, accessErrorCode(err), err)
+				return
+			}
+		}
+
+		if err := h.Store.Comment().Delete(userID, taskID, commentID); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// findComment returns a comment of a task of the active workspace.
+func (h *TaskHandler) findComment(r *http.Request, userID int, taskID int, commentID int) (*model.Comment, error) {
+	if _, err := todos(h.Store, r).FindByID(userID, taskID); err != nil {
+		return nil, err
+	}
+
+	return h.Store.Comment().FindByID(userID, taskID, commentID)
+}
+
+func parseCommentQuery(values url.Values) (*model.CommentQuery, error) {
+	for key := range values {
+		if key != "limit" && key != "cursor" {
+			return nil, fmt.Errorf("unknown query parameter %q", key)
+		}
+	}
+
+	q := model.NewCommentQuery()
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return nil, errors.New("invalid limit")
+		}
+		q.Limit = n
+	}
+
+	if cursor := values.Get("cursor"); cursor != "" {
+		id, err := model.DecodeCommentCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		q.AfterID = id
+	}
+
+	if err := q.Validation(); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+)
+
+type dependencyRequest struct {
+	BlockedBy int `json:"blocked_by"`
+}
+
+// AddDependency makes the task blocked by another task of the user. The
+// task cannot be completed while the other task is open.
+func (h *TaskHandler) AddDependency(userID int, taskID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, taskID, model.RoleEditor); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		req := &dependencyRequest{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if req.BlockedBy <= 0 {
+			h.Error(w, r, http.StatusUnprocessableEntity, errors.New("blocked_by is required"))
+			return
+		}
+
+		if err := h.authorize(r, userID, req.BlockedBy, model.RoleViewer); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		if err := todos(h.Store, r).AddDependency(userID, taskID, req.BlockedBy); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		t, err := todos(h.Store, r).FindByID(userID, taskID)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusCreated, t)
+	}
+}
+
+func (h *TaskHandler) RemoveDependency(userID int, taskID int, blockedByID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, taskID, model.RoleEditor); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		if err := todos(h.Store, r).RemoveDependency(userID, taskID, blockedByID); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// GetOrderedTasks returns the tasks of the user in an order in which they
+// can be done: every task comes after the tasks that block it. The complete
+// parameter filters the tasks the same way the listing does.
+func (h *TaskHandler) GetOrderedTasks(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, 0, model.RoleViewer); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		complete, err := parseBoolParam(r.URL.Query(), "complete")
+		if err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		tasks := []*model.Task{}
+
+		if err := todos(h.Store, r).Export(userID, func(t *model.Task) error {
+			if complete == nil || (t.Complete != nil && *t.Complete) == *complete {
+				tasks = append(tasks, t)
+			}
+			return nil
+		}); err != nil {
+			h.Error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, model.SortTopologically(tasks))
+	}
+}
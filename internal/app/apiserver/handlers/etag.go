@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+)
+
+var (
+	errPreconditionRequired = errors.New("the request must be conditional: send If-Match with the ETag of the task")
+	errInvalidIfMatch       = errors.New("If-Match must be * or a single ETag of the task")
+	errBulkDeleteNotAllowed = errors.New("a bulk delete cannot be conditional: delete the tasks one by one with If-Match or in a batch with their versions")
+)
+
+// setETag sets the ETag of the response to the version of the task.
+func setETag(w http.ResponseWriter, t *model.Task) {
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, t.Version))
+}
+
+// ifMatch returns the version of the task required by the If-Match header
+// of the request. It is nil when the header is missing or matches any
+// version, a missing header is an error when RequireIfMatch is set.
+func (h *TaskHandler) ifMatch(r *http.Request) (*int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+
+	if header == "" && h.RequireIfMatch {
+		return nil, errPreconditionRequired
+	}
+
+	return parseIfMatch(header)
+}
+
+// parseIfMatch returns the version of an If-Match header, nil when it is
+// empty or *.
+func parseIfMatch(header string) (*int, error) {
+	header = strings.TrimSpace(header)
+
+	switch header {
+	case "", "*":
+		return nil, nil
+	}
+
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return nil, errInvalidIfMatch
+	}
+
+	version, err := strconv.Atoi(header[1 : len(header)-1])
+	if err != nil {
+		return nil, errInvalidIfMatch
+	}
+
+	return &version, nil
+}
+
+// ifMatchErrorCode maps errors of ifMatch to response codes.
+func ifMatchErrorCode(err error) int {
+	if errors.Is(err, errPreconditionRequired) {
+		return http.StatusPreconditionRequired
+	}
+
+	return http.StatusBadRequest
+}
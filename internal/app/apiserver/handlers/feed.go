@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/ical"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/middleware"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+// FeedHandler manages the calendar feed of a user and serves it. The feed
+// is read without a bearer token, the secret URL is the credential.
+type FeedHandler struct {
+	Store store.Store
+	// RateLimit limits the reads of each feed, the requests with an unknown
+	// token are limited by the address they come from.
+	RateLimit *middleware.RateLimiter
+	Respond   func(http.ResponseWriter, *http.Request, int, interface{})
+	Error     func(http.ResponseWriter, *http.Request, int, error)
+}
+
+// feedResponse adds the URL of the feed, it is only known together with
+// the token.
+type feedResponse struct {
+	*model.Feed
+	URL string `json:"url,omitempty"`
+}
+
+func (h *FeedHandler) GetFeed(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Error(w, r, http.StatusForbidden, errAccessDenied)
+			return
+		}
+
+		f, err := h.Store.Feed().FindByUser(userID)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, f)
+	}
+}
+
+// CreateFeed creates the feed of the user, or rotates its URL when it
+// already has one.
+func (h *FeedHandler) CreateFeed(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Error(w, r, http.StatusForbidden, errAccessDenied)
+			return
+		}
+
+		f := &model.Feed{UserID: userID}
+
+		if err := h.Store.Feed().Save(f); err != nil {
+			h.Error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusCreated, &feedResponse{Feed: f, URL: feedURL(r, f.Token)})
+	}
+}
+
+// DeleteFeed revokes the feed of the user.
+func (h *FeedHandler) DeleteFeed(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Error(w, r, http.StatusForbidden, errAccessDenied)
+			return
+		}
+
+		if err := h.Store.Feed().Delete(userID); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// ServeFeed writes the tasks of the personal workspace of the owner of the
+// token as an iCalendar file. An unknown token is not found, like any other
+// wrong URL.
+func (h *FeedHandler) ServeFeed(token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		f, err := h.Store.Feed().FindByToken(token)
+		if err != nil {
+			if !h.RateLimit.Allow(w, "addr:"+middleware.ClientAddr(r)) {
+				return
+			}
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		if !h.RateLimit.Allow(w, "feed:"+token) {
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Cache-Control", "private, max-age=300")
+		clearWriteDeadline(w)
+		w.WriteHeader(http.StatusOK)
+
+		if r.Method == http.MethodHead {
+			return
+		}
+
+		enc := ical.NewEncoder(w, "Tasks")
+
+		if err := h.Store.Todo().Export(f.UserID, enc.Encode); err != nil {
+			// the status is already sent, the client has to see a broken
+			// response instead of a calendar that looks complete
+			panic(http.ErrAbortHandler)
+		}
+
+		if err := enc.Close(); err != nil {
+			panic(http.ErrAbortHandler)
+		}
+	}
+}
+
+func feedURL(r *http.Request, token string) string {
+	return fmt.Sprintf("%s/feed/%s.ics", baseURL(r), token)
+}
+
+// baseURL returns the scheme and host the request was made to, for the URLs
+// that are handed out to be opened without the API.
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return fmt.Sprintf("%s://%s", scheme, r.Host)
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+type ListHandler struct {
+	Store   store.Store
+	Respond func(http.ResponseWriter, *http.Request, int, interface{})
+	Error   func(http.ResponseWriter, *http.Request, int, error)
+}
+
+func (h *ListHandler) GetLists(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, 0, model.RoleViewer); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		lists, err := lists(h.Store, r).FindAll(userID)
+		if err != nil {
+			h.Error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, lists)
+	}
+}
+
+func (h *ListHandler) GetListByID(userID int, listID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, listID, model.RoleViewer); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		l, err := lists(h.Store, r).FindByID(userID, listID)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, l)
+	}
+}
+
+func (h *ListHandler) CreateList(userID int) http.HandlerFunc {
+	type request struct {
+		Name string `json:"name"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, 0, model.RoleEditor); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		l := &model.List{
+			UserID: userID,
+			Name:   req.Name,
+		}
+
+		if err := lists(h.Store, r).Create(l); err != nil {
+			h.Error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusCreated, l)
+	}
+}
+
+func (h *ListHandler) UpdateList(userID int, listID int) http.HandlerFunc {
+	type request struct {
+		Name string `json:"name"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, listID, model.RoleEditor); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		l := &model.List{
+			ID:     listID,
+			UserID: userID,
+			Name:   req.Name,
+		}
+
+		if err := lists(h.Store, r).Update(l); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, l)
+	}
+}
+
+func (h *ListHandler) DeleteList(userID int, listID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, listID, model.RoleOwner); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		if err := lists(h.Store, r).Delete(userID, listID); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusNoContent, nil)
+	}
+}
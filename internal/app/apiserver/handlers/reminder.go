@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+)
+
+func (h *TaskHandler) GetReminders(userID int, taskID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, taskID, model.RoleViewer); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		// the reminders of the tasks of the other workspaces are not found
+		if _, err := todos(h.Store, r).FindByID(userID, taskID); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		reminders, err := h.Store.Reminder().FindByTask(userID, taskID)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, reminders)
+	}
+}
+
+func (h *TaskHandler) CreateReminder(userID int, taskID int) http.HandlerFunc {
+	type request struct {
+		Offset string `json:"offset"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, taskID, model.RoleEditor); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		// the reminders of the tasks of the other workspaces are not found
+		if _, err := todos(h.Store, r).FindByID(userID, taskID); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		offset, err := model.ParseReminderOffset(req.Offset)
+		if err != nil {
+			h.Error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		rem := &model.Reminder{
+			UserID: userID,
+			TaskID: taskID,
+			Offset: offset,
+		}
+
+		if err := h.Store.Reminder().Create(rem); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusCreated, rem)
+	}
+}
+
+func (h *TaskHandler) DeleteReminder(userID int, taskID int, reminderID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, taskID, model.RoleEditor); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		// the reminders of the tasks of the other workspaces are not found
+		if _, err := todos(h.Store, r).FindByID(userID, taskID); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		if err := h.Store.Reminder().Delete(userID, taskID, reminderID); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusNoContent, nil)
+	}
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/middleware"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+// ShareHandler shares the lists and tasks of a user with other users and
+// answers the invitations of a user.
+type ShareHandler struct {
+	Store   store.Store
+	Respond func(http.ResponseWriter, *http.Request, int, interface{})
+	Error   func(http.ResponseWriter, *http.Request, int, error)
+}
+
+// GetShares returns the shares of the lists and tasks of the user.
+func (h *ShareHandler) GetShares(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Error(w, r, http.StatusForbidden, errAccessDenied)
+			return
+		}
+
+		shares, err := h.Store.Share().FindByOwner(userID)
+		if err != nil {
+			h.Error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, shares)
+	}
+}
+
+// CreateShare invites a user by email to a list or a task of the user. The
+// user and the owners of the item may invite, inviting a user again changes
+// their role.
+func (h *ShareHandler) CreateShare(userID int) http.HandlerFunc {
+	type request struct {
+		Email  string `json:"email"`
+		Role   string `json:"role"`
+		ListID *int   `json:"list_id"`
+		TaskID *int   `json:"task_id"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		s := &model.Share{
+			OwnerID:   userID,
+			ListID:    req.ListID,
+			TaskID:    req.TaskID,
+			Email:     req.Email,
+			Role:      req.Role,
+			InvitedBy: authUser.ID,
+		}
+
+		if err := h.authorize(r, s); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		if err := h.checkItem(r, s); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		grantee, err := h.Store.User().FindByEmail(req.Email)
+		if err != nil {
+			if errors.Is(err, store.ErrRecordNotFound) {
+				h.Error(w, r, http.StatusUnprocessableEntity, errors.New("no user with the email"))
+				return
+			}
+			h.Error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.GranteeID = grantee.ID
+
+		if err := h.Store.Share().Create(s); err != nil {
+			h.Error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusCreated, s)
+	}
+}
+
+// DeleteShare revokes a share of a list or a task of the user.
+func (h *ShareHandler) DeleteShare(userID int, shareID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		s, err := h.Store.Share().FindByID(shareID)
+		if err != nil || s.OwnerID != userID {
+			h.Error(w, r, http.StatusNotFound, store.ErrRecordNotFound)
+			return
+		}
+
+		if err := h.authorize(r, s); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		if err := h.Store.Share().Delete(userID, shareID); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// GetInvitations returns the shares of other users with the user, only the
+// ones with the status of the status parameter when it is set.
+func (h *ShareHandler) GetInvitations(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Error(w, r, http.StatusForbidden, errAccessDenied)
+			return
+		}
+
+		shares, err := h.Store.Share().FindByGrantee(userID)
+		if err != nil {
+			h.Error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		status := r.URL.Query().Get("status")
+
+		invitations := []*model.Share{}
+		for _, s := range shares {
+			if status == "" || s.Status == status {
+				invitations = append(invitations, s)
+			}
+		}
+
+		h.Respond(w, r, http.StatusOK, invitations)
+	}
+}
+
+// AnswerInvitation accepts or declines an invitation of the user. Declining
+// an accepted invitation leaves the share, a declined invitation cannot be
+// accepted until the user is invited again.
+func (h *ShareHandler) AnswerInvitation(userID int, shareID int, status string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Error(w, r, http.StatusForbidden, errAccessDenied)
+			return
+		}
+
+		s, err := h.Store.Share().FindByID(shareID)
+		if err != nil || s.GranteeID != userID {
+			h.Error(w, r, http.StatusNotFound, store.ErrRecordNotFound)
+			return
+		}
+
+		if status == model.ShareStatusAccepted && s.Status == model.ShareStatusDeclined {
+			h.Error(w, r, http.StatusConflict, errors.New("the invitation has been declined"))
+			return
+		}
+
+		if err := h.Store.Share().SetStatus(shareID, userID, status); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		s.Status = status
+
+		h.Respond(w, r, http.StatusOK, s)
+	}
+}
+
+// authorize checks that the user may share the item of the share, the user
+// needs to be an owner of it.
+func (h *ShareHandler) authorize(r *http.Request, s *model.Share) error {
+	switch {
+	case s.TaskID != nil:
+		return authorizeTask(h.Store, r, s.OwnerID, *s.TaskID, model.RoleOwner)
+	case s.ListID != nil:
+		return authorizeList(h.Store, r, s.OwnerID, *s.ListID, model.RoleOwner)
+	}
+
+	return authorizeTask(h.Store, r, s.OwnerID, 0, model.RoleOwner)
+}
+
+// checkItem makes sure that the item of the share is one of its owner in
+// the active workspace.
+func (h *ShareHandler) checkItem(r *http.Request, s *model.Share) error {
+	var err error
+
+	if s.TaskID != nil {
+		_, err = todos(h.Store, r).FindByID(s.OwnerID, *s.TaskID)
+	}
+
+	if s.ListID != nil && err == nil {
+		_, err = lists(h.Store, r).FindByID(s.OwnerID, *s.ListID)
+	}
+
+	return err
+}
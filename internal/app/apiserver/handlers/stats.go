@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+)
+
+// GetStats returns the statistics of the tasks of the user. Every windows
+// parameter adds the counts of a window of that many days, the last 7 and
+// 30 days by default.
+func (h *TaskHandler) GetStats(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, 0, model.RoleViewer); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		q, err := parseStatsQuery(r.URL.Query())
+		if err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		stats, err := todos(h.Store, r).Stats(userID, q)
+		if err != nil {
+			h.Error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, stats)
+	}
+}
+
+func parseStatsQuery(values url.Values) (*model.TaskStatsQuery, error) {
+	for key := range values {
+		if key != "windows" {
+			return nil, fmt.Errorf("unknown query parameter %q", key)
+		}
+	}
+
+	q := model.NewTaskStatsQuery(time.Now())
+
+	if values.Has("windows") {
+		q.Windows = nil
+		for _, s := range values["windows"] {
+			days, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, errors.New("invalid windows: use a number of days")
+			}
+			q.Windows = append(q.Windows, days)
+		}
+	}
+
+	if err := q.Validation(); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/middleware"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+type TagHandler struct {
+	Store   store.Store
+	Respond func(http.ResponseWriter, *http.Request, int, interface{})
+	Error   func(http.ResponseWriter, *http.Request, int, error)
+}
+
+func (h *TagHandler) GetTags(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Error(w, r, http.StatusForbidden, errors.New("access denied"))
+			return
+		}
+
+		tags, err := h.Store.Tag().FindAll(userID)
+		if err != nil {
+			h.Error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, tags)
+	}
+}
+
+func (h *TagHandler) CreateTag(userID int) http.HandlerFunc {
+	type request struct {
+		Name string `json:"name"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Error(w, r, http.StatusForbidden, errors.New("access denied"))
+			return
+		}
+
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		t := &model.Tag{
+			UserID: userID,
+			Name:   req.Name,
+		}
+
+		if err := h.Store.Tag().Create(t); err != nil {
+			h.Error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusCreated, t)
+	}
+}
+
+func (h *TagHandler) UpdateTag(userID int, tagID int) http.HandlerFunc {
+	type request struct {
+		Name string `json:"name"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Error(w, r, http.StatusForbidden, errors.New("access denied"))
+			return
+		}
+
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		t := &model.Tag{
+			ID:     tagID,
+			UserID: userID,
+			Name:   req.Name,
+		}
+
+		if err := h.Store.Tag().Update(t); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, t)
+	}
+}
+
+func (h *TagHandler) DeleteTag(userID int, tagID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Error(w, r, http.StatusForbidden, errors.New("access denied"))
+			return
+		}
+
+		if err := h.Store.Tag().Delete(userID, tagID); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusNoContent, nil)
+	}
+}
@@ -3,19 +3,28 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/middleware"
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/services"
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/todo"
 )
 
 type TaskHandler struct {
 	Store        store.Store
 	TokenService services.TokenService
-	Respond      func(http.ResponseWriter, *http.Request, int, interface{})
-	Error        func(http.ResponseWriter, *http.Request, int, error)
+	Events       services.EventPublisher
+	// RequireIfMatch refuses the writes of a task that do not send If-Match.
+	RequireIfMatch bool
+	Respond        func(http.ResponseWriter, *http.Request, int, interface{})
+	Error          func(http.ResponseWriter, *http.Request, int, error)
 }
 
 func (h *TaskHandler) GetTask(userID int) http.HandlerFunc {
@@ -25,92 +34,400 @@ func (h *TaskHandler) GetTask(userID int) http.HandlerFunc {
 			return
 		}
 
-		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+		if err := h.authorize(r, userID, 0, model.RoleViewer); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
 
-		if authUser.ID != userID {
-			h.Error(w, r, http.StatusForbidden, errors.New("access denied"))
+		q, err := parseTaskQuery(r.URL.Query())
+		if err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
 			return
 		}
 
-		tasks, err := h.Store.Todo().Get(userID)
+		page, err := todos(h.Store, r).Get(userID, q)
 		if err != nil {
-			h.Error(w, r, http.StatusNotFound, err)
+			h.Error(w, r, http.StatusInternalServerError, err)
 			return
 		}
 
-		h.Respond(w, r, http.StatusOK, tasks)
+		w.Header().Set("Link", pageLinks(r.URL, page.NextCursor))
+
+		h.Respond(w, r, http.StatusOK, page)
 	}
 }
 
-func (h *TaskHandler) CreateTask(userID int) http.HandlerFunc {
-	type request struct {
-		UserID      int              `json:"user_id"`
-		Title       string           `json:"title"`
-		Description string           `json:"description"`
-		Deadline    model.CustomTime `json:"deadline"`
-		Complete    bool            `json:"complete,omitempty"`
+func (h *TaskHandler) GetTaskByID(userID int, taskID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, taskID, model.RoleViewer); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		t, err := todos(h.Store, r).FindByID(userID, taskID)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		setETag(w, t)
+
+		h.Respond(w, r, http.StatusOK, t)
 	}
+}
 
+func (h *TaskHandler) GetTaskTree(userID int, taskID int) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
+		if r.Method != http.MethodGet {
 			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
 			return
 		}
 
-		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+		if err := h.authorize(r, userID, taskID, model.RoleViewer); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
 
-		if authUser.ID != userID {
-			h.Error(w, r, http.StatusForbidden, errors.New("access denied"))
+		t, err := todos(h.Store, r).FindTree(userID, taskID)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
 			return
 		}
 
-		req := &request{}
+		h.Respond(w, r, http.StatusOK, t)
+	}
+}
+
+func (h *TaskHandler) SearchTask(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, 0, model.RoleViewer); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		values := r.URL.Query()
+		for key := range values {
+			if key != "q" && key != "limit" {
+				h.Error(w, r, http.StatusBadRequest, fmt.Errorf("unknown query parameter %q", key))
+				return
+			}
+		}
+
+		text := strings.TrimSpace(values.Get("q"))
+		if text == "" {
+			h.Error(w, r, http.StatusBadRequest, errors.New("search query cannot be empty"))
+			return
+		}
+
+		limit := model.DefaultTaskLimit
+		if values.Has("limit") {
+			n, err := strconv.Atoi(values.Get("limit"))
+			if err != nil || n < 1 || n > model.MaxTaskLimit {
+				h.Error(w, r, http.StatusBadRequest, fmt.Errorf("limit must be between 1 and %d", model.MaxTaskLimit))
+				return
+			}
+			limit = n
+		}
+
+		results, err := todos(h.Store, r).Search(userID, text, limit)
+		if err != nil {
+			h.Error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, results)
+	}
+}
+
+// createTaskRequest is the body of CreateTask and the task of a create
+// operation of a batch.
+type createTaskRequest struct {
+	UserID       int              `json:"user_id"`
+	ListID       *int             `json:"list_id,omitempty"`
+	ParentTaskID *int             `json:"parent_task_id,omitempty"`
+	Title        string           `json:"title"`
+	Description  string           `json:"description"`
+	Deadline     model.CustomTime `json:"deadline"`
+	Complete     *bool            `json:"complete,omitempty"`
+	Status       *string          `json:"status,omitempty"`
+	Tags         []string         `json:"tags,omitempty"`
+	RRule        *string          `json:"rrule,omitempty"`
+}
+
+func (req *createTaskRequest) task(userID int, actorID int) *model.Task {
+	return &model.Task{
+		UserID:       userID,
+		ListID:       req.ListID,
+		ParentTaskID: req.ParentTaskID,
+		Title:        &req.Title,
+		Description:  &req.Description,
+		Deadline:     &req.Deadline.Time,
+		Complete:     req.Complete,
+		Status:       req.Status,
+		Tags:         model.NormalizeTags(req.Tags),
+		RRule:        req.RRule,
+		ActorID:      actorID,
+	}
+}
+
+func (h *TaskHandler) CreateTask(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		req := &createTaskRequest{}
 
 		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
 			h.Error(w, r, http.StatusBadRequest, err)
 			return
 		}
 
-		t := &model.Task{
-			UserID:      userID,
-			Title:       &req.Title,
-			Description: &req.Description,
-			Deadline:    &req.Deadline.Time,
-			Complete:    &req.Complete,
-		}
+		t := req.task(userID, authUser.ID)
 
-		if err := t.Validation(r.Method); err != nil {
-			h.Error(w, r, http.StatusUnprocessableEntity, err)
+		// the other users create tasks in the lists and under the tasks
+		// that are shared with them
+		if err := h.authorizePlace(r, t); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
 			return
 		}
 
-		if err := h.Store.Todo().Create(t); err != nil {
-			h.Error(w, r, http.StatusUnprocessableEntity, err)
+		if code, err := h.createTask(todos(h.Store, r), t); err != nil {
+			h.Error(w, r, code, err)
 			return
 		}
 
+		h.publish(model.EventTaskCreated, t)
+
+		setETag(w, t)
+
 		h.Respond(w, r, http.StatusCreated, t)
 	}
 }
 
+// createTask checks the new task and stores it with the repository. The
+// code is the response code for the error.
+func (h *TaskHandler) createTask(repo todo.TodoRepository, t *model.Task) (int, error) {
+	if err := h.placeInTree(repo, t, false); err != nil {
+		return storeErrorCode(err), err
+	}
+
+	w, err := h.workflow(repo, t.UserID, t.ListID)
+	if err != nil {
+		return http.StatusUnprocessableEntity, err
+	}
+
+	// the status sets the complete flag that Validation checks
+	if err := w.Apply(t, nil); err != nil {
+		return http.StatusUnprocessableEntity, err
+	}
+
+	if err := t.Validation(http.MethodPost); err != nil {
+		return http.StatusUnprocessableEntity, err
+	}
+
+	if err := repo.Create(t); err != nil {
+		return storeErrorCode(err), err
+	}
+
+	return http.StatusCreated, nil
+}
+
+// updateTaskRequest is the body of UpdateTask and the task of an update
+// operation of a batch.
+type updateTaskRequest struct {
+	ListID           *int              `json:"list_id,omitempty"`
+	ParentTaskID     nullableID        `json:"parent_task_id,omitempty"`
+	Title            *string           `json:"title,omitempty"`
+	Description      *string           `json:"description,omitempty"`
+	Deadline         *model.CustomTime `json:"deadline,omitempty"`
+	Complete         *bool             `json:"complete,omitempty"`
+	Status           *string           `json:"status,omitempty"`
+	Tags             []string          `json:"tags,omitempty"`
+	RRule            *string           `json:"rrule,omitempty"`
+	CompleteSubtasks bool              `json:"complete_subtasks,omitempty"`
+	Force            bool              `json:"force,omitempty"`
+}
+
+func (req *updateTaskRequest) task(userID int, taskID int, actorID int) *model.Task {
+	t := &model.Task{
+		UserID:       userID,
+		TaskID:       taskID,
+		ListID:       req.ListID,
+		ParentTaskID: req.ParentTaskID.ID,
+		Title:        req.Title,
+		Description:  req.Description,
+		Complete:     req.Complete,
+		Status:       req.Status,
+		ActorID:      actorID,
+	}
+
+	if req.Deadline != nil {
+		t.Deadline = &req.Deadline.Time
+	}
+
+	// a null parent moves the task to the top level
+	t.ClearParent = req.ParentTaskID.Set && req.ParentTaskID.ID == nil
+
+	// a present tags array replaces the tags of the task, an empty one
+	// detaches all of them
+	if req.Tags != nil {
+		t.Tags = model.NormalizeTags(req.Tags)
+	}
+
+	// an empty rule stops the recurrence
+	t.RRule = req.RRule
+
+	t.CompleteSubtasks = req.CompleteSubtasks
+	t.ForceComplete = req.Force
+
+	return t
+}
+
+// nullableID is an ID of a request body that can be set to null. Unlike a
+// *int it tells a null apart from a missing field.
+type nullableID struct {
+	Set bool
+	ID  *int
+}
+
+func (n *nullableID) UnmarshalJSON(b []byte) error {
+	n.Set = true
+	return json.Unmarshal(b, &n.ID)
+}
+
 func (h *TaskHandler) UpdateTask(userID int, taskID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if err := h.authorize(r, userID, taskID, model.RoleEditor); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		version, err := h.ifMatch(r)
+		if err != nil {
+			h.Error(w, r, ifMatchErrorCode(err), err)
+			return
+		}
+
+		req := &updateTaskRequest{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		t := req.task(userID, taskID, authUser.ID)
+		t.IfVersion = version
+
+		if err := h.authorizeMove(r, t, true); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		before, code, err := h.updateTask(todos(h.Store, r), t)
+		if err != nil {
+			h.Error(w, r, code, err)
+			return
+		}
+
+		h.publishUpdate(todos(h.Store, r), t, completes(before, t))
+
+		setETag(w, t)
+
+		h.Respond(w, r, http.StatusOK, nil)
+	}
+}
+
+// updateTask checks the changes of the task and stores them with the
+// repository. It returns the task as it was before the changes, the code is
+// the response code for the error.
+func (h *TaskHandler) updateTask(repo todo.TodoRepository, t *model.Task) (*model.Task, int, error) {
+	if t.ParentTaskID != nil {
+		if err := h.placeInTree(repo, t, true); err != nil {
+			return nil, storeErrorCode(err), err
+		}
+	}
+
+	before, err := repo.FindByID(t.UserID, t.TaskID)
+	if err != nil {
+		return nil, storeErrorCode(err), err
+	}
+
+	listID := t.ListID
+	if listID == nil {
+		listID = before.ListID
+	}
+
+	w, err := h.workflow(repo, t.UserID, listID)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity, err
+	}
+
+	// the status sets the complete flag that Validation checks
+	if err := w.Apply(t, before); err != nil {
+		return nil, http.StatusUnprocessableEntity, err
+	}
+
+	if err := t.Validation(http.MethodPatch); err != nil {
+		return nil, http.StatusUnprocessableEntity, err
+	}
+
+	if err := repo.Update(t); err != nil {
+		return nil, storeErrorCode(err), err
+	}
+
+	return before, http.StatusOK, nil
+}
+
+func (h *TaskHandler) ReplaceTask(userID int, taskID int) http.HandlerFunc {
 	type request struct {
-		Title       *string           `json:"title,omitempty"`
-		Description *string           `json:"description,omitempty"`
-		Deadline    *model.CustomTime `json:"deadline,omitempty"`
-		Complete    *bool            `json:"complete,omitempty"`
+		ListID       *int             `json:"list_id"`
+		ParentTaskID *int             `json:"parent_task_id"`
+		Title        string           `json:"title"`
+		Description  *string          `json:"description"`
+		Deadline     model.CustomTime `json:"deadline"`
+		Complete     *bool            `json:"complete"`
+		Status       *string          `json:"status"`
+		Tags         []string         `json:"tags"`
+		RRule        *string          `json:"rrule"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPatch {
+		if r.Method != http.MethodPut {
 			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
 			return
 		}
 
 		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
 
-		if authUser.ID != userID {
-			h.Error(w, r, http.StatusForbidden, errors.New("access denied"))
+		if err := h.authorize(r, userID, taskID, model.RoleEditor); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		version, err := h.ifMatch(r)
+		if err != nil {
+			h.Error(w, r, ifMatchErrorCode(err), err)
 			return
 		}
 
@@ -121,38 +438,75 @@ func (h *TaskHandler) UpdateTask(userID int, taskID int) http.HandlerFunc {
 			return
 		}
 
+		// a task replaced without a status or a complete flag is open
+		if req.Status == nil && req.Complete == nil {
+			complete := false
+			req.Complete = &complete
+		}
+
 		t := &model.Task{
-			UserID: userID,
-			TaskID: taskID,
+			UserID:       userID,
+			TaskID:       taskID,
+			ListID:       req.ListID,
+			ParentTaskID: req.ParentTaskID,
+			Title:        &req.Title,
+			Description:  req.Description,
+			Deadline:     &req.Deadline.Time,
+			Complete:     req.Complete,
+			Status:       req.Status,
+			Tags:         model.NormalizeTags(req.Tags),
+			RRule:        req.RRule,
+			ActorID:      authUser.ID,
+			IfVersion:    version,
 		}
 
-		if req.Title != nil {
-			t.Title = req.Title
+		if err := h.authorizeMove(r, t, false); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
 		}
 
-		if req.Description != nil {
-			t.Description = req.Description
+		if err := h.placeInTree(todos(h.Store, r), t, true); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
 		}
 
-		if req.Deadline != nil {
-			t.Deadline = &req.Deadline.Time
+		before, err := todos(h.Store, r).FindByID(userID, taskID)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
 		}
 
-		if req.Complete != nil {
-			t.Complete = req.Complete
+		// only a new deadline has to be in the future, an overdue task can
+		// be replaced as it is
+		t.PastDeadline = before.Deadline != nil && before.Deadline.Equal(*t.Deadline)
+
+		workflow, err := h.workflow(todos(h.Store, r), userID, t.ListID)
+		if err != nil {
+			h.Error(w, r, http.StatusUnprocessableEntity, err)
+			return
 		}
 
-		if err := t.Validation(r.Method); err != nil {
+		// the status sets the complete flag that Validation checks
+		if err := workflow.Apply(t, before); err != nil {
 			h.Error(w, r, http.StatusUnprocessableEntity, err)
 			return
 		}
-		
-		if err := h.Store.Todo().Update(t); err != nil {
+
+		if err := t.Validation(r.Method); err != nil {
 			h.Error(w, r, http.StatusUnprocessableEntity, err)
 			return
 		}
 
-		h.Respond(w, r, http.StatusOK, nil)
+		if err := todos(h.Store, r).Replace(t); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.publishUpdate(todos(h.Store, r), t, completes(before, t))
+
+		setETag(w, t)
+
+		h.Respond(w, r, http.StatusOK, t)
 	}
 }
 
@@ -165,17 +519,371 @@ func (h *TaskHandler) DeleteTask(userID int, taskIDs []int) http.HandlerFunc {
 
 		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
 
-		if authUser.ID != userID {
-			h.Error(w, r, http.StatusForbidden, errors.New("access denied"))
+		if err := h.authorize(r, userID, 0, model.RoleEditor); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		// a single If-Match cannot hold the versions of all the tasks
+		if h.RequireIfMatch {
+			h.Error(w, r, http.StatusPreconditionRequired, errBulkDeleteNotAllowed)
 			return
 		}
 
-		count, err := h.Store.Todo().Delete(userID, taskIDs)
+		// the deleted tasks are loaded beforehand for the events
+		var deleted []*model.Task
+		if h.Events != nil {
+			for _, id := range taskIDs {
+				if t, err := todos(h.Store, r).FindByID(userID, id); err == nil {
+					deleted = append(deleted, t)
+				}
+			}
+		}
+
+		count, err := todos(h.Store, r).Delete(userID, taskIDs, authUser.ID)
 		if err != nil {
 			h.Error(w, r, http.StatusUnprocessableEntity, err)
 			return
 		}
 
+		for _, t := range deleted {
+			h.publish(model.EventTaskDeleted, t)
+		}
+
 		h.Respond(w, r, http.StatusOK, count)
 	}
 }
+
+// DeleteTaskByID deletes a single task, unlike DeleteTask it honours
+// If-Match.
+func (h *TaskHandler) DeleteTaskByID(userID int, taskID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if err := h.authorize(r, userID, taskID, model.RoleEditor); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		version, err := h.ifMatch(r)
+		if err != nil {
+			h.Error(w, r, ifMatchErrorCode(err), err)
+			return
+		}
+
+		t, err := todos(h.Store, r).FindByID(userID, taskID)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		var count int64
+		if version != nil {
+			count, err = todos(h.Store, r).DeleteVersion(userID, taskID, *version, authUser.ID)
+		} else {
+			count, err = todos(h.Store, r).Delete(userID, []int{taskID}, authUser.ID)
+		}
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		if count == 0 {
+			h.Error(w, r, http.StatusNotFound, store.ErrRecordNotFound)
+			return
+		}
+
+		h.publish(model.EventTaskDeleted, t)
+
+		h.Respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+func (h *TaskHandler) GetTaskHistory(userID int, taskID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, taskID, model.RoleViewer); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		events, err := todos(h.Store, r).History(userID, taskID)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, events)
+	}
+}
+
+// publish hands the event over to the webhooks, if the handler has a
+// publisher.
+func (h *TaskHandler) publish(eventType string, t *model.Task) {
+	if h.Events == nil {
+		return
+	}
+
+	h.Events.Publish(model.NewWebhookEvent(eventType, t.UserID, t.TaskID, t))
+}
+
+// publishUpdate emits the events of a task after it was changed,
+// completing a task emits task.completed in addition to task.updated. The
+// subtasks completed along with the task and the next occurrence of a
+// recurring task get their events as well.
+func (h *TaskHandler) publishUpdate(repo todo.TodoRepository, t *model.Task, completed bool) {
+	if h.Events == nil {
+		return
+	}
+
+	if stored, err := repo.FindByID(t.UserID, t.TaskID); err == nil {
+		h.publish(model.EventTaskUpdated, stored)
+
+		if completed {
+			h.publish(model.EventTaskCompleted, stored)
+		}
+	}
+
+	for _, id := range t.CompletedSubtaskIDs {
+		if sub, err := repo.FindByID(t.UserID, id); err == nil {
+			h.publish(model.EventTaskUpdated, sub)
+			h.publish(model.EventTaskCompleted, sub)
+		}
+	}
+
+	if t.NextTaskID != 0 {
+		if next, err := repo.FindByID(t.UserID, t.NextTaskID); err == nil {
+			h.publish(model.EventTaskCreated, next)
+		}
+	}
+}
+
+// completes reports whether the write of t completes the task that was
+// stored as before.
+func completes(before *model.Task, t *model.Task) bool {
+	return (before.Complete == nil || !*before.Complete) && t.Complete != nil && *t.Complete
+}
+
+// placeInTree checks that the task can be stored under its parent and fills
+// in the depth for Validation. A stored task brings its subtree along, so it
+// must not become a descendant of itself.
+func (h *TaskHandler) placeInTree(repo todo.TodoRepository, t *model.Task, stored bool) error {
+	height := 1
+
+	if stored {
+		tree, err := repo.FindTree(t.UserID, t.TaskID)
+		if err != nil {
+			return err
+		}
+
+		if t.ParentTaskID != nil && tree.Contains(*t.ParentTaskID) {
+			return errors.New("a task cannot be moved under itself or its subtasks")
+		}
+
+		height = tree.Height()
+	}
+
+	if t.ParentTaskID == nil {
+		t.Depth = height
+		return nil
+	}
+
+	depth, err := repo.Depth(t.UserID, *t.ParentTaskID)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			return errors.New("parent task not found")
+		}
+		return err
+	}
+
+	t.Depth = depth + height
+
+	return nil
+}
+
+// checkList makes sure that the task is moved only to a list of its owner
+// in the workspace of the repository.
+func (h *TaskHandler) checkList(repo todo.TodoRepository, userID int, listID *int) error {
+	_, err := h.workflow(repo, userID, listID)
+
+	return err
+}
+
+// workflow returns the workflow of the tasks of the list, the default one
+// for the tasks without a list. It finds the same lists as checkList.
+func (h *TaskHandler) workflow(repo todo.TodoRepository, userID int, listID *int) (*model.Workflow, error) {
+	if listID == nil {
+		return model.DefaultWorkflow(), nil
+	}
+
+	l, err := h.Store.List().InWorkspace(repo.Workspace()).FindByID(userID, *listID)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			return nil, errors.New("list not found")
+		}
+		return nil, err
+	}
+
+	return l.TaskWorkflow(), nil
+}
+
+// storeErrorCode maps errors of the store to response codes.
+func storeErrorCode(err error) int {
+	if errors.Is(err, store.ErrRecordNotFound) {
+		return http.StatusNotFound
+	}
+
+	if errors.Is(err, store.ErrVersionConflict) {
+		return http.StatusPreconditionFailed
+	}
+
+	if errors.Is(err, store.ErrTaskBlocked) || errors.Is(err, store.ErrTimerRunning) || errors.Is(err, store.ErrUIDConflict) {
+		return http.StatusConflict
+	}
+
+	return http.StatusUnprocessableEntity
+}
+
+var taskQueryParams = map[string]bool{
+	"limit":      true,
+	"cursor":     true,
+	"complete":   true,
+	"due_before": true,
+	"due_after":  true,
+	"overdue":    true,
+	"text":       true,
+	"sort":       true,
+	"order":      true,
+	"tag":        true,
+	"list_id":    true,
+}
+
+func parseTaskQuery(values url.Values) (*model.TaskQuery, error) {
+	for key := range values {
+		if !taskQueryParams[key] {
+			return nil, fmt.Errorf("unknown query parameter %q", key)
+		}
+	}
+
+	q := model.NewTaskQuery()
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return nil, errors.New("invalid limit")
+		}
+		q.Limit = n
+	}
+
+	if cursor := values.Get("cursor"); cursor != "" {
+		c, err := model.DecodeTaskCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		q.Cursor = c
+	}
+
+	var err error
+
+	if q.Filter.Complete, err = parseBoolParam(values, "complete"); err != nil {
+		return nil, err
+	}
+
+	if q.Filter.Overdue, err = parseBoolParam(values, "overdue"); err != nil {
+		return nil, err
+	}
+
+	if q.Filter.DueBefore, err = parseTimeParam(values, "due_before"); err != nil {
+		return nil, err
+	}
+
+	if q.Filter.DueAfter, err = parseTimeParam(values, "due_after"); err != nil {
+		return nil, err
+	}
+
+	q.Filter.Text = strings.TrimSpace(values.Get("text"))
+
+	if values.Has("list_id") {
+		listID, err := strconv.Atoi(values.Get("list_id"))
+		if err != nil {
+			return nil, errors.New("invalid list_id")
+		}
+		q.Filter.ListID = &listID
+	}
+
+	if tags, ok := values["tag"]; ok {
+		q.Filter.Tags = model.NormalizeTags(tags)
+	}
+
+	if sort := values.Get("sort"); sort != "" {
+		q.Sort = sort
+	}
+
+	if order := values.Get("order"); order != "" {
+		q.Order = order
+	}
+
+	if err := q.Validation(); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+func parseBoolParam(values url.Values, key string) (*bool, error) {
+	if !values.Has(key) {
+		return nil, nil
+	}
+
+	b, err := strconv.ParseBool(values.Get(key))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: use true or false", key)
+	}
+
+	return &b, nil
+}
+
+func parseTimeParam(values url.Values, key string) (*time.Time, error) {
+	if !values.Has(key) {
+		return nil, nil
+	}
+
+	t, err := model.ParseTime(values.Get(key))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", key, err)
+	}
+
+	return &t, nil
+}
+
+// pageLinks builds an RFC 8288 Link header value with the first page and,
+// when there is one, the next page of the listing.
+func pageLinks(u *url.URL, nextCursor string) string {
+	link := func(cursor, rel string) string {
+		values := u.Query()
+		values.Del("cursor")
+		if cursor != "" {
+			values.Set("cursor", cursor)
+		}
+
+		target := url.URL{Path: u.Path, RawQuery: values.Encode()}
+
+		return fmt.Sprintf("<%s>; rel=\"%s\"", target.String(), rel)
+	}
+
+	links := []string{link("", "first")}
+	if nextCursor != "" {
+		links = append(links, link(nextCursor, "next"))
+	}
+
+	return strings.Join(links, ", ")
+}
@@ -0,0 +1,355 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/middleware"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+)
+
+var errNotTracker = errors.New("only the user who tracked the time may edit the entry")
+
+// timeEntryRequest is the body of CreateTimeEntry and UpdateTimeEntry, the
+// fields left out of an update keep their value.
+type timeEntryRequest struct {
+	StartedAt *model.CustomTime `json:"started_at"`
+	StoppedAt *model.CustomTime `json:"stopped_at"`
+	Note      *string           `json:"note"`
+}
+
+func (req *timeEntryRequest) apply(e *model.TimeEntry) {
+	if req.StartedAt != nil {
+		e.StartedAt = req.StartedAt.Time
+	}
+
+	if req.StoppedAt != nil {
+		stoppedAt := req.StoppedAt.Time
+		e.StoppedAt = &stoppedAt
+	}
+
+	if req.Note != nil {
+		e.Note = *req.Note
+	}
+}
+
+// StartTimer starts a timer of the authenticated user on the task, a user
+// runs a single timer at a time.
+func (h *TaskHandler) StartTimer(userID int, taskID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, taskID, model.RoleEditor); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		e := &model.TimeEntry{
+			UserID:    userID,
+			TaskID:    taskID,
+			TrackerID: authUser.ID,
+		}
+
+		if err := timeEntries(h.Store, r).Start(e); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusCreated, e)
+	}
+}
+
+// StopTimer stops the timer the authenticated user runs on the task.
+func (h *TaskHandler) StopTimer(userID int, taskID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, taskID, model.RoleEditor); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		e, err := timeEntries(h.Store, r).Stop(userID, taskID, authUser.ID)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, e)
+	}
+}
+
+func (h *TaskHandler) GetTimeEntries(userID int, taskID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, taskID, model.RoleViewer); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		// the entries of the tasks of the other workspaces are not found
+		if _, err := todos(h.Store, r).FindByID(userID, taskID); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		entries, err := timeEntries(h.Store, r).FindByTask(userID, taskID)
+		if err != nil {
+			h.Error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, entries)
+	}
+}
+
+// CreateTimeEntry adds time the authenticated user spent on the task
+// without a timer.
+func (h *TaskHandler) CreateTimeEntry(userID int, taskID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, taskID, model.RoleEditor); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		req := &timeEntryRequest{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		e := &model.TimeEntry{
+			UserID:    userID,
+			TaskID:    taskID,
+			TrackerID: authUser.ID,
+		}
+		req.apply(e)
+
+		if err := timeEntries(h.Store, r).Create(e); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusCreated, e)
+	}
+}
+
+// UpdateTimeEntry changes an entry, only the user who tracked the time may
+// edit it. A running timer is stopped by giving it an end.
+func (h *TaskHandler) UpdateTimeEntry(userID int, taskID int, entryID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, taskID, model.RoleEditor); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		e, err := timeEntries(h.Store, r).FindByID(userID, taskID, entryID)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if e.TrackerID != authUser.ID {
+			h.Error(w, r, http.StatusForbidden, errNotTracker)
+			return
+		}
+
+		req := &timeEntryRequest{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		req.apply(e)
+
+		if err := timeEntries(h.Store, r).Update(e); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, e)
+	}
+}
+
+// DeleteTimeEntry deletes an entry. The user who tracked the time may
+// delete it and so may the owners of the task.
+func (h *TaskHandler) DeleteTimeEntry(userID int, taskID int, entryID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, taskID, model.RoleEditor); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		e, err := timeEntries(h.Store, r).FindByID(userID, taskID, entryID)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if e.TrackerID != authUser.ID {
+			if err := h.authorize(r, userID, taskID, model.RoleOwner); err != nil {
+				h.Error(w, r, accessErrorCode(err), err)
+				return
+			}
+		}
+
+		if err := timeEntries(h.Store, r).Delete(userID, taskID, entryID); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// GetTimeReport sums the time tracked on the tasks of the user from the
+// from parameter up to the to parameter, grouped by day, list or tag. With
+// format=csv the report is a CSV file of the rows, the durations in seconds
+// and in hours. The rows by list have the ID of their list as well.
+func (h *TaskHandler) GetTimeReport(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, 0, model.RoleViewer); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		q, format, err := parseTimeReportQuery(r.URL.Query())
+		if err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		report, err := timeEntries(h.Store, r).Report(userID, q)
+		if err != nil {
+			h.Error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if format == "json" {
+			h.Respond(w, r, http.StatusOK, report)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="time_report.csv"`)
+		w.WriteHeader(http.StatusOK)
+
+		byList := report.GroupBy == model.TimeReportByList
+
+		enc := csv.NewWriter(w)
+		header := []string{report.GroupBy, "duration", "hours"}
+		if byList {
+			header = []string{report.GroupBy, "list_id", "duration", "hours"}
+		}
+		enc.Write(header)
+
+		for _, row := range report.Rows {
+			record := []string{row.Group}
+			if byList {
+				listID := ""
+				if row.ListID != nil {
+					listID = strconv.Itoa(*row.ListID)
+				}
+				record = append(record, listID)
+			}
+
+			enc.Write(append(record,
+				strconv.FormatInt(row.Duration, 10),
+				strconv.FormatFloat(float64(row.Duration)/3600, 'f', 2, 64),
+			))
+		}
+		enc.Flush()
+	}
+}
+
+func parseTimeReportQuery(values url.Values) (*model.TimeReportQuery, string, error) {
+	for key := range values {
+		switch key {
+		case "from", "to", "group_by", "format":
+		default:
+			return nil, "", fmt.Errorf("unknown query parameter %q", key)
+		}
+	}
+
+	from, err := parseTimeParam(values, "from")
+	if err != nil {
+		return nil, "", err
+	}
+
+	to, err := parseTimeParam(values, "to")
+	if err != nil {
+		return nil, "", err
+	}
+
+	if from == nil || to == nil {
+		return nil, "", errors.New("from and to are required")
+	}
+
+	q := &model.TimeReportQuery{From: *from, To: *to, GroupBy: model.TimeReportByDay}
+
+	if groupBy := values.Get("group_by"); groupBy != "" {
+		q.GroupBy = groupBy
+	}
+
+	if err := q.Validation(); err != nil {
+		return nil, "", err
+	}
+
+	format := values.Get("format")
+	switch format {
+	case "":
+		format = "json"
+	case "json", "csv":
+	default:
+		return nil, "", errors.New("unknown format: use csv or json")
+	}
+
+	return q, format, nil
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/middleware"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/todo"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/taskio"
+)
+
+// maxImportSize is the largest file accepted by ImportTasks.
+const maxImportSize = 10 << 20
+
+// ExportTasks streams all the tasks of the user in the format of the
+// format parameter.
+func (h *TaskHandler) ExportTasks(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, 0, model.RoleViewer); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		format, err := taskio.ParseFormat(r.URL.Query().Get("format"))
+		if err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", taskio.ContentType(format))
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="tasks.%s"`, format))
+		clearWriteDeadline(w)
+		w.WriteHeader(http.StatusOK)
+
+		enc, _ := taskio.NewEncoder(w, format)
+
+		if err := todos(h.Store, r).Export(userID, enc.Encode); err != nil {
+			// the status is already sent, the client has to see a broken
+			// response instead of a file that looks complete
+			panic(http.ErrAbortHandler)
+		}
+
+		if err := enc.Close(); err != nil {
+			panic(http.ErrAbortHandler)
+		}
+	}
+}
+
+// clearWriteDeadline lifts the write timeout of the server for a response
+// that is streamed for as long as the store takes to read the tasks.
+func clearWriteDeadline(w http.ResponseWriter) {
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+}
+
+// ImportTasks creates the tasks of a file in the format of the format
+// parameter. The subtasks of the file are created under their imported
+// parents, a list that is not a list of the user is dropped. Every row is
+// validated first and nothing is imported if one of them is invalid. With
+// dry_run nothing is imported either, and with allow_past_deadline the
+// deadlines may be in the past.
+func (h *TaskHandler) ImportTasks(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if err := h.authorize(r, userID, 0, model.RoleEditor); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		query := r.URL.Query()
+
+		format, err := taskio.ParseFormat(query.Get("format"))
+		if err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		dryRun, err := parseBoolParam(query, "dry_run")
+		if err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		pastDeadline, err := parseBoolParam(query, "allow_past_deadline")
+		if err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		records, err := taskio.Decode(http.MaxBytesReader(w, r.Body, maxImportSize), format)
+		if err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		res := &model.TaskImportResult{
+			DryRun: dryRun != nil && *dryRun,
+			Errors: []*model.TaskImportError{},
+		}
+
+		for _, rec := range records {
+			if rec.Err == nil {
+				rec.Task.UserID = userID
+				rec.Task.ActorID = authUser.ID
+				rec.Task.PastDeadline = pastDeadline != nil && *pastDeadline
+			}
+		}
+
+		records = h.planImport(todos(h.Store, r), userID, records, res)
+
+		if len(res.Errors) > 0 {
+			h.Respond(w, r, http.StatusUnprocessableEntity, res)
+			return
+		}
+
+		if res.DryRun {
+			res.Imported = len(records)
+			h.Respond(w, r, http.StatusOK, res)
+			return
+		}
+
+		var failed *taskio.Record
+		ids := map[int]int{}
+
+		err = todos(h.Store, r).InTx(func(repo todo.TodoRepository) error {
+			for _, rec := range records {
+				t := rec.Task
+				fileID := t.TaskID
+				t.TaskID = 0
+
+				if t.ParentTaskID != nil {
+					parentID := ids[*t.ParentTaskID]
+					t.ParentTaskID = &parentID
+				}
+
+				if err := repo.Create(t); err != nil {
+					failed, rec.Err = rec, err
+					return err
+				}
+
+				if fileID != 0 {
+					ids[fileID] = t.TaskID
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			if failed == nil {
+				h.Error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			res.Errors = append(res.Errors, &model.TaskImportError{Row: failed.Row, Error: failed.Err.Error()})
+			h.Respond(w, r, http.StatusUnprocessableEntity, res)
+			return
+		}
+
+		for _, rec := range records {
+			h.publish(model.EventTaskCreated, rec.Task)
+		}
+
+		res.Imported = len(records)
+
+		h.Respond(w, r, http.StatusCreated, res)
+	}
+}
+
+// planImport validates the records and returns them parents first. The
+// errors of the records are added to the result.
+func (h *TaskHandler) planImport(repo todo.TodoRepository, userID int, records []*taskio.Record, res *model.TaskImportResult) []*taskio.Record {
+	byID := map[int]*taskio.Record{}
+	for _, rec := range records {
+		if rec.Err == nil && rec.Task.TaskID != 0 {
+			if _, ok := byID[rec.Task.TaskID]; ok {
+				rec.Err = fmt.Errorf("task_id %d is used by another row", rec.Task.TaskID)
+				continue
+			}
+			byID[rec.Task.TaskID] = rec
+		}
+	}
+
+	// depth returns the level of the task in the file, 0 when its parents
+	// cannot be imported
+	depths := map[*taskio.Record]int{}
+	var depth func(rec *taskio.Record, seen int) int
+	depth = func(rec *taskio.Record, seen int) int {
+		if d, ok := depths[rec]; ok {
+			return d
+		}
+
+		if rec.Task.ParentTaskID == nil {
+			depths[rec] = 1
+			return 1
+		}
+
+		parent, ok := byID[*rec.Task.ParentTaskID]
+		if !ok || parent.Err != nil || seen > len(records) {
+			return 0
+		}
+
+		d := depth(parent, seen+1)
+		if d > 0 {
+			d++
+		}
+		depths[rec] = d
+
+		return d
+	}
+
+	planned := []*taskio.Record{}
+
+	for _, rec := range records {
+		if rec.Err == nil {
+			if rec.Task.ListID != nil && h.checkList(repo, userID, rec.Task.ListID) != nil {
+				rec.Task.ListID = nil
+			}
+
+			rec.Task.Depth = depth(rec, 0)
+
+			switch {
+			case rec.Task.Depth == 0 && byID[*rec.Task.ParentTaskID] == nil:
+				rec.Err = fmt.Errorf("parent task %d is not in the file", *rec.Task.ParentTaskID)
+			case rec.Task.Depth == 0:
+				rec.Err = fmt.Errorf("parent task %d cannot be imported", *rec.Task.ParentTaskID)
+			default:
+				rec.Err = rec.Task.Validation(http.MethodPost)
+			}
+		}
+
+		if rec.Err != nil {
+			res.Errors = append(res.Errors, &model.TaskImportError{Row: rec.Row, Error: rec.Err.Error()})
+			continue
+		}
+
+		planned = append(planned, rec)
+	}
+
+	sort.SliceStable(planned, func(i, j int) bool {
+		return planned[i].Task.Depth < planned[j].Task.Depth
+	})
+
+	return planned
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/middleware"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+// WebhookHandler manages the webhooks of a user. The secret of a webhook is
+// only returned when it is created.
+type WebhookHandler struct {
+	Store store.Store
+	// AllowPrivateTargets lets webhooks point to loopback, private and
+	// link-local addresses.
+	AllowPrivateTargets bool
+	Respond             func(http.ResponseWriter, *http.Request, int, interface{})
+	Error               func(http.ResponseWriter, *http.Request, int, error)
+}
+
+func (h *WebhookHandler) GetWebhooks(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Error(w, r, http.StatusForbidden, errors.New("access denied"))
+			return
+		}
+
+		webhooks, err := h.Store.Webhook().FindAll(userID)
+		if err != nil {
+			h.Error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		for _, wh := range webhooks {
+			wh.Secret = ""
+		}
+
+		h.Respond(w, r, http.StatusOK, webhooks)
+	}
+}
+
+func (h *WebhookHandler) GetWebhookByID(userID int, webhookID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Error(w, r, http.StatusForbidden, errors.New("access denied"))
+			return
+		}
+
+		wh, err := h.Store.Webhook().FindByID(userID, webhookID)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		wh.Secret = ""
+
+		h.Respond(w, r, http.StatusOK, wh)
+	}
+}
+
+func (h *WebhookHandler) CreateWebhook(userID int) http.HandlerFunc {
+	type request struct {
+		URL    string   `json:"url"`
+		Secret string   `json:"secret"`
+		Events []string `json:"events"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Error(w, r, http.StatusForbidden, errors.New("access denied"))
+			return
+		}
+
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		wh := &model.Webhook{
+			UserID: userID,
+			URL:    req.URL,
+			Secret: req.Secret,
+			Events: req.Events,
+		}
+
+		if !h.AllowPrivateTargets {
+			if err := wh.CheckTarget(net.LookupIP); err != nil {
+				h.Error(w, r, http.StatusUnprocessableEntity, err)
+				return
+			}
+		}
+
+		if err := h.Store.Webhook().Create(wh); err != nil {
+			h.Error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusCreated, wh)
+	}
+}
+
+func (h *WebhookHandler) DeleteWebhook(userID int, webhookID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Error(w, r, http.StatusForbidden, errors.New("access denied"))
+			return
+		}
+
+		if err := h.Store.Webhook().Delete(userID, webhookID); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+func (h *WebhookHandler) GetDeliveries(userID int, webhookID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			h.Error(w, r, http.StatusForbidden, errors.New("access denied"))
+			return
+		}
+
+		deliveries, err := h.Store.Webhook().FindDeliveries(userID, webhookID)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, deliveries)
+	}
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+)
+
+// GetWorkflow returns the workflow of the tasks of the list, the default
+// one when the list has not set its own.
+func (h *ListHandler) GetWorkflow(userID int, listID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, listID, model.RoleViewer); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		l, err := lists(h.Store, r).FindByID(userID, listID)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, l.TaskWorkflow())
+	}
+}
+
+// UpdateWorkflow sets the workflow of the list, DELETE restores the default
+// one. The tasks in a status the workflow no longer has take its first
+// status that agrees with their complete flag.
+func (h *ListHandler) UpdateWorkflow(userID int, listID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut && r.Method != http.MethodDelete {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		// the workflow changes the tasks of every user the list is
+		// shared with
+		if err := h.authorize(r, userID, listID, model.RoleOwner); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		l := &model.List{
+			ID:     listID,
+			UserID: userID,
+		}
+
+		if r.Method == http.MethodPut {
+			l.Workflow = &model.Workflow{}
+
+			if err := json.NewDecoder(r.Body).Decode(l.Workflow); err != nil {
+				h.Error(w, r, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		if err := lists(h.Store, r).UpdateWorkflow(l); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, l.TaskWorkflow())
+	}
+}
+
+// GetBoard returns the tasks of the list grouped by their status, in the
+// order of the workflow of the list.
+func (h *ListHandler) GetBoard(userID int, listID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, listID, model.RoleViewer); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		l, err := lists(h.Store, r).FindByID(userID, listID)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		tasks := []*model.Task{}
+
+		if err := todos(h.Store, r).Export(userID, func(t *model.Task) error {
+			if t.ListID != nil && *t.ListID == listID {
+				tasks = append(tasks, t)
+			}
+			return nil
+		}); err != nil {
+			h.Error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, model.NewBoard(listID, l.TaskWorkflow(), tasks))
+	}
+}
@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/middleware"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/services"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+// WorkspaceHandler manages the team workspaces of a user and their members,
+// and switches the active workspace of the user. A workspace that the user
+// is not a member of is not found.
+type WorkspaceHandler struct {
+	Store        store.Store
+	TokenService services.TokenService
+	Respond      func(http.ResponseWriter, *http.Request, int, interface{})
+	Error        func(http.ResponseWriter, *http.Request, int, error)
+}
+
+type workspaceRequest struct {
+	Name string `json:"name"`
+}
+
+func (h *WorkspaceHandler) GetWorkspaces(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, model.PersonalWorkspaceID, ""); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		workspaces, err := h.Store.Workspace().FindByUser(userID)
+		if err != nil {
+			h.Error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, workspaces)
+	}
+}
+
+// CreateWorkspace creates a workspace with the user as its owner.
+func (h *WorkspaceHandler) CreateWorkspace(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, model.PersonalWorkspaceID, ""); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		req := &workspaceRequest{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		ws := &model.Workspace{
+			Name:      req.Name,
+			CreatedBy: userID,
+		}
+
+		if err := h.Store.Workspace().Create(ws); err != nil {
+			h.Error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusCreated, ws)
+	}
+}
+
+func (h *WorkspaceHandler) GetWorkspace(userID int, workspaceID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		m, err := h.member(r, userID, workspaceID)
+		if err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		ws, err := h.Store.Workspace().FindByID(workspaceID)
+		if err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		ws.Role = m.Role
+
+		h.Respond(w, r, http.StatusOK, ws)
+	}
+}
+
+// UpdateWorkspace renames the workspace, the owners may rename it.
+func (h *WorkspaceHandler) UpdateWorkspace(userID int, workspaceID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, workspaceID, model.RoleOwner); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		req := &workspaceRequest{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		ws := &model.Workspace{
+			ID:   workspaceID,
+			Name: req.Name,
+			Role: model.RoleOwner,
+		}
+
+		if err := h.Store.Workspace().Update(ws); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, ws)
+	}
+}
+
+// DeleteWorkspace deletes the workspace together with its tasks and lists,
+// the owners may delete it.
+func (h *WorkspaceHandler) DeleteWorkspace(userID int, workspaceID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, workspaceID, model.RoleOwner); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		if err := h.Store.Workspace().Delete(workspaceID); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+func (h *WorkspaceHandler) GetMembers(userID int, workspaceID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, workspaceID, model.RoleViewer); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		members, err := h.Store.Workspace().FindMembers(workspaceID)
+		if err != nil {
+			h.Error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, members)
+	}
+}
+
+// SaveMember adds a registered user to the workspace by email, or changes
+// the role of a member. The owners may manage the members.
+func (h *WorkspaceHandler) SaveMember(userID int, workspaceID int) http.HandlerFunc {
+	type request struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, workspaceID, model.RoleOwner); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		req := &request{}
+
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			h.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		u, err := h.Store.User().FindByEmail(req.Email)
+		if err != nil {
+			if errors.Is(err, store.ErrRecordNotFound) {
+				h.Error(w, r, http.StatusUnprocessableEntity, errors.New("no user with the email"))
+				return
+			}
+			h.Error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		m := &model.WorkspaceMember{
+			WorkspaceID: workspaceID,
+			UserID:      u.ID,
+			Email:       u.Email,
+			Role:        req.Role,
+		}
+
+		if err := h.Store.Workspace().SaveMember(m); err != nil {
+			h.Error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, m)
+	}
+}
+
+// DeleteMember removes a member from the workspace. The owners may remove
+// any member and every member may leave, except the last owner.
+func (h *WorkspaceHandler) DeleteMember(userID int, workspaceID int, memberID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		role := model.RoleOwner
+		if memberID == userID {
+			role = model.RoleViewer
+		}
+
+		if err := h.authorize(r, userID, workspaceID, role); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		if err := h.Store.Workspace().DeleteMember(workspaceID, memberID); err != nil {
+			h.Error(w, r, storeErrorCode(err), err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// SwitchWorkspace makes the workspace the active one of the user. It
+// returns an access token for the workspace, the workspace 0 is the personal
+// one.
+func (h *WorkspaceHandler) SwitchWorkspace(userID int, workspaceID int) http.HandlerFunc {
+	type response struct {
+		AccessToken string `json:"access_token"`
+		WorkspaceID int    `json:"workspace_id"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.Error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if err := h.authorize(r, userID, workspaceID, model.RoleViewer); err != nil {
+			h.Error(w, r, accessErrorCode(err), err)
+			return
+		}
+
+		token, err := h.TokenService.GenerateWorkspaceAccessToken(userID, workspaceID)
+		if err != nil {
+			h.Error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		h.Respond(w, r, http.StatusOK, response{
+			AccessToken: token,
+			WorkspaceID: workspaceID,
+		})
+	}
+}
+
+// authorize checks that the authenticated user is the user and has the
+// role in the workspace. Everyone has their personal workspace.
+func (h *WorkspaceHandler) authorize(r *http.Request, userID int, workspaceID int, role string) error {
+	if workspaceID == model.PersonalWorkspaceID {
+		authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+		if authUser.ID != userID {
+			return errAccessDenied
+		}
+
+		return nil
+	}
+
+	m, err := h.member(r, userID, workspaceID)
+	if err != nil {
+		return err
+	}
+
+	return allow(m.Role, role)
+}
+
+// member returns the membership of the user in the workspace when the user
+// is the authenticated one.
+func (h *WorkspaceHandler) member(r *http.Request, userID int, workspaceID int) (*model.WorkspaceMember, error) {
+	authUser := r.Context().Value(middleware.CtxKeyUser).(*model.User)
+
+	if authUser.ID != userID {
+		return nil, errAccessDenied
+	}
+
+	return h.Store.Workspace().FindMember(workspaceID, userID)
+}
@@ -11,8 +11,11 @@ import (
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/apiserver/config"
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/apiserver/handlers"
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/middleware"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/services"
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/services/auth"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/services/blobstore"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/services/dispatcher"
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
 )
 
@@ -29,7 +32,8 @@ type Server struct {
 	config      *config.Config
 	log			*slog.Logger
 	tokenService services.TokenService
-
+	dispatcher  *dispatcher.Dispatcher
+	blobs       services.BlobStore
 }
 
 func newServer(store store.Store, logger *slog.Logger, cfg *config.Config) *Server {
@@ -39,6 +43,8 @@ func newServer(store store.Store, logger *slog.Logger, cfg *config.Config) *Serv
 		config: cfg,
 		log: logger,
 		tokenService: auth.NewTokenService([]byte(cfg.JWTSecret)),
+		dispatcher: dispatcher.New(store, cfg.Webhooks.MaxAttempts, cfg.Webhooks.Backoff, cfg.Webhooks.AllowPrivateTargets, logger),
+		blobs: blobstore.NewFileStore(cfg.Attachments.Dir),
 	}
 
 	s.configureRouter()
@@ -61,10 +67,71 @@ func (s *Server) configureRouter() {
 	taskHandler := &handlers.TaskHandler{
 		Store: s.store,
 		TokenService: s.tokenService,
+		Events: s.dispatcher,
+		RequireIfMatch: s.config.Concurrency.RequireIfMatch,
+		Respond: s.respond,
+		Error: s.error,
+	}
+
+	tagHandler := &handlers.TagHandler{
+		Store: s.store,
+		Respond: s.respond,
+		Error: s.error,
+	}
+
+	listHandler := &handlers.ListHandler{
+		Store: s.store,
+		Respond: s.respond,
+		Error: s.error,
+	}
+
+	webhookHandler := &handlers.WebhookHandler{
+		Store: s.store,
+		AllowPrivateTargets: s.config.Webhooks.AllowPrivateTargets,
+		Respond: s.respond,
+		Error: s.error,
+	}
+
+	feedHandler := &handlers.FeedHandler{
+		Store: s.store,
+		RateLimit: middleware.NewRateLimiter(s.config.Feed.RateLimit, s.config.Feed.RatePeriod),
 		Respond: s.respond,
 		Error: s.error,
 	}
 
+	appPasswordHandler := &handlers.AppPasswordHandler{
+		Store: s.store,
+		Respond: s.respond,
+		Error: s.error,
+	}
+
+	shareHandler := &handlers.ShareHandler{
+		Store: s.store,
+		Respond: s.respond,
+		Error: s.error,
+	}
+
+	workspaceHandler := &handlers.WorkspaceHandler{
+		Store: s.store,
+		TokenService: s.tokenService,
+		Respond: s.respond,
+		Error: s.error,
+	}
+
+	attachmentHandler := &handlers.AttachmentHandler{
+		Store: s.store,
+		Blobs: s.blobs,
+		MaxSize: s.config.Attachments.MaxSize,
+		URLTTL: s.config.Attachments.URLTTL,
+		SigningKey: []byte(s.config.JWTSecret),
+		Respond: s.respond,
+		Error: s.error,
+	}
+
+	calDAVHandler := &handlers.CalDAVHandler{
+		Tasks: taskHandler,
+	}
+
 	secret := []byte(s.config.JWTSecret)
 
 	// registration of authorization routs
@@ -77,65 +144,108 @@ func (s *Server) configureRouter() {
 	private.Handle("/whoami", middleware.Compose(middleware.AuthMiddleware(secret, s.store))(authHandler.Whoami()))
 	s.router.Handle("/private/", http.StripPrefix("/private", private))
 
-	// registration of task (todo) routs
+	// registration of the calendar feed, the token in the path is the only
+	// credential so it is also the key of the rate limit
+	s.router.Handle("/feed/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// expect /feed/{token}.ics
+		token, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/feed/"), ".ics")
+		if !ok || token == "" || strings.Contains(token, "/") {
+			http.NotFound(w, r)
+			return
+		}
+
+		feedHandler.ServeFeed(token)(w, r)
+	}))
+
+	// registration of the downloads of attachments, the signature in the
+	// query is the credential
+	s.router.HandleFunc("/attachment/", func(w http.ResponseWriter, r *http.Request) {
+		// expect /attachment/{attachment_id}?expires=&signature=
+		attachmentID, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/attachment/"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		attachmentHandler.ServeAttachment(attachmentID)(w, r)
+	})
+
+	// registration of the CalDAV server, its clients sign in with the email
+	// of the user and an app password
+	s.router.Handle("/.well-known/caldav", http.RedirectHandler(handlers.DAVRoot, http.StatusMovedPermanently))
+	s.router.Handle(handlers.DAVRoot, middleware.Compose(middleware.BasicAuthMiddleware("taskmanager-api", s.store))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.davRoutes(w, r, calDAVHandler)
+		}),
+	))
+
+	// registration of user resource routs
 	s.router.Handle("/user/", middleware.Compose(middleware.AuthMiddleware(secret, s.store))(
 		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			path := strings.Trim(r.URL.Path, "/")
-			parts := strings.FieldsFunc(path, func(r rune) bool {
-				return r == '/' || r == '?' || r == '=' || r == '&'
-			})
-			
-			// expect /user/{user_id}/task
-			if len(parts) == 3 && parts[0] == "user" && parts[2] == "task" {
-				userID, err := strconv.Atoi(parts[1])
-				if err != nil {
-					s.error(w, r, http.StatusBadRequest, errors.New("invalid user_id"))
-					return
-				}
+			parts := strings.Split(path, "/")
 
-				switch r.Method {
-				case http.MethodGet:
-					taskHandler.GetTask(userID)(w, r)
-				case http.MethodPost:
-					taskHandler.CreateTask(userID)(w, r)
-				case http.MethodDelete:
-					query := r.URL.Query()["ids"]
-					var taskIDs []int
-					for _, idStr := range query {
-						id, err := strconv.Atoi(idStr)
-						if err != nil {
-							s.error(w, r, http.StatusBadRequest, err)
-							return
-						}
-						taskIDs = append(taskIDs, id)
-					}
-					taskHandler.DeleteTask(userID, taskIDs)(w, r)
-				default:
-					s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
-				}
+			// expect /user/{user_id}/{resource}/...
+			if len(parts) < 3 || parts[0] != "user" {
+				http.NotFound(w, r)
 				return
 			}
-			
-			// expect /user/{user_id}/task/{task_id} or /user/{user_id}/task?ids=1&ids=2
-			if len(parts) == 4 && parts[0] == "user" && parts[2] == "task" {
-				userID, err1 := strconv.Atoi(parts[1])
-				taskID, err2 := strconv.Atoi(parts[3])
-				if err1 != nil || err2 != nil {
-					s.error(w, r, http.StatusBadRequest, errors.New("invalid user_id or task_id"))
-					return
-				}
 
-				switch r.Method {
-				case http.MethodPatch:
-					taskHandler.UpdateTask(userID, taskID)(w, r)
-				default:
-					s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
-				}
+			userID, err := strconv.Atoi(parts[1])
+			if err != nil {
+				s.error(w, r, http.StatusBadRequest, errors.New("invalid user_id"))
 				return
 			}
 
-
-			http.NotFound(w, r)
+			switch parts[2] {
+			case "task":
+				if len(parts) > 4 && parts[4] == "attachment" {
+					s.attachmentRoutes(w, r, attachmentHandler, userID, parts[3:])
+					return
+				}
+				s.taskRoutes(w, r, taskHandler, userID, parts[3:])
+			case "task:batch":
+				// expect /user/{user_id}/task:batch
+				if len(parts) > 3 {
+					http.NotFound(w, r)
+					return
+				}
+				taskHandler.BatchTasks(userID)(w, r)
+			case "time_report":
+				// expect /user/{user_id}/time_report?from=&to=&group_by=&format=
+				if len(parts) > 3 {
+					http.NotFound(w, r)
+					return
+				}
+				taskHandler.GetTimeReport(userID)(w, r)
+			case "stats":
+				// expect /user/{user_id}/stats?windows=7&windows=30
+				if len(parts) > 3 {
+					http.NotFound(w, r)
+					return
+				}
+				taskHandler.GetStats(userID)(w, r)
+			case "tag":
+				s.tagRoutes(w, r, tagHandler, userID, parts[3:])
+			case "list":
+				s.listRoutes(w, r, listHandler, userID, parts[3:])
+			case "trash":
+				s.trashRoutes(w, r, taskHandler, userID, parts[3:])
+			case "webhook":
+				s.webhookRoutes(w, r, webhookHandler, userID, parts[3:])
+			case "feed":
+				s.feedRoutes(w, r, feedHandler, userID, parts[3:])
+			case "app_password":
+				s.appPasswordRoutes(w, r, appPasswordHandler, userID, parts[3:])
+			case "share":
+				s.shareRoutes(w, r, shareHandler, userID, parts[3:])
+			case "invitation":
+				s.invitationRoutes(w, r, shareHandler, userID, parts[3:])
+			case "workspace":
+				s.workspaceRoutes(w, r, workspaceHandler, userID, parts[3:])
+			default:
+				http.NotFound(w, r)
+			}
 		}),
 	))
 
@@ -148,6 +258,678 @@ func (s *Server) configureRouter() {
 
 }
 
+// taskRoutes serves /user/{user_id}/task/...
+func (s *Server) taskRoutes(w http.ResponseWriter, r *http.Request, h *handlers.TaskHandler, userID int, parts []string) {
+	// expect /user/{user_id}/task or /user/{user_id}/task?ids=1&ids=2
+	if len(parts) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetTask(userID)(w, r)
+		case http.MethodPost:
+			h.CreateTask(userID)(w, r)
+		case http.MethodDelete:
+			query := r.URL.Query()["ids"]
+			var taskIDs []int
+			for _, idStr := range query {
+				id, err := strconv.Atoi(idStr)
+				if err != nil {
+					s.error(w, r, http.StatusBadRequest, err)
+					return
+				}
+				taskIDs = append(taskIDs, id)
+			}
+			h.DeleteTask(userID, taskIDs)(w, r)
+		default:
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		}
+		return
+	}
+
+	// expect /user/{user_id}/task/search?q=
+	if len(parts) == 1 && parts[0] == "search" {
+		h.SearchTask(userID)(w, r)
+		return
+	}
+
+	// expect /user/{user_id}/task/export?format=
+	if len(parts) == 1 && parts[0] == "export" {
+		h.ExportTasks(userID)(w, r)
+		return
+	}
+
+	// expect /user/{user_id}/task/ordered?complete=
+	if len(parts) == 1 && parts[0] == "ordered" {
+		h.GetOrderedTasks(userID)(w, r)
+		return
+	}
+
+	// expect /user/{user_id}/task/import?format=
+	if len(parts) == 1 && parts[0] == "import" {
+		h.ImportTasks(userID)(w, r)
+		return
+	}
+
+	taskID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		s.error(w, r, http.StatusBadRequest, errors.New("invalid task_id"))
+		return
+	}
+
+	// expect /user/{user_id}/task/{task_id}
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetTaskByID(userID, taskID)(w, r)
+		case http.MethodPut:
+			h.ReplaceTask(userID, taskID)(w, r)
+		case http.MethodPatch:
+			h.UpdateTask(userID, taskID)(w, r)
+		case http.MethodDelete:
+			h.DeleteTaskByID(userID, taskID)(w, r)
+		default:
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		}
+		return
+	}
+
+	// expect /user/{user_id}/task/{task_id}/tree
+	if len(parts) == 2 && parts[1] == "tree" {
+		h.GetTaskTree(userID, taskID)(w, r)
+		return
+	}
+
+	// expect /user/{user_id}/task/{task_id}/history
+	if len(parts) == 2 && parts[1] == "history" {
+		h.GetTaskHistory(userID, taskID)(w, r)
+		return
+	}
+
+	// expect /user/{user_id}/task/{task_id}/reminder
+	if len(parts) == 2 && parts[1] == "reminder" {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetReminders(userID, taskID)(w, r)
+		case http.MethodPost:
+			h.CreateReminder(userID, taskID)(w, r)
+		default:
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		}
+		return
+	}
+
+	// expect /user/{user_id}/task/{task_id}/reminder/{reminder_id}
+	if len(parts) == 3 && parts[1] == "reminder" {
+		reminderID, err := strconv.Atoi(parts[2])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, errors.New("invalid reminder_id"))
+			return
+		}
+
+		h.DeleteReminder(userID, taskID, reminderID)(w, r)
+		return
+	}
+
+	// expect /user/{user_id}/task/{task_id}/comment?limit=&cursor=
+	if len(parts) == 2 && parts[1] == "comment" {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetComments(userID, taskID)(w, r)
+		case http.MethodPost:
+			h.CreateComment(userID, taskID)(w, r)
+		default:
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		}
+		return
+	}
+
+	// expect /user/{user_id}/task/{task_id}/comment/{comment_id}
+	if len(parts) == 3 && parts[1] == "comment" {
+		commentID, err := strconv.Atoi(parts[2])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, errors.New("invalid comment_id"))
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPatch:
+			h.UpdateComment(userID, taskID, commentID)(w, r)
+		case http.MethodDelete:
+			h.DeleteComment(userID, taskID, commentID)(w, r)
+		default:
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		}
+		return
+	}
+
+	// expect /user/{user_id}/task/{task_id}/dependency
+	if len(parts) == 2 && parts[1] == "dependency" {
+		h.AddDependency(userID, taskID)(w, r)
+		return
+	}
+
+	// expect /user/{user_id}/task/{task_id}/dependency/{blocked_by_id}
+	if len(parts) == 3 && parts[1] == "dependency" {
+		blockedByID, err := strconv.Atoi(parts[2])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, errors.New("invalid blocked_by_id"))
+			return
+		}
+
+		h.RemoveDependency(userID, taskID, blockedByID)(w, r)
+		return
+	}
+
+	// expect /user/{user_id}/task/{task_id}/timer/start or .../timer/stop
+	if len(parts) == 3 && parts[1] == "timer" {
+		switch parts[2] {
+		case "start":
+			h.StartTimer(userID, taskID)(w, r)
+		case "stop":
+			h.StopTimer(userID, taskID)(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+		return
+	}
+
+	// expect /user/{user_id}/task/{task_id}/time_entry
+	if len(parts) == 2 && parts[1] == "time_entry" {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetTimeEntries(userID, taskID)(w, r)
+		case http.MethodPost:
+			h.CreateTimeEntry(userID, taskID)(w, r)
+		default:
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		}
+		return
+	}
+
+	// expect /user/{user_id}/task/{task_id}/time_entry/{entry_id}
+	if len(parts) == 3 && parts[1] == "time_entry" {
+		entryID, err := strconv.Atoi(parts[2])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, errors.New("invalid entry_id"))
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPatch:
+			h.UpdateTimeEntry(userID, taskID, entryID)(w, r)
+		case http.MethodDelete:
+			h.DeleteTimeEntry(userID, taskID, entryID)(w, r)
+		default:
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		}
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// attachmentRoutes serves /user/{user_id}/task/{task_id}/attachment/...
+func (s *Server) attachmentRoutes(w http.ResponseWriter, r *http.Request, h *handlers.AttachmentHandler, userID int, parts []string) {
+	taskID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		s.error(w, r, http.StatusBadRequest, errors.New("invalid task_id"))
+		return
+	}
+
+	// expect /user/{user_id}/task/{task_id}/attachment
+	if len(parts) == 2 {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetAttachments(userID, taskID)(w, r)
+		case http.MethodPost:
+			h.UploadAttachment(userID, taskID)(w, r)
+		default:
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		}
+		return
+	}
+
+	// expect /user/{user_id}/task/{task_id}/attachment/{attachment_id}
+	if len(parts) == 3 {
+		attachmentID, err := strconv.Atoi(parts[2])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, errors.New("invalid attachment_id"))
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			h.GetAttachment(userID, taskID, attachmentID)(w, r)
+		case http.MethodDelete:
+			h.DeleteAttachment(userID, taskID, attachmentID)(w, r)
+		default:
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		}
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// tagRoutes serves /user/{user_id}/tag/...
+func (s *Server) tagRoutes(w http.ResponseWriter, r *http.Request, h *handlers.TagHandler, userID int, parts []string) {
+	// expect /user/{user_id}/tag
+	if len(parts) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetTags(userID)(w, r)
+		case http.MethodPost:
+			h.CreateTag(userID)(w, r)
+		default:
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		}
+		return
+	}
+
+	// expect /user/{user_id}/tag/{tag_id}
+	if len(parts) == 1 {
+		tagID, err := strconv.Atoi(parts[0])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, errors.New("invalid tag_id"))
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPatch:
+			h.UpdateTag(userID, tagID)(w, r)
+		case http.MethodDelete:
+			h.DeleteTag(userID, tagID)(w, r)
+		default:
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		}
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// listRoutes serves /user/{user_id}/list/...
+func (s *Server) listRoutes(w http.ResponseWriter, r *http.Request, h *handlers.ListHandler, userID int, parts []string) {
+	// expect /user/{user_id}/list
+	if len(parts) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetLists(userID)(w, r)
+		case http.MethodPost:
+			h.CreateList(userID)(w, r)
+		default:
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		}
+		return
+	}
+
+	// expect /user/{user_id}/list/{list_id}
+	if len(parts) == 1 {
+		listID, err := strconv.Atoi(parts[0])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, errors.New("invalid list_id"))
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			h.GetListByID(userID, listID)(w, r)
+		case http.MethodPatch:
+			h.UpdateList(userID, listID)(w, r)
+		case http.MethodDelete:
+			h.DeleteList(userID, listID)(w, r)
+		default:
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		}
+		return
+	}
+
+	// expect /user/{user_id}/list/{list_id}/workflow or
+	// /user/{user_id}/list/{list_id}/board
+	if len(parts) == 2 {
+		listID, err := strconv.Atoi(parts[0])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, errors.New("invalid list_id"))
+			return
+		}
+
+		switch {
+		case parts[1] == "workflow" && r.Method == http.MethodGet:
+			h.GetWorkflow(userID, listID)(w, r)
+		case parts[1] == "workflow":
+			h.UpdateWorkflow(userID, listID)(w, r)
+		case parts[1] == "board":
+			h.GetBoard(userID, listID)(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// trashRoutes serves /user/{user_id}/trash/...
+func (s *Server) trashRoutes(w http.ResponseWriter, r *http.Request, h *handlers.TaskHandler, userID int, parts []string) {
+	// expect /user/{user_id}/trash
+	if len(parts) == 0 {
+		h.GetTrash(userID)(w, r)
+		return
+	}
+
+	// expect /user/{user_id}/trash/{task_id}/restore
+	if len(parts) == 2 && parts[1] == "restore" {
+		taskID, err := strconv.Atoi(parts[0])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, errors.New("invalid task_id"))
+			return
+		}
+
+		h.RestoreTask(userID, taskID)(w, r)
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// appPasswordRoutes serves /user/{user_id}/app_password/...
+func (s *Server) appPasswordRoutes(w http.ResponseWriter, r *http.Request, h *handlers.AppPasswordHandler, userID int, parts []string) {
+	// expect /user/{user_id}/app_password
+	if len(parts) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetAppPasswords(userID)(w, r)
+		case http.MethodPost:
+			h.CreateAppPassword(userID)(w, r)
+		default:
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		}
+		return
+	}
+
+	// expect /user/{user_id}/app_password/{password_id}
+	if len(parts) == 1 {
+		passwordID, err := strconv.Atoi(parts[0])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, errors.New("invalid password_id"))
+			return
+		}
+
+		h.DeleteAppPassword(userID, passwordID)(w, r)
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// shareRoutes serves /user/{user_id}/share/...
+func (s *Server) shareRoutes(w http.ResponseWriter, r *http.Request, h *handlers.ShareHandler, userID int, parts []string) {
+	// expect /user/{user_id}/share
+	if len(parts) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetShares(userID)(w, r)
+		case http.MethodPost:
+			h.CreateShare(userID)(w, r)
+		default:
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		}
+		return
+	}
+
+	// expect /user/{user_id}/share/{share_id}
+	if len(parts) == 1 {
+		shareID, err := strconv.Atoi(parts[0])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, errors.New("invalid share_id"))
+			return
+		}
+
+		h.DeleteShare(userID, shareID)(w, r)
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// invitationRoutes serves /user/{user_id}/invitation/...
+func (s *Server) invitationRoutes(w http.ResponseWriter, r *http.Request, h *handlers.ShareHandler, userID int, parts []string) {
+	// expect /user/{user_id}/invitation?status=
+	if len(parts) == 0 {
+		h.GetInvitations(userID)(w, r)
+		return
+	}
+
+	// expect /user/{user_id}/invitation/{share_id}/accept or .../decline
+	if len(parts) == 2 && (parts[1] == "accept" || parts[1] == "decline") {
+		shareID, err := strconv.Atoi(parts[0])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, errors.New("invalid share_id"))
+			return
+		}
+
+		status := model.ShareStatusAccepted
+		if parts[1] == "decline" {
+			status = model.ShareStatusDeclined
+		}
+
+		h.AnswerInvitation(userID, shareID, status)(w, r)
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// workspaceRoutes serves /user/{user_id}/workspace/...
+func (s *Server) workspaceRoutes(w http.ResponseWriter, r *http.Request, h *handlers.WorkspaceHandler, userID int, parts []string) {
+	// expect /user/{user_id}/workspace
+	if len(parts) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetWorkspaces(userID)(w, r)
+		case http.MethodPost:
+			h.CreateWorkspace(userID)(w, r)
+		default:
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		}
+		return
+	}
+
+	workspaceID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		s.error(w, r, http.StatusBadRequest, errors.New("invalid workspace_id"))
+		return
+	}
+
+	// expect /user/{user_id}/workspace/{workspace_id}/switch, the workspace 0
+	// is the personal one
+	if len(parts) == 2 && parts[1] == "switch" {
+		h.SwitchWorkspace(userID, workspaceID)(w, r)
+		return
+	}
+
+	// expect /user/{user_id}/workspace/{workspace_id}
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetWorkspace(userID, workspaceID)(w, r)
+		case http.MethodPatch:
+			h.UpdateWorkspace(userID, workspaceID)(w, r)
+		case http.MethodDelete:
+			h.DeleteWorkspace(userID, workspaceID)(w, r)
+		default:
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		}
+		return
+	}
+
+	if parts[1] != "member" {
+		http.NotFound(w, r)
+		return
+	}
+
+	// expect /user/{user_id}/workspace/{workspace_id}/member
+	if len(parts) == 2 {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetMembers(userID, workspaceID)(w, r)
+		case http.MethodPost:
+			h.SaveMember(userID, workspaceID)(w, r)
+		default:
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		}
+		return
+	}
+
+	// expect /user/{user_id}/workspace/{workspace_id}/member/{member_id}
+	if len(parts) == 3 {
+		memberID, err := strconv.Atoi(parts[2])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, errors.New("invalid member_id"))
+			return
+		}
+
+		h.DeleteMember(userID, workspaceID, memberID)(w, r)
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// davRoutes serves /dav/...
+func (s *Server) davRoutes(w http.ResponseWriter, r *http.Request, h *handlers.CalDAVHandler) {
+	if r.Method == http.MethodOptions {
+		h.Options()(w, r)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	// expect /dav/
+	if len(parts) == 1 {
+		if r.Method != "PROPFIND" {
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+		h.PropfindRoot()(w, r)
+		return
+	}
+
+	// expect /dav/principals/{user_id}/ or /dav/calendars/{user_id}/...
+	if len(parts) < 3 || (parts[1] != "principals" && parts[1] != "calendars") {
+		http.NotFound(w, r)
+		return
+	}
+
+	userID, err := strconv.Atoi(parts[2])
+	if err != nil {
+		s.error(w, r, http.StatusBadRequest, errors.New("invalid user_id"))
+		return
+	}
+
+	switch {
+	case len(parts) == 3 && parts[1] == "principals":
+		if r.Method != "PROPFIND" {
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+		h.PropfindPrincipal(userID)(w, r)
+	case len(parts) == 3:
+		if r.Method != "PROPFIND" {
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+		h.PropfindHome(userID)(w, r)
+	case len(parts) == 4 && parts[3] == "tasks":
+		// expect /dav/calendars/{user_id}/tasks/
+		switch r.Method {
+		case "PROPFIND":
+			h.PropfindCalendar(userID)(w, r)
+		case "REPORT":
+			h.Report(userID)(w, r)
+		default:
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		}
+	case len(parts) == 5 && parts[3] == "tasks":
+		// expect /dav/calendars/{user_id}/tasks/{uid}.ics
+		switch r.Method {
+		case "PROPFIND":
+			h.PropfindTask(userID, parts[4])(w, r)
+		case http.MethodGet, http.MethodHead:
+			h.GetTask(userID, parts[4])(w, r)
+		case http.MethodPut:
+			h.PutTask(userID, parts[4])(w, r)
+		case http.MethodDelete:
+			h.DeleteTask(userID, parts[4])(w, r)
+		default:
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// feedRoutes serves /user/{user_id}/feed
+func (s *Server) feedRoutes(w http.ResponseWriter, r *http.Request, h *handlers.FeedHandler, userID int, parts []string) {
+	if len(parts) > 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.GetFeed(userID)(w, r)
+	case http.MethodPost:
+		h.CreateFeed(userID)(w, r)
+	case http.MethodDelete:
+		h.DeleteFeed(userID)(w, r)
+	default:
+		s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+	}
+}
+
+// webhookRoutes serves /user/{user_id}/webhook/...
+func (s *Server) webhookRoutes(w http.ResponseWriter, r *http.Request, h *handlers.WebhookHandler, userID int, parts []string) {
+	// expect /user/{user_id}/webhook
+	if len(parts) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetWebhooks(userID)(w, r)
+		case http.MethodPost:
+			h.CreateWebhook(userID)(w, r)
+		default:
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		}
+		return
+	}
+
+	webhookID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		s.error(w, r, http.StatusBadRequest, errors.New("invalid webhook_id"))
+		return
+	}
+
+	// expect /user/{user_id}/webhook/{webhook_id}
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetWebhookByID(userID, webhookID)(w, r)
+		case http.MethodDelete:
+			h.DeleteWebhook(userID, webhookID)(w, r)
+		default:
+			s.error(w, r, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		}
+		return
+	}
+
+	// expect /user/{user_id}/webhook/{webhook_id}/delivery
+	if len(parts) == 2 && parts[1] == "delivery" {
+		h.GetDeliveries(userID, webhookID)(w, r)
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
 func (s *Server) error(w http.ResponseWriter, r *http.Request, code int, err error) {
 	s.respond(w, r, code, map[string]string{"error": err.Error()})
 }
@@ -2,11 +2,18 @@ package apiserver
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/apiserver/config"
@@ -162,4 +169,2039 @@ func TestServer_HandleLogin(t *testing.T) {
 			assert.Equal(t, tc.expectedCode, rec.Code)
 		})
 	}
-}
\ No newline at end of file
+}
+func TestServer_HandleGetTask(t *testing.T) {
+	cfg := config.InitConfig()
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	u := model.TestUser(t)
+	s.store.User().Create(u)
+
+	for i := 0; i < 3; i++ {
+		s.store.Todo().Create(model.TestTask(t, u.ID))
+	}
+
+	token, _ := s.tokenService.GenerateAccessToken(u.ID)
+
+	get := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/user/1/task?limit=2")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	page := &model.TaskPage{}
+	json.NewDecoder(rec.Body).Decode(page)
+	assert.Len(t, page.Tasks, 2)
+	assert.NotEmpty(t, page.NextCursor)
+	assert.Contains(t, rec.Header().Get("Link"), `rel="next"`)
+
+	rec = get("/user/1/task?limit=2&cursor=" + page.NextCursor)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	page = &model.TaskPage{}
+	json.NewDecoder(rec.Body).Decode(page)
+	assert.Len(t, page.Tasks, 1)
+	assert.Empty(t, page.NextCursor)
+	assert.NotContains(t, rec.Header().Get("Link"), `rel="next"`)
+
+	assert.Equal(t, http.StatusBadRequest, get("/user/1/task?limit=1000").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/user/1/task?cursor=invalid").Code)
+	assert.Equal(t, http.StatusForbidden, get("/user/2/task").Code)
+}
+
+func TestServer_HandleGetTask_Filter(t *testing.T) {
+	cfg := config.InitConfig()
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	u := model.TestUser(t)
+	s.store.User().Create(u)
+
+	for _, title := range []string{"buy milk", "write report", "call mom"} {
+		task := model.TestTask(t, u.ID)
+		task.Title = &title
+		task.Complete = new(bool)
+		*task.Complete = title == "call mom"
+		s.store.Todo().Create(task)
+	}
+
+	token, _ := s.tokenService.GenerateAccessToken(u.ID)
+
+	testCases := []struct {
+		name          string
+		query         string
+		expectedCode  int
+		expectedTasks []string
+	}{
+		{
+			name:          "by complete",
+			query:         "complete=false&sort=title",
+			expectedCode:  http.StatusOK,
+			expectedTasks: []string{"buy milk", "write report"},
+		},
+		{
+			name:          "by text",
+			query:         "text=REPORT",
+			expectedCode:  http.StatusOK,
+			expectedTasks: []string{"write report"},
+		},
+		{
+			name:          "sorted by title descending",
+			query:         "sort=title&order=desc",
+			expectedCode:  http.StatusOK,
+			expectedTasks: []string{"write report", "call mom", "buy milk"},
+		},
+		{
+			name:         "unknown parameter",
+			query:        "color=red",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "unknown sort field",
+			query:        "sort=color",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid deadline",
+			query:        "due_before=tomorrow",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/user/1/task?"+tc.query, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+
+			if tc.expectedTasks != nil {
+				page := &model.TaskPage{}
+				json.NewDecoder(rec.Body).Decode(page)
+
+				titles := []string{}
+				for _, task := range page.Tasks {
+					titles = append(titles, *task.Title)
+				}
+				assert.Equal(t, tc.expectedTasks, titles)
+			}
+		})
+	}
+}
+
+func TestServer_HandleSearchTask(t *testing.T) {
+	cfg := config.InitConfig()
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	u := model.TestUser(t)
+	s.store.User().Create(u)
+
+	for _, title := range []string{"quarterly report", "buy milk"} {
+		task := model.TestTask(t, u.ID)
+		task.Title = &title
+		s.store.Todo().Create(task)
+	}
+
+	token, _ := s.tokenService.GenerateAccessToken(u.ID)
+
+	testCases := []struct {
+		name          string
+		query         string
+		expectedCode  int
+		expectedCount int
+	}{
+		{
+			name:          "found",
+			query:         "q=report",
+			expectedCode:  http.StatusOK,
+			expectedCount: 1,
+		},
+		{
+			name:          "not found",
+			query:         "q=bread",
+			expectedCode:  http.StatusOK,
+			expectedCount: 0,
+		},
+		{
+			name:         "empty query",
+			query:        "q=",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "unknown parameter",
+			query:        "q=milk&sort=title",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/user/1/task/search?"+tc.query, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+
+			if tc.expectedCode == http.StatusOK {
+				results := []*model.TaskSearchResult{}
+				json.NewDecoder(rec.Body).Decode(&results)
+				assert.Len(t, results, tc.expectedCount)
+				for _, res := range results {
+					assert.Contains(t, res.Snippet, "<b>")
+				}
+			}
+		})
+	}
+
+	// the snippets are HTML, the text of the tasks is escaped
+	for _, title := range []string{"<img src=x onerror=alert(1)> invoice", "İz"} {
+		task := model.TestTask(t, u.ID)
+		task.Title, task.Description = &title, nil
+		s.store.Todo().Create(task)
+	}
+
+	snippets := map[string]string{
+		"invoice": "&lt;img src=x onerror=alert(1)&gt; <b>invoice</b>",
+		"z":       "İ<b>z</b>",
+	}
+
+	for q, snippet := range snippets {
+		rec := testRequest(s, token, http.MethodGet, "/user/1/task/search?q="+q, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		results := []*model.TaskSearchResult{}
+		json.NewDecoder(rec.Body).Decode(&results)
+		if assert.Len(t, results, 1, q) {
+			assert.Equal(t, snippet, results[0].Snippet)
+		}
+	}
+}
+
+func TestServer_HandleGetTaskByID(t *testing.T) {
+	cfg := config.InitConfig()
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	u := model.TestUser(t)
+	s.store.User().Create(u)
+	s.store.Todo().Create(model.TestTask(t, u.ID))
+
+	token, _ := s.tokenService.GenerateAccessToken(u.ID)
+
+	testCases := []struct {
+		name         string
+		path         string
+		expectedCode int
+	}{
+		{
+			name:         "found",
+			path:         "/user/1/task/1",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "not found",
+			path:         "/user/1/task/2",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "invalid task_id",
+			path:         "/user/1/task/first",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_HandleReplaceTask(t *testing.T) {
+	cfg := config.InitConfig()
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	u := model.TestUser(t)
+	s.store.User().Create(u)
+	s.store.Todo().Create(model.TestTask(t, u.ID))
+
+	token, _ := s.tokenService.GenerateAccessToken(u.ID)
+	deadline := time.Now().Add(48 * time.Hour).UTC().Format("2006-01-02 15:04:05")
+
+	testCases := []struct {
+		name         string
+		path         string
+		payload      interface{}
+		expectedCode int
+	}{
+		{
+			name: "valid",
+			path: "/user/1/task/1",
+			payload: map[string]interface{}{
+				"title":    "replaced",
+				"deadline": deadline,
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "without title",
+			path: "/user/1/task/1",
+			payload: map[string]interface{}{
+				"deadline": deadline,
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "not found",
+			path: "/user/1/task/2",
+			payload: map[string]interface{}{
+				"title":    "replaced",
+				"deadline": deadline,
+			},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(http.MethodPut, tc.path, b)
+			req.Header.Set("Authorization", "Bearer "+token)
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	task, err := s.store.Todo().FindByID(u.ID, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "replaced", *task.Title)
+	assert.Nil(t, task.Description)
+
+	// an overdue task keeps its deadline, only a new one must be in the future
+	overdue := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	assert.NoError(t, s.store.Todo().Update(&model.Task{UserID: u.ID, TaskID: 1, Deadline: &overdue}))
+
+	rec := testRequest(s, token, http.MethodPut, "/user/1/task/1", map[string]interface{}{
+		"title":    "still overdue",
+		"deadline": overdue.Format("2006-01-02 15:04:05"),
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = testRequest(s, token, http.MethodPut, "/user/1/task/1", map[string]interface{}{
+		"title":    "moved to the past",
+		"deadline": overdue.Add(-time.Hour).Format("2006-01-02 15:04:05"),
+	})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = testRequest(s, token, http.MethodPut, "/user/1/task/1", map[string]interface{}{"title": "no deadline"})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "wrong format of deadline")
+}
+
+func TestServer_HandleTags(t *testing.T) {
+	cfg := config.InitConfig()
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	u := model.TestUser(t)
+	s.store.User().Create(u)
+
+	token, _ := s.tokenService.GenerateAccessToken(u.ID)
+
+	do := func(method, url string, payload interface{}) *httptest.ResponseRecorder {
+		return testRequest(s, token, method, url, payload)
+	}
+
+	deadline := time.Now().Add(time.Hour).UTC().Format("2006-01-02 15:04:05")
+
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/user/1/tag", map[string]string{"name": "urgent"}).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, do(http.MethodPost, "/user/1/tag", map[string]string{"name": "urgent"}).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, do(http.MethodPost, "/user/1/tag", map[string]string{"name": " "}).Code)
+
+	rec := do(http.MethodPost, "/user/1/task", map[string]interface{}{
+		"title":    "fix login",
+		"deadline": deadline,
+		"tags":     []string{"backend", "urgent", "backend"},
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	task := &model.Task{}
+	json.NewDecoder(rec.Body).Decode(task)
+	assert.Equal(t, []string{"backend", "urgent"}, task.Tags)
+
+	do(http.MethodPost, "/user/1/task", map[string]interface{}{
+		"title":    "write docs",
+		"deadline": deadline,
+		"tags":     []string{"backend"},
+	})
+
+	tags := []*model.Tag{}
+	json.NewDecoder(do(http.MethodGet, "/user/1/tag", nil).Body).Decode(&tags)
+	assert.Len(t, tags, 2)
+
+	page := &model.TaskPage{}
+	json.NewDecoder(do(http.MethodGet, "/user/1/task?tag=backend&tag=urgent", nil).Body).Decode(page)
+	assert.Len(t, page.Tasks, 1)
+
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/user/1/task/1", map[string]interface{}{"tags": []string{}}).Code)
+	page = &model.TaskPage{}
+	json.NewDecoder(do(http.MethodGet, "/user/1/task?tag=urgent", nil).Body).Decode(page)
+	assert.Len(t, page.Tasks, 0)
+
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/user/1/tag/1", map[string]string{"name": "later"}).Code)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/user/1/tag/2", nil).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/user/1/tag/2", nil).Code)
+
+	page = &model.TaskPage{}
+	json.NewDecoder(do(http.MethodGet, "/user/1/task?tag=backend", nil).Body).Decode(page)
+	assert.Len(t, page.Tasks, 0)
+}
+
+func TestServer_HandleLists(t *testing.T) {
+	cfg := config.InitConfig()
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	u := model.TestUser(t)
+	s.store.User().Create(u)
+
+	token, _ := s.tokenService.GenerateAccessToken(u.ID)
+
+	do := func(method, url string, payload interface{}) *httptest.ResponseRecorder {
+		return testRequest(s, token, method, url, payload)
+	}
+
+	lists := []*model.List{}
+	json.NewDecoder(do(http.MethodGet, "/user/1/list", nil).Body).Decode(&lists)
+	assert.Len(t, lists, 1)
+	assert.True(t, lists[0].Inbox)
+	inboxID := lists[0].ID
+
+	rec := do(http.MethodPost, "/user/1/list", map[string]string{"name": "Work"})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	work := &model.List{}
+	json.NewDecoder(rec.Body).Decode(work)
+
+	deadline := time.Now().Add(time.Hour).UTC().Format("2006-01-02 15:04:05")
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/user/1/task", map[string]interface{}{
+		"title":    "report",
+		"deadline": deadline,
+		"list_id":  work.ID,
+	}).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, do(http.MethodPost, "/user/1/task", map[string]interface{}{
+		"title":    "report",
+		"deadline": deadline,
+		"list_id":  100,
+	}).Code)
+
+	page := &model.TaskPage{}
+	json.NewDecoder(do(http.MethodGet, fmt.Sprintf("/user/1/task?list_id=%d", work.ID), nil).Body).Decode(page)
+	assert.Len(t, page.Tasks, 1)
+
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/user/1/task/1", map[string]interface{}{"list_id": inboxID}).Code)
+
+	page = &model.TaskPage{}
+	json.NewDecoder(do(http.MethodGet, fmt.Sprintf("/user/1/task?list_id=%d", inboxID), nil).Body).Decode(page)
+	assert.Len(t, page.Tasks, 1)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, do(http.MethodDelete, fmt.Sprintf("/user/1/list/%d", inboxID), nil).Code)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, fmt.Sprintf("/user/1/list/%d", work.ID), nil).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, fmt.Sprintf("/user/1/list/%d", work.ID), nil).Code)
+}
+
+func testRequest(s *Server, token, method, url string, payload interface{}) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	b := &bytes.Buffer{}
+	if payload != nil {
+		json.NewEncoder(b).Encode(payload)
+	}
+	req, _ := http.NewRequest(method, url, b)
+	req.Header.Set("Authorization", "Bearer "+token)
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServer_HandleSubtasks(t *testing.T) {
+	cfg := config.InitConfig()
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	u := model.TestUser(t)
+	s.store.User().Create(u)
+
+	token, _ := s.tokenService.GenerateAccessToken(u.ID)
+	deadline := time.Now().Add(time.Hour).UTC().Format("2006-01-02 15:04:05")
+
+	do := func(method, url string, payload interface{}) *httptest.ResponseRecorder {
+		return testRequest(s, token, method, url, payload)
+	}
+
+	// 1 <- 2 <- 3
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/user/1/task", map[string]interface{}{"title": "release", "deadline": deadline}).Code)
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/user/1/task", map[string]interface{}{"title": "test", "deadline": deadline, "parent_task_id": 1}).Code)
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/user/1/task", map[string]interface{}{"title": "unit tests", "deadline": deadline, "parent_task_id": 2}).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, do(http.MethodPost, "/user/1/task", map[string]interface{}{"title": "orphan", "deadline": deadline, "parent_task_id": 100}).Code)
+
+	rec := do(http.MethodGet, "/user/1/task/1/tree", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	tree := &model.Task{}
+	json.NewDecoder(rec.Body).Decode(tree)
+	assert.Equal(t, 3, tree.Height())
+
+	assert.Equal(t, http.StatusUnprocessableEntity, do(http.MethodPatch, "/user/1/task/1", map[string]interface{}{"parent_task_id": 3}).Code)
+
+	// a null parent moves the subtask to the top level, a missing one keeps
+	// the parent
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/user/1/task/3", map[string]interface{}{"title": "unit"}).Code)
+	task, _ := s.store.Todo().FindByID(u.ID, 3)
+	assert.Equal(t, 2, *task.ParentTaskID)
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/user/1/task/3", map[string]interface{}{"parent_task_id": nil}).Code)
+	task, _ = s.store.Todo().FindByID(u.ID, 3)
+	assert.Nil(t, task.ParentTaskID)
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/user/1/task/3", map[string]interface{}{"parent_task_id": 2}).Code)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, do(http.MethodPatch, "/user/1/task/1", map[string]interface{}{"complete_subtasks": true}).Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/user/1/task/1", map[string]interface{}{"complete": true, "complete_subtasks": true}).Code)
+
+	task, _ = s.store.Todo().FindByID(u.ID, 3)
+	assert.True(t, *task.Complete)
+
+	assert.Equal(t, http.StatusOK, do(http.MethodDelete, "/user/1/task?ids=1", nil).Code)
+	_, err := s.store.Todo().FindByID(u.ID, 3)
+	assert.Error(t, err)
+}
+
+func TestServer_HandleRecurringTask(t *testing.T) {
+	cfg := config.InitConfig()
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	u := model.TestUser(t)
+	s.store.User().Create(u)
+
+	token, _ := s.tokenService.GenerateAccessToken(u.ID)
+	deadline := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	do := func(method, url string, payload interface{}) *httptest.ResponseRecorder {
+		return testRequest(s, token, method, url, payload)
+	}
+
+	payload := map[string]interface{}{
+		"title":    "standup",
+		"deadline": deadline.Format("2006-01-02 15:04:05"),
+		"tags":     []string{"work"},
+	}
+
+	payload["rrule"] = "FREQ=SECONDLY"
+	assert.Equal(t, http.StatusUnprocessableEntity, do(http.MethodPost, "/user/1/task", payload).Code)
+
+	payload["rrule"] = "freq=daily;count=2"
+	rec := do(http.MethodPost, "/user/1/task", payload)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	created := &model.Task{}
+	json.NewDecoder(rec.Body).Decode(created)
+	assert.Equal(t, "FREQ=DAILY;COUNT=2", *created.RRule)
+	assert.NotNil(t, created.SeriesID)
+
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/user/1/task/1", map[string]interface{}{"complete": true}).Code)
+
+	next, err := s.store.Todo().FindByID(u.ID, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, deadline.Add(24*time.Hour), *next.Deadline)
+	assert.Equal(t, "FREQ=DAILY;COUNT=1", *next.RRule)
+	assert.Equal(t, *created.SeriesID, *next.SeriesID)
+	assert.Equal(t, []string{"work"}, next.Tags)
+	assert.False(t, *next.Complete)
+
+	// completing the task again does not create another occurrence
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/user/1/task/1", map[string]interface{}{"complete": true}).Code)
+	// the last occurrence of the series has no successor
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/user/1/task/2", map[string]interface{}{"complete": true}).Code)
+
+	page, _ := s.store.Todo().Get(u.ID, model.NewTaskQuery())
+	assert.Len(t, page.Tasks, 2)
+}
+
+func TestServer_HandleReminders(t *testing.T) {
+	cfg := config.InitConfig()
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	u := model.TestUser(t)
+	s.store.User().Create(u)
+	task := model.TestTask(t, u.ID)
+	s.store.Todo().Create(task)
+
+	token, _ := s.tokenService.GenerateAccessToken(u.ID)
+
+	do := func(method, url string, payload interface{}) *httptest.ResponseRecorder {
+		return testRequest(s, token, method, url, payload)
+	}
+
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/user/1/task/1/reminder", map[string]string{"offset": "1h"}).Code)
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/user/1/task/1/reminder", map[string]string{"offset": "15m"}).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, do(http.MethodPost, "/user/1/task/1/reminder", map[string]string{"offset": "1h"}).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, do(http.MethodPost, "/user/1/task/1/reminder", map[string]string{"offset": "soon"}).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/user/1/task/2/reminder", map[string]string{"offset": "1h"}).Code)
+
+	rec := do(http.MethodGet, "/user/1/task/1/reminder", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	reminders := []*model.Reminder{}
+	json.NewDecoder(rec.Body).Decode(&reminders)
+	assert.Len(t, reminders, 2)
+	assert.Equal(t, "15m", reminders[0].Offset.String())
+	assert.True(t, task.Deadline.Add(-15*time.Minute).Equal(*reminders[0].RemindAt))
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/user/1/task/1/reminder/1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/user/1/task/1/reminder/1", nil).Code)
+
+	// the next occurrence of a recurring task has the reminders of the
+	// completed one
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/user/1/task/1", map[string]interface{}{"rrule": "FREQ=DAILY"}).Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/user/1/task/1", map[string]interface{}{"complete": true}).Code)
+
+	next, err := s.store.Todo().FindByID(u.ID, 2)
+	assert.NoError(t, err)
+
+	reminders = []*model.Reminder{}
+	json.NewDecoder(do(http.MethodGet, "/user/1/task/2/reminder", nil).Body).Decode(&reminders)
+	assert.Len(t, reminders, 1)
+	assert.Equal(t, "15m", reminders[0].Offset.String())
+	assert.True(t, next.Deadline.Add(-15*time.Minute).Equal(*reminders[0].RemindAt))
+	assert.Nil(t, reminders[0].SentAt)
+
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/user/1/task/2/reminder", map[string]string{"offset": "1h"}).Code)
+	json.NewDecoder(do(http.MethodGet, "/user/1/task/1/reminder", nil).Body).Decode(&reminders)
+	assert.Len(t, reminders, 1)
+}
+
+func TestServer_HandleWebhooks(t *testing.T) {
+	events := make(chan *model.WebhookEvent, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e := &model.WebhookEvent{}
+		json.NewDecoder(r.Body).Decode(e)
+		events <- e
+	}))
+	defer receiver.Close()
+
+	cfg := config.InitConfig()
+	u := model.TestUser(t)
+
+	// the webhooks cannot point to the host of the server or to its network
+	strict := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	strict.store.User().Create(u)
+	strictToken, _ := strict.tokenService.GenerateAccessToken(u.ID)
+
+	for _, target := range []string{receiver.URL, "http://127.0.0.1:8080/hook", "http://10.0.0.1/hook", "http://169.254.169.254/latest", "http://[::1]/hook"} {
+		rec := testRequest(strict, strictToken, http.MethodPost, "/user/1/webhook", map[string]interface{}{"url": target})
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, target)
+	}
+
+	// the receiver of the test listens on the loopback
+	privateCfg := *cfg
+	privateCfg.Webhooks.AllowPrivateTargets = true
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), &privateCfg)
+	s.store.User().Create(u)
+
+	token, _ := s.tokenService.GenerateAccessToken(u.ID)
+	deadline := time.Now().Add(time.Hour).UTC().Format("2006-01-02 15:04:05")
+
+	do := func(method, url string, payload interface{}) *httptest.ResponseRecorder {
+		return testRequest(s, token, method, url, payload)
+	}
+
+	assert.Equal(t, http.StatusUnprocessableEntity, do(http.MethodPost, "/user/1/webhook", map[string]interface{}{"url": "ftp://example.com"}).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, do(http.MethodPost, "/user/1/webhook", map[string]interface{}{"url": receiver.URL, "events": []string{"task.archived"}}).Code)
+
+	rec := do(http.MethodPost, "/user/1/webhook", map[string]interface{}{"url": receiver.URL})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	wh := &model.Webhook{}
+	json.NewDecoder(rec.Body).Decode(wh)
+	assert.NotEmpty(t, wh.Secret)
+
+	rec = do(http.MethodGet, "/user/1/webhook/1", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	wh = &model.Webhook{}
+	json.NewDecoder(rec.Body).Decode(wh)
+	assert.Empty(t, wh.Secret)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.dispatcher.Run(ctx)
+
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/user/1/task", map[string]interface{}{"title": "ship", "deadline": deadline}).Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/user/1/task/1", map[string]interface{}{"complete": true}).Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodDelete, "/user/1/task?ids=1", nil).Code)
+
+	received := map[string]bool{}
+	for i := 0; i < 4; i++ {
+		select {
+		case e := <-events:
+			assert.Equal(t, 1, e.TaskID)
+			received[e.Type] = true
+		case <-time.After(5 * time.Second):
+			t.Fatal("events were not delivered")
+		}
+	}
+
+	assert.Equal(t, map[string]bool{
+		model.EventTaskCreated:   true,
+		model.EventTaskUpdated:   true,
+		model.EventTaskCompleted: true,
+		model.EventTaskDeleted:   true,
+	}, received)
+
+	// the subtasks completed along with a recurring task and its next
+	// occurrence get their events too
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/user/1/task", map[string]interface{}{"title": "standup", "deadline": deadline, "rrule": "FREQ=DAILY"}).Code)
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/user/1/task", map[string]interface{}{"title": "notes", "deadline": deadline, "parent_task_id": 2}).Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/user/1/task/2", map[string]interface{}{"complete": true, "complete_subtasks": true}).Code)
+
+	received = map[string]bool{}
+	for i := 0; i < 7; i++ {
+		select {
+		case e := <-events:
+			received[fmt.Sprintf("%s %d", e.Type, e.TaskID)] = true
+		case <-time.After(5 * time.Second):
+			t.Fatal("events were not delivered")
+		}
+	}
+
+	assert.Equal(t, map[string]bool{
+		"task.created 2":   true,
+		"task.created 3":   true,
+		"task.updated 2":   true,
+		"task.completed 2": true,
+		"task.updated 3":   true,
+		"task.completed 3": true,
+		"task.created 4":   true,
+	}, received)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/user/1/webhook/1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/user/1/webhook/1/delivery", nil).Code)
+}
+
+func TestServer_HandleTaskHistory(t *testing.T) {
+	cfg := config.InitConfig()
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	u := model.TestUser(t)
+	s.store.User().Create(u)
+
+	token, _ := s.tokenService.GenerateAccessToken(u.ID)
+	deadline := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	moved := deadline.Add(24 * time.Hour)
+
+	do := func(method, url string, payload interface{}) *httptest.ResponseRecorder {
+		return testRequest(s, token, method, url, payload)
+	}
+
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/user/1/task", map[string]interface{}{"title": "release", "deadline": deadline.Format("2006-01-02 15:04:05")}).Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/user/1/task/1", map[string]interface{}{"deadline": moved.Format("2006-01-02 15:04:05")}).Code)
+	// an update that changes nothing is not recorded
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/user/1/task/1", map[string]interface{}{"title": "release"}).Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodDelete, "/user/1/task?ids=1", nil).Code)
+
+	rec := do(http.MethodGet, "/user/1/task/1/history", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	events := []*model.TaskEvent{}
+	json.NewDecoder(rec.Body).Decode(&events)
+	assert.Len(t, events, 3)
+	assert.Equal(t, model.TaskEventCreated, events[0].Type)
+	assert.Equal(t, model.TaskEventUpdated, events[1].Type)
+	assert.Equal(t, u.ID, events[1].ActorID)
+	assert.Equal(t, &model.FieldChange{
+		Before: deadline.Format(time.RFC3339),
+		After:  moved.Format(time.RFC3339),
+	}, events[1].Changes["deadline"])
+	assert.Len(t, events[1].Changes, 1)
+	assert.Equal(t, model.TaskEventDeleted, events[2].Type)
+
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/user/1/task/2/history", nil).Code)
+
+	// the user a task is shared with is recorded when deleting it
+	guest := model.TestUser(t)
+	guest.Email = "guest@example.org"
+	s.store.User().Create(guest)
+	guestToken, _ := s.tokenService.GenerateAccessToken(guest.ID)
+
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/user/1/task", map[string]interface{}{"title": "review", "deadline": deadline.Format("2006-01-02 15:04:05")}).Code)
+	rec = do(http.MethodPost, "/user/1/share", map[string]interface{}{"email": guest.Email, "role": model.RoleEditor, "task_id": 2})
+	share := &model.Share{}
+	json.NewDecoder(rec.Body).Decode(share)
+	assert.Equal(t, http.StatusOK, testRequest(s, guestToken, http.MethodPost, fmt.Sprintf("/user/2/invitation/%d/accept", share.ID), nil).Code)
+	assert.Equal(t, http.StatusNoContent, testRequest(s, guestToken, http.MethodDelete, "/user/1/task/2", nil).Code)
+
+	events = []*model.TaskEvent{}
+	json.NewDecoder(do(http.MethodGet, "/user/1/task/2/history", nil).Body).Decode(&events)
+	assert.Equal(t, model.TaskEventDeleted, events[len(events)-1].Type)
+	assert.Equal(t, guest.ID, events[len(events)-1].ActorID)
+}
+
+func TestServer_HandleTrash(t *testing.T) {
+	cfg := config.InitConfig()
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	u := model.TestUser(t)
+	s.store.User().Create(u)
+	s.store.Todo().Create(model.TestTask(t, u.ID))
+	s.store.Todo().Create(model.TestTask(t, u.ID))
+
+	token, _ := s.tokenService.GenerateAccessToken(u.ID)
+
+	do := func(method, url string, payload interface{}) *httptest.ResponseRecorder {
+		return testRequest(s, token, method, url, payload)
+	}
+
+	assert.Equal(t, http.StatusOK, do(http.MethodDelete, "/user/1/task?ids=1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/user/1/task/1", nil).Code)
+
+	rec := do(http.MethodGet, "/user/1/task", nil)
+	page := &model.TaskPage{}
+	json.NewDecoder(rec.Body).Decode(page)
+	assert.Len(t, page.Tasks, 1)
+
+	rec = do(http.MethodGet, "/user/1/trash", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	trash := []*model.Task{}
+	json.NewDecoder(rec.Body).Decode(&trash)
+	assert.Len(t, trash, 1)
+	assert.Equal(t, 1, trash[0].TaskID)
+	assert.NotNil(t, trash[0].DeletedAt)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, do(http.MethodPost, "/user/1/trash/2/restore", nil).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/user/1/trash/3/restore", nil).Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/user/1/trash/1/restore", nil).Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/user/1/task/1", nil).Code)
+
+	rec = do(http.MethodGet, "/user/1/task/1/history", nil)
+	events := []*model.TaskEvent{}
+	json.NewDecoder(rec.Body).Decode(&events)
+	assert.Equal(t, model.TaskEventRestored, events[len(events)-1].Type)
+}
+
+func TestServer_HandleTaskVersions(t *testing.T) {
+	cfg := config.InitConfig()
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	u := model.TestUser(t)
+	s.store.User().Create(u)
+	s.store.Todo().Create(model.TestTask(t, u.ID))
+
+	token, _ := s.tokenService.GenerateAccessToken(u.ID)
+
+	strictCfg := *cfg
+	strictCfg.Concurrency.RequireIfMatch = true
+	strict := newServer(s.store, logger.InitLogger(cfg.Env), &strictCfg)
+
+	do := func(s *Server, method, url, ifMatch string, payload interface{}) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		b := &bytes.Buffer{}
+		if payload != nil {
+			json.NewEncoder(b).Encode(payload)
+		}
+		req, _ := http.NewRequest(method, url, b)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(s, http.MethodGet, "/user/1/task/1", "", nil)
+	assert.Equal(t, `"1"`, rec.Header().Get("ETag"))
+
+	rec = do(s, http.MethodPatch, "/user/1/task/1", `"1"`, map[string]string{"title": "first"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+
+	assert.Equal(t, http.StatusPreconditionFailed, do(s, http.MethodPatch, "/user/1/task/1", `"1"`, map[string]string{"title": "second"}).Code)
+	assert.Equal(t, http.StatusBadRequest, do(s, http.MethodPatch, "/user/1/task/1", "2", map[string]string{"title": "second"}).Code)
+	assert.Equal(t, http.StatusOK, do(s, http.MethodPatch, "/user/1/task/1", "", map[string]string{"title": "second"}).Code)
+
+	assert.Equal(t, http.StatusPreconditionRequired, do(strict, http.MethodPatch, "/user/1/task/1", "", map[string]string{"title": "third"}).Code)
+	assert.Equal(t, http.StatusPreconditionRequired, do(strict, http.MethodDelete, "/user/1/task/1", "", nil).Code)
+	assert.Equal(t, http.StatusPreconditionRequired, do(strict, http.MethodDelete, "/user/1/task?ids=1", `"2"`, nil).Code)
+	assert.Equal(t, http.StatusPreconditionFailed, do(strict, http.MethodDelete, "/user/1/task/1", `"2"`, nil).Code)
+	assert.Equal(t, http.StatusNoContent, do(strict, http.MethodDelete, "/user/1/task/1", `"3"`, nil).Code)
+	assert.Equal(t, http.StatusNotFound, do(strict, http.MethodDelete, "/user/1/task/1", "*", nil).Code)
+}
+
+func TestServer_HandleTaskBatch(t *testing.T) {
+	cfg := config.InitConfig()
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	u := model.TestUser(t)
+	s.store.User().Create(u)
+	s.store.Todo().Create(model.TestTask(t, u.ID))
+	s.store.Todo().Create(model.TestTask(t, u.ID))
+
+	token, _ := s.tokenService.GenerateAccessToken(u.ID)
+	deadline := time.Now().Add(time.Hour).UTC().Format("2006-01-02 15:04:05")
+
+	batch := func(mode string, ops ...map[string]interface{}) (int, *model.TaskBatchResult) {
+		rec := testRequest(s, token, http.MethodPost, "/user/1/task:batch", map[string]interface{}{
+			"mode":       mode,
+			"operations": ops,
+		})
+		res := &model.TaskBatchResult{}
+		json.NewDecoder(rec.Body).Decode(res)
+		return rec.Code, res
+	}
+
+	count := func() int {
+		page, _ := s.store.Todo().Get(u.ID, model.NewTaskQuery())
+		return len(page.Tasks)
+	}
+
+	code, res := batch("",
+		map[string]interface{}{"op": "create", "task": map[string]interface{}{"title": "new", "deadline": deadline}},
+		map[string]interface{}{"op": "update", "task_id": 1, "version": 1, "task": map[string]interface{}{"title": "renamed"}},
+		map[string]interface{}{"op": "complete", "task_id": 1},
+		map[string]interface{}{"op": "delete", "task_id": 2},
+	)
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, res.Committed)
+	assert.Equal(t, []int{http.StatusCreated, http.StatusOK, http.StatusOK, http.StatusNoContent}, statuses(res))
+	assert.Equal(t, 3, res.Results[0].TaskID)
+	assert.True(t, *res.Results[2].Task.Complete)
+	assert.Equal(t, 2, count())
+
+	code, res = batch(model.BatchAtomic,
+		map[string]interface{}{"op": "create", "task": map[string]interface{}{"title": "rolled back", "deadline": deadline}},
+		map[string]interface{}{"op": "update", "task_id": 1, "version": 1, "task": map[string]interface{}{"title": "stale"}},
+		map[string]interface{}{"op": "delete", "task_id": 3},
+	)
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.False(t, res.Committed)
+	assert.Equal(t, []int{http.StatusCreated, http.StatusPreconditionFailed, http.StatusFailedDependency}, statuses(res))
+	assert.Equal(t, 2, count())
+
+	code, res = batch(model.BatchBestEffort,
+		map[string]interface{}{"op": "create", "task": map[string]interface{}{"title": "kept", "deadline": deadline}},
+		map[string]interface{}{"op": "delete", "task_id": 100},
+		map[string]interface{}{"op": "archive", "task_id": 1},
+	)
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, res.Committed)
+	assert.Equal(t, []int{http.StatusCreated, http.StatusNotFound, http.StatusUnprocessableEntity}, statuses(res))
+	assert.Equal(t, 3, count())
+
+	code, _ = batch("sometimes", map[string]interface{}{"op": "delete", "task_id": 1})
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+
+	// a null operation is rejected before the batch runs
+	for _, mode := range []string{model.BatchAtomic, model.BatchBestEffort} {
+		rec := testRequest(s, token, http.MethodPost, "/user/1/task:batch", map[string]interface{}{
+			"mode":       mode,
+			"operations": []interface{}{map[string]interface{}{"op": "delete", "task_id": 1}, nil},
+		})
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	}
+	assert.Equal(t, 3, count())
+
+	// with If-Match required, the operations on stored tasks need a version
+	strictCfg := *cfg
+	strictCfg.Concurrency.RequireIfMatch = true
+	s = newServer(s.store, logger.InitLogger(cfg.Env), &strictCfg)
+
+	stored, _ := s.store.Todo().FindByID(u.ID, 1)
+
+	code, res = batch(model.BatchBestEffort,
+		map[string]interface{}{"op": "create", "task": map[string]interface{}{"title": "unconditional", "deadline": deadline}},
+		map[string]interface{}{"op": "update", "task_id": 1, "task": map[string]interface{}{"title": "no version"}},
+		map[string]interface{}{"op": "complete", "task_id": 1},
+		map[string]interface{}{"op": "delete", "task_id": 1},
+		map[string]interface{}{"op": "delete", "task_id": 1, "version": stored.Version},
+	)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []int{
+		http.StatusCreated,
+		http.StatusPreconditionRequired,
+		http.StatusPreconditionRequired,
+		http.StatusPreconditionRequired,
+		http.StatusNoContent,
+	}, statuses(res))
+}
+
+func statuses(res *model.TaskBatchResult) []int {
+	codes := []int{}
+	for _, r := range res.Results {
+		codes = append(codes, r.Status)
+	}
+	return codes
+}
+
+func TestServer_HandleTaskImportExport(t *testing.T) {
+	cfg := config.InitConfig()
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	u := model.TestUser(t)
+	s.store.User().Create(u)
+	other := model.TestUser(t)
+	other.Email = "other@example.org"
+	s.store.User().Create(other)
+
+	parent := model.TestTask(t, u.ID)
+	s.store.Todo().Create(parent)
+	child := model.TestTask(t, u.ID)
+	child.ParentTaskID = &parent.TaskID
+	child.Tags = []string{"work"}
+	s.store.Todo().Create(child)
+
+	token, _ := s.tokenService.GenerateAccessToken(u.ID)
+	otherToken, _ := s.tokenService.GenerateAccessToken(other.ID)
+
+	importFile := func(query string, body string) (int, *model.TaskImportResult) {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/user/%d/task/import?%s", other.ID, query), strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+otherToken)
+		s.ServeHTTP(rec, req)
+		res := &model.TaskImportResult{}
+		json.NewDecoder(rec.Body).Decode(res)
+		return rec.Code, res
+	}
+
+	for _, format := range []string{"csv", "json", "ndjson"} {
+		t.Run(format, func(t *testing.T) {
+			rec := testRequest(s, token, http.MethodGet, "/user/1/task/export?format="+format, nil)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Header().Get("Content-Disposition"), "tasks."+format)
+			file := rec.Body.String()
+
+			code, res := importFile("dry_run=true&format="+format, file)
+			assert.Equal(t, http.StatusOK, code)
+			assert.True(t, res.DryRun)
+			assert.Equal(t, 2, res.Imported)
+		})
+	}
+
+	code, res := importFile("format=json", `[
+		{"task_id": 7, "title": "old", "deadline": "2001-01-01 10:00:00", "complete": true},
+		{"task_id": 8, "parent_task_id": 9, "title": "orphan", "deadline": "2030-01-01 10:00:00"},
+		{"task_id": 10, "title": "", "deadline": "2030-01-01 10:00:00"}
+	]`)
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.Equal(t, []int{1, 2, 3}, importRows(res))
+
+	code, res = importFile("format=json&allow_past_deadline=true", `[
+		{"task_id": 8, "parent_task_id": 7, "title": "child", "deadline": "2030-01-01 10:00:00", "tags": ["work"]},
+		{"task_id": 7, "title": "old", "deadline": "2001-01-01 10:00:00", "complete": true}
+	]`)
+	assert.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, 2, res.Imported)
+
+	tree, err := s.store.Todo().FindTree(other.ID, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, "old", *tree.Title)
+	assert.Len(t, tree.Children, 1)
+	assert.Equal(t, []string{"work"}, tree.Children[0].Tags)
+
+	code, _ = importFile("format=xml", "<tasks/>")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, http.StatusBadRequest, testRequest(s, token, http.MethodGet, "/user/1/task/export?format=xml", nil).Code)
+
+	// an export is streamed past the write timeout of the server
+	srv := httptest.NewUnstartedServer(s)
+	srv.Config.WriteTimeout = time.Nanosecond
+	srv.Start()
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/user/1/task/export?format=ndjson", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := srv.Client().Do(req)
+	if assert.NoError(t, err) {
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, 2, strings.Count(string(body), "\n"))
+	}
+}
+
+func importRows(res *model.TaskImportResult) []int {
+	rows := []int{}
+	for _, e := range res.Errors {
+		rows = append(rows, e.Row)
+	}
+	return rows
+}
+
+func TestServer_HandleFeed(t *testing.T) {
+	cfg := config.InitConfig()
+	cfg.Feed.RateLimit = 3
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	u := model.TestUser(t)
+	s.store.User().Create(u)
+	s.store.Todo().Create(model.TestTask(t, u.ID))
+
+	token, _ := s.tokenService.GenerateAccessToken(u.ID)
+
+	do := func(method, url string, payload interface{}) *httptest.ResponseRecorder {
+		return testRequest(s, token, method, url, payload)
+	}
+
+	feed := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/user/1/feed", nil).Code)
+
+	rec := do(http.MethodPost, "/user/1/feed", nil)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	created := map[string]interface{}{}
+	json.NewDecoder(rec.Body).Decode(&created)
+	assert.Len(t, created["token"], 64)
+
+	path := fmt.Sprintf("/feed/%s.ics", created["token"])
+	assert.True(t, strings.HasSuffix(created["url"].(string), path))
+
+	rec = do(http.MethodGet, "/user/1/feed", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "token")
+
+	rec = feed(path)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/calendar; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "UID:task-1@taskmanager-api")
+
+	assert.Equal(t, http.StatusOK, feed(path).Code)
+	assert.Equal(t, http.StatusOK, feed(path).Code)
+	rec = feed(path)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+
+	// rotating the feed retires the old URL
+	rec = do(http.MethodPost, "/user/1/feed", nil)
+	rotated := map[string]interface{}{}
+	json.NewDecoder(rec.Body).Decode(&rotated)
+	assert.NotEqual(t, created["token"], rotated["token"])
+
+	rotatedPath := fmt.Sprintf("/feed/%s.ics", rotated["token"])
+	assert.Equal(t, http.StatusOK, feed(rotatedPath).Code)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/user/1/feed", nil).Code)
+	assert.Equal(t, http.StatusNotFound, feed(rotatedPath).Code)
+	assert.Equal(t, http.StatusNotFound, feed("/feed/unknown").Code)
+
+	// the requests with unknown tokens share the limit of their address
+	assert.Equal(t, http.StatusNotFound, feed("/feed/a.ics").Code)
+	assert.Equal(t, http.StatusNotFound, feed("/feed/b.ics").Code)
+	assert.Equal(t, http.StatusTooManyRequests, feed("/feed/c.ics").Code)
+
+	rec = httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/feed/c.ics", nil)
+	req.RemoteAddr = "198.51.100.7:4321"
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestServer_HandleCalDAV(t *testing.T) {
+	cfg := config.InitConfig()
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	u := model.TestUser(t)
+	s.store.User().Create(u)
+	s.store.Todo().Create(model.TestTask(t, u.ID))
+
+	token, _ := s.tokenService.GenerateAccessToken(u.ID)
+
+	rec := testRequest(s, token, http.MethodPost, "/user/1/app_password", map[string]string{"name": "phone"})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	created := map[string]interface{}{}
+	json.NewDecoder(rec.Body).Decode(&created)
+	password := created["password"].(string)
+
+	rec = testRequest(s, token, http.MethodGet, "/user/1/app_password", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), password)
+
+	dav := func(method, url, body string, headers map[string]string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, strings.NewReader(body))
+		req.SetBasicAuth(u.Email, password)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	syncToken := func(rec *httptest.ResponseRecorder) string {
+		body := rec.Body.String()
+		start := strings.LastIndex(body, "<D:sync-token>") + len("<D:sync-token>")
+		return body[start:strings.LastIndex(body, "</D:sync-token>")]
+	}
+
+	vtodo := func(uid, status string) string {
+		due := time.Now().Add(48 * time.Hour).UTC().Format("20060102T150405Z")
+		return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VTODO\r\nUID:" + uid + "\r\nSUMMARY:call mom\r\nDUE:" + due +
+			"\r\nSTATUS:" + status + "\r\nBEGIN:VALARM\r\nSUMMARY:alarm\r\nEND:VALARM\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+	}
+
+	// the account password is not an app password
+	rec = httptest.NewRecorder()
+	req, _ := http.NewRequest("PROPFIND", "/dav/", nil)
+	req.SetBasicAuth(u.Email, u.Password)
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Header().Get("WWW-Authenticate"), "Basic")
+
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/.well-known/caldav", nil)
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+	assert.Equal(t, "/dav/", rec.Header().Get("Location"))
+
+	rec = dav(http.MethodOptions, "/dav/calendars/1/tasks/", "", nil)
+	assert.Contains(t, rec.Header().Get("DAV"), "calendar-access")
+
+	rec = dav("PROPFIND", "/dav/", `<D:propfind xmlns:D="DAV:"><D:prop><D:current-user-principal/></D:prop></D:propfind>`, map[string]string{"Depth": "0"})
+	assert.Equal(t, http.StatusMultiStatus, rec.Code)
+	assert.Contains(t, rec.Body.String(), "<D:current-user-principal><D:href>/dav/principals/1/</D:href>")
+
+	rec = dav("PROPFIND", "/dav/principals/1/", `<D:propfind xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav"><D:prop><C:calendar-home-set/><D:unknown/></D:prop></D:propfind>`, nil)
+	assert.Contains(t, rec.Body.String(), "<C:calendar-home-set><D:href>/dav/calendars/1/</D:href>")
+	assert.Contains(t, rec.Body.String(), "<D:unknown/></D:prop><D:status>HTTP/1.1 404 Not Found</D:status>")
+
+	assert.Equal(t, http.StatusForbidden, dav("PROPFIND", "/dav/calendars/2/tasks/", "", nil).Code)
+
+	rec = dav("PROPFIND", "/dav/calendars/1/tasks/", "", map[string]string{"Depth": "1"})
+	assert.Equal(t, http.StatusMultiStatus, rec.Code)
+	assert.Contains(t, rec.Body.String(), `<C:comp name="VTODO"/>`)
+	assert.Contains(t, rec.Body.String(), "<D:href>/dav/calendars/1/tasks/task-1@taskmanager-api.ics</D:href>")
+
+	syncReport := func(token string) *httptest.ResponseRecorder {
+		return dav("REPORT", "/dav/calendars/1/tasks/", `<D:sync-collection xmlns:D="DAV:"><D:sync-token>`+token+
+			`</D:sync-token><D:sync-level>1</D:sync-level><D:prop><D:getetag/></D:prop></D:sync-collection>`, nil)
+	}
+
+	rec = syncReport("")
+	assert.Equal(t, http.StatusMultiStatus, rec.Code)
+	assert.Contains(t, rec.Body.String(), "task-1@taskmanager-api.ics")
+	first := syncToken(rec)
+
+	rec = dav(http.MethodPut, "/dav/calendars/1/tasks/abc.ics", vtodo("abc", "NEEDS-ACTION"), map[string]string{"If-None-Match": "*"})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, `"1"`, rec.Header().Get("ETag"))
+
+	rec = dav(http.MethodPut, "/dav/calendars/1/tasks/abc.ics", vtodo("abc", "NEEDS-ACTION"), map[string]string{"If-None-Match": "*"})
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	rec = dav(http.MethodPut, "/dav/calendars/1/tasks/other.ics", vtodo("task-1@taskmanager-api", "NEEDS-ACTION"), nil)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = syncReport(first)
+	assert.Contains(t, rec.Body.String(), "/dav/calendars/1/tasks/abc.ics")
+	assert.NotContains(t, rec.Body.String(), "task-1@taskmanager-api.ics")
+	second := syncToken(rec)
+
+	rec = dav(http.MethodGet, "/dav/calendars/1/tasks/abc.ics", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "UID:abc\r\n")
+	assert.Contains(t, rec.Body.String(), "SUMMARY:call mom\r\n")
+
+	rec = dav(http.MethodPut, "/dav/calendars/1/tasks/abc.ics", vtodo("abc", "COMPLETED"), map[string]string{"If-Match": `"9"`})
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	rec = dav(http.MethodPut, "/dav/calendars/1/tasks/abc.ics", vtodo("abc", "COMPLETED"), map[string]string{"If-Match": `"1"`})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+
+	stored, _ := s.store.Todo().FindByICalUID(u.ID, "abc")
+	assert.True(t, *stored.Complete)
+
+	rec = dav("REPORT", "/dav/calendars/1/tasks/", `<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+		<D:prop><D:getetag/><C:calendar-data/></D:prop>
+		<C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VTODO">
+			<C:prop-filter name="COMPLETED"><C:is-not-defined/></C:prop-filter>
+		</C:comp-filter></C:comp-filter></C:filter>
+	</C:calendar-query>`, nil)
+	assert.Equal(t, http.StatusMultiStatus, rec.Code)
+	assert.Contains(t, rec.Body.String(), "task-1@taskmanager-api.ics")
+	assert.Contains(t, rec.Body.String(), "BEGIN:VTODO")
+	assert.NotContains(t, rec.Body.String(), "abc.ics")
+
+	rec = dav("REPORT", "/dav/calendars/1/tasks/", `<C:calendar-multiget xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+		<D:prop><D:getetag/></D:prop>
+		<D:href>/dav/calendars/1/tasks/abc.ics</D:href>
+		<D:href>/dav/calendars/1/tasks/missing.ics</D:href>
+	</C:calendar-multiget>`, nil)
+	assert.Contains(t, rec.Body.String(), `<D:getetag>&#34;2&#34;</D:getetag>`)
+	assert.Contains(t, rec.Body.String(), "<D:href>/dav/calendars/1/tasks/missing.ics</D:href><D:status>HTTP/1.1 404 Not Found</D:status>")
+
+	assert.Equal(t, http.StatusNoContent, dav(http.MethodDelete, "/dav/calendars/1/tasks/abc.ics", "", nil).Code)
+	assert.Equal(t, http.StatusNotFound, dav(http.MethodGet, "/dav/calendars/1/tasks/abc.ics", "", nil).Code)
+
+	rec = syncReport(second)
+	assert.Contains(t, rec.Body.String(), "<D:href>/dav/calendars/1/tasks/abc.ics</D:href><D:status>HTTP/1.1 404 Not Found</D:status>")
+
+	rec = syncReport("urn:other:1")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "valid-sync-token")
+
+	// the UID of a deleted task is free again, the deleted task cannot be
+	// restored while another task has it
+	rec = dav(http.MethodPut, "/dav/calendars/1/tasks/abc.ics", vtodo("abc", "NEEDS-ACTION"), map[string]string{"If-None-Match": "*"})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, http.StatusOK, dav(http.MethodGet, "/dav/calendars/1/tasks/abc.ics", "", nil).Code)
+	assert.Equal(t, http.StatusConflict, testRequest(s, token, http.MethodPost, "/user/1/trash/2/restore", nil).Code)
+
+	// a task blocked by an open task cannot be completed through CalDAV
+	stored, _ = s.store.Todo().FindByICalUID(u.ID, "abc")
+	assert.Equal(t, http.StatusCreated, testRequest(s, token, http.MethodPost, fmt.Sprintf("/user/1/task/%d/dependency", stored.TaskID), map[string]int{"blocked_by": 1}).Code)
+	assert.Equal(t, http.StatusConflict, dav(http.MethodPut, "/dav/calendars/1/tasks/abc.ics", vtodo("abc", "COMPLETED"), nil).Code)
+	stored, _ = s.store.Todo().FindByICalUID(u.ID, "abc")
+	assert.False(t, *stored.Complete)
+
+	// a subtask is related to the UID its parent has in the client
+	rec = testRequest(s, token, http.MethodPost, "/user/1/task", map[string]interface{}{
+		"title":          "buy flowers",
+		"deadline":       time.Now().Add(time.Hour).UTC().Format("2006-01-02 15:04:05"),
+		"parent_task_id": stored.TaskID,
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	sub := &model.Task{}
+	json.NewDecoder(rec.Body).Decode(sub)
+	rec = dav(http.MethodGet, fmt.Sprintf("/dav/calendars/1/tasks/task-%d@taskmanager-api.ics", sub.TaskID), "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "RELATED-TO:abc\r\n")
+
+	// with If-Match required, a stored task is only replaced or deleted
+	// conditionally
+	strictCfg := *cfg
+	strictCfg.Concurrency.RequireIfMatch = true
+	s = newServer(s.store, logger.InitLogger(cfg.Env), &strictCfg)
+
+	assert.Equal(t, http.StatusPreconditionRequired, dav(http.MethodPut, "/dav/calendars/1/tasks/abc.ics", vtodo("abc", "NEEDS-ACTION"), nil).Code)
+	assert.Equal(t, http.StatusPreconditionRequired, dav(http.MethodDelete, "/dav/calendars/1/tasks/abc.ics", "", nil).Code)
+	assert.Equal(t, http.StatusCreated, dav(http.MethodPut, "/dav/calendars/1/tasks/new.ics", vtodo("new", "NEEDS-ACTION"), nil).Code)
+	assert.Equal(t, http.StatusNoContent, dav(http.MethodDelete, "/dav/calendars/1/tasks/new.ics", "", map[string]string{"If-Match": `"1"`}).Code)
+}
+
+func TestServer_HandleShares(t *testing.T) {
+	cfg := config.InitConfig()
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	owner := model.TestUser(t)
+	s.store.User().Create(owner)
+	guest := model.TestUser(t)
+	guest.Email = "guest@example.org"
+	s.store.User().Create(guest)
+
+	ownerToken, _ := s.tokenService.GenerateAccessToken(owner.ID)
+	guestToken, _ := s.tokenService.GenerateAccessToken(guest.ID)
+
+	rec := testRequest(s, ownerToken, http.MethodPost, "/user/1/list", map[string]string{"name": "Work"})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	work := &model.List{}
+	json.NewDecoder(rec.Body).Decode(work)
+
+	assert.Equal(t, http.StatusCreated, testRequest(s, ownerToken, http.MethodPost, "/user/1/task", map[string]interface{}{
+		"title":    "report",
+		"deadline": time.Now().Add(time.Hour).UTC().Format("2006-01-02 15:04:05"),
+		"list_id":  work.ID,
+	}).Code)
+
+	invite := func(role string) *httptest.ResponseRecorder {
+		return testRequest(s, ownerToken, http.MethodPost, "/user/1/share", map[string]interface{}{
+			"email":   guest.Email,
+			"role":    role,
+			"list_id": work.ID,
+		})
+	}
+
+	assert.Equal(t, http.StatusUnprocessableEntity, testRequest(s, ownerToken, http.MethodPost, "/user/1/share", map[string]interface{}{
+		"email":   "nobody@example.org",
+		"role":    model.RoleViewer,
+		"list_id": work.ID,
+	}).Code)
+	assert.Equal(t, http.StatusNotFound, testRequest(s, ownerToken, http.MethodPost, "/user/1/share", map[string]interface{}{
+		"email":   guest.Email,
+		"role":    model.RoleViewer,
+		"task_id": 100,
+	}).Code)
+
+	rec = invite(model.RoleViewer)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	share := &model.Share{}
+	json.NewDecoder(rec.Body).Decode(share)
+	assert.Equal(t, model.ShareStatusPending, share.Status)
+
+	// a pending invitation grants nothing
+	assert.Equal(t, http.StatusForbidden, testRequest(s, guestToken, http.MethodGet, "/user/1/task/1", nil).Code)
+
+	invitations := []*model.Share{}
+	json.NewDecoder(testRequest(s, guestToken, http.MethodGet, "/user/2/invitation?status=pending", nil).Body).Decode(&invitations)
+	assert.Len(t, invitations, 1)
+	assert.Equal(t, http.StatusNotFound, testRequest(s, ownerToken, http.MethodPost, fmt.Sprintf("/user/1/invitation/%d/accept", share.ID), nil).Code)
+
+	assert.Equal(t, http.StatusOK, testRequest(s, guestToken, http.MethodPost, fmt.Sprintf("/user/2/invitation/%d/accept", share.ID), nil).Code)
+
+	lists := []*model.List{}
+	json.NewDecoder(testRequest(s, guestToken, http.MethodGet, "/user/2/list", nil).Body).Decode(&lists)
+	assert.Len(t, lists, 2)
+	assert.Equal(t, model.RoleViewer, lists[1].Role)
+
+	page := &model.TaskPage{}
+	json.NewDecoder(testRequest(s, guestToken, http.MethodGet, "/user/2/task", nil).Body).Decode(page)
+	assert.Len(t, page.Tasks, 1)
+
+	assert.Equal(t, http.StatusOK, testRequest(s, guestToken, http.MethodGet, "/user/1/task/1", nil).Code)
+	assert.Equal(t, http.StatusForbidden, testRequest(s, guestToken, http.MethodPatch, "/user/1/task/1", map[string]interface{}{"title": "draft"}).Code)
+	assert.Equal(t, http.StatusForbidden, testRequest(s, guestToken, http.MethodGet, "/user/1/task", nil).Code)
+
+	// inviting again changes the role and keeps the share accepted
+	assert.Equal(t, http.StatusCreated, invite(model.RoleEditor).Code)
+	assert.Equal(t, http.StatusOK, testRequest(s, guestToken, http.MethodPatch, "/user/1/task/1", map[string]interface{}{"title": "draft"}).Code)
+	assert.Equal(t, http.StatusForbidden, testRequest(s, guestToken, http.MethodDelete, fmt.Sprintf("/user/1/list/%d", work.ID), nil).Code)
+	assert.Equal(t, http.StatusForbidden, testRequest(s, guestToken, http.MethodPost, "/user/1/share", map[string]interface{}{
+		"email":   owner.Email,
+		"role":    model.RoleViewer,
+		"list_id": work.ID,
+	}).Code)
+
+	assert.Equal(t, http.StatusOK, testRequest(s, guestToken, http.MethodPost, fmt.Sprintf("/user/2/invitation/%d/decline", share.ID), nil).Code)
+	assert.Equal(t, http.StatusForbidden, testRequest(s, guestToken, http.MethodGet, "/user/1/task/1", nil).Code)
+	assert.Equal(t, http.StatusConflict, testRequest(s, guestToken, http.MethodPost, fmt.Sprintf("/user/2/invitation/%d/accept", share.ID), nil).Code)
+
+	assert.Equal(t, http.StatusForbidden, testRequest(s, guestToken, http.MethodDelete, fmt.Sprintf("/user/1/share/%d", share.ID), nil).Code)
+	assert.Equal(t, http.StatusNoContent, testRequest(s, ownerToken, http.MethodDelete, fmt.Sprintf("/user/1/share/%d", share.ID), nil).Code)
+	assert.Equal(t, http.StatusNotFound, testRequest(s, ownerToken, http.MethodDelete, fmt.Sprintf("/user/1/share/%d", share.ID), nil).Code)
+}
+
+func TestServer_HandleWorkspaces(t *testing.T) {
+	cfg := config.InitConfig()
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	owner := model.TestUser(t)
+	s.store.User().Create(owner)
+	mate := model.TestUser(t)
+	mate.Email = "mate@example.org"
+	s.store.User().Create(mate)
+
+	ownerToken, _ := s.tokenService.GenerateAccessToken(owner.ID)
+	mateToken, _ := s.tokenService.GenerateAccessToken(mate.ID)
+
+	rec := testRequest(s, ownerToken, http.MethodPost, "/user/1/workspace", map[string]string{"name": " "})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = testRequest(s, ownerToken, http.MethodPost, "/user/1/workspace", map[string]string{"name": "Team"})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	ws := &model.Workspace{}
+	json.NewDecoder(rec.Body).Decode(ws)
+	wsURL := fmt.Sprintf("/user/1/workspace/%d", ws.ID)
+
+	// a token for a workspace the user is not a member of is refused
+	foreignToken, _ := s.tokenService.GenerateWorkspaceAccessToken(mate.ID, ws.ID)
+	assert.Equal(t, http.StatusUnauthorized, testRequest(s, foreignToken, http.MethodGet, "/user/2/task", nil).Code)
+	assert.Equal(t, http.StatusNotFound, testRequest(s, mateToken, http.MethodPost, fmt.Sprintf("/user/2/workspace/%d/switch", ws.ID), nil).Code)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, testRequest(s, ownerToken, http.MethodPost, wsURL+"/member", map[string]string{
+		"email": "nobody@example.org",
+		"role":  model.RoleViewer,
+	}).Code)
+	assert.Equal(t, http.StatusOK, testRequest(s, ownerToken, http.MethodPost, wsURL+"/member", map[string]string{
+		"email": mate.Email,
+		"role":  model.RoleViewer,
+	}).Code)
+
+	members := []*model.WorkspaceMember{}
+	json.NewDecoder(testRequest(s, mateToken, http.MethodGet, fmt.Sprintf("/user/2/workspace/%d/member", ws.ID), nil).Body).Decode(&members)
+	assert.Len(t, members, 2)
+
+	workspaces := []*model.Workspace{}
+	json.NewDecoder(testRequest(s, mateToken, http.MethodGet, "/user/2/workspace", nil).Body).Decode(&workspaces)
+	assert.Len(t, workspaces, 1)
+	assert.Equal(t, model.RoleViewer, workspaces[0].Role)
+
+	switchTo := func(token string, userID int, workspaceID int) string {
+		res := &struct {
+			AccessToken string `json:"access_token"`
+		}{}
+		rec := testRequest(s, token, http.MethodPost, fmt.Sprintf("/user/%d/workspace/%d/switch", userID, workspaceID), nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		json.NewDecoder(rec.Body).Decode(res)
+		return res.AccessToken
+	}
+
+	ownerTeamToken := switchTo(ownerToken, owner.ID, ws.ID)
+	mateTeamToken := switchTo(mateToken, mate.ID, ws.ID)
+
+	newTask := func(token, title string) *model.Task {
+		rec := testRequest(s, token, http.MethodPost, "/user/1/task", map[string]interface{}{
+			"title":    title,
+			"deadline": time.Now().Add(time.Hour).UTC().Format("2006-01-02 15:04:05"),
+		})
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		task := &model.Task{}
+		json.NewDecoder(rec.Body).Decode(task)
+		return task
+	}
+
+	personal := newTask(ownerToken, "groceries")
+	personalToken, _ := s.store.Todo().SyncToken(owner.ID)
+	team := newTask(ownerTeamToken, "roadmap")
+	assert.Equal(t, ws.ID, *team.WorkspaceID)
+
+	// the tasks of one workspace are not found in the other
+	page := &model.TaskPage{}
+	json.NewDecoder(testRequest(s, ownerToken, http.MethodGet, "/user/1/task", nil).Body).Decode(page)
+	assert.Len(t, page.Tasks, 1)
+	assert.Equal(t, http.StatusNotFound, testRequest(s, ownerToken, http.MethodGet, fmt.Sprintf("/user/1/task/%d", team.TaskID), nil).Code)
+	assert.Equal(t, http.StatusNotFound, testRequest(s, ownerTeamToken, http.MethodGet, fmt.Sprintf("/user/1/task/%d", personal.TaskID), nil).Code)
+
+	// the members have the role of their membership on the tasks of each other
+	assert.Equal(t, http.StatusOK, testRequest(s, mateTeamToken, http.MethodGet, "/user/1/task", nil).Code)
+	assert.Equal(t, http.StatusOK, testRequest(s, mateTeamToken, http.MethodGet, fmt.Sprintf("/user/1/task/%d", team.TaskID), nil).Code)
+	assert.Equal(t, http.StatusForbidden, testRequest(s, mateTeamToken, http.MethodPatch, fmt.Sprintf("/user/1/task/%d", team.TaskID), map[string]string{"title": "plan"}).Code)
+	assert.Equal(t, http.StatusForbidden, testRequest(s, mateTeamToken, http.MethodGet, fmt.Sprintf("/user/1/task/%d", personal.TaskID), nil).Code)
+	assert.Equal(t, http.StatusForbidden, testRequest(s, mateToken, http.MethodGet, fmt.Sprintf("/user/1/task/%d", team.TaskID), nil).Code)
+
+	assert.Equal(t, http.StatusForbidden, testRequest(s, mateToken, http.MethodPatch, fmt.Sprintf("/user/2/workspace/%d", ws.ID), map[string]string{"name": "Mine"}).Code)
+	assert.Equal(t, http.StatusOK, testRequest(s, ownerToken, http.MethodPatch, wsURL, map[string]string{"name": "Platform"}).Code)
+
+	// the last owner cannot leave, a member can
+	assert.Equal(t, http.StatusUnprocessableEntity, testRequest(s, ownerToken, http.MethodDelete, wsURL+"/member/1", nil).Code)
+	assert.Equal(t, http.StatusNoContent, testRequest(s, mateToken, http.MethodDelete, fmt.Sprintf("/user/2/workspace/%d/member/2", ws.ID), nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, testRequest(s, mateTeamToken, http.MethodGet, "/user/1/task", nil).Code)
+
+	assert.Equal(t, http.StatusNoContent, testRequest(s, ownerToken, http.MethodDelete, wsURL, nil).Code)
+	assert.Equal(t, http.StatusNotFound, testRequest(s, ownerToken, http.MethodGet, wsURL, nil).Code)
+	assert.Equal(t, http.StatusOK, testRequest(s, ownerToken, http.MethodGet, fmt.Sprintf("/user/1/task/%d", personal.TaskID), nil).Code)
+
+	// the tasks of the workspace, deleted with it, do not show up in the
+	// changes of the personal workspace
+	token, _ := s.store.Todo().SyncToken(owner.ID)
+	assert.Equal(t, personalToken, token)
+	changes, err := s.store.Todo().Changes(owner.ID, 0)
+	assert.NoError(t, err)
+	assert.Len(t, changes.Changed, 1)
+	assert.Empty(t, changes.Deleted)
+}
+
+func TestServer_HandleComments(t *testing.T) {
+	cfg := config.InitConfig()
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	owner := model.TestUser(t)
+	s.store.User().Create(owner)
+	guest := model.TestUser(t)
+	guest.Email = "guest@example.org"
+	s.store.User().Create(guest)
+
+	ownerToken, _ := s.tokenService.GenerateAccessToken(owner.ID)
+	guestToken, _ := s.tokenService.GenerateAccessToken(guest.ID)
+
+	assert.Equal(t, http.StatusCreated, testRequest(s, ownerToken, http.MethodPost, "/user/1/task", map[string]interface{}{
+		"title":    "report",
+		"deadline": time.Now().Add(time.Hour).UTC().Format("2006-01-02 15:04:05"),
+	}).Code)
+
+	comment := func(token, body string) *httptest.ResponseRecorder {
+		return testRequest(s, token, http.MethodPost, "/user/1/task/1/comment", map[string]string{"body": body})
+	}
+
+	assert.Equal(t, http.StatusUnprocessableEntity, comment(ownerToken, "  ").Code)
+	assert.Equal(t, http.StatusNotFound, testRequest(s, ownerToken, http.MethodPost, "/user/1/task/2/comment", map[string]string{"body": "hi"}).Code)
+	assert.Equal(t, http.StatusForbidden, comment(guestToken, "hi").Code)
+
+	rec := testRequest(s, ownerToken, http.MethodPost, "/user/1/share", map[string]interface{}{
+		"email":   guest.Email,
+		"role":    model.RoleEditor,
+		"task_id": 1,
+	})
+	share := &model.Share{}
+	json.NewDecoder(rec.Body).Decode(share)
+	assert.Equal(t, http.StatusOK, testRequest(s, guestToken, http.MethodPost, fmt.Sprintf("/user/2/invitation/%d/accept", share.ID), nil).Code)
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusCreated, comment(ownerToken, fmt.Sprintf("owner %d", i)).Code)
+	}
+
+	rec = comment(guestToken, "guest")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	guestComment := &model.Comment{}
+	json.NewDecoder(rec.Body).Decode(guestComment)
+	assert.Equal(t, guest.ID, guestComment.AuthorID)
+	assert.Nil(t, guestComment.EditedAt)
+
+	page := &model.CommentPage{}
+	rec = testRequest(s, guestToken, http.MethodGet, "/user/1/task/1/comment?limit=3", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	json.NewDecoder(rec.Body).Decode(page)
+	assert.Len(t, page.Comments, 3)
+	assert.Equal(t, "owner 0", page.Comments[0].Body)
+	assert.NotEmpty(t, page.NextCursor)
+
+	next := page.NextCursor
+	page = &model.CommentPage{}
+	json.NewDecoder(testRequest(s, guestToken, http.MethodGet, "/user/1/task/1/comment?limit=3&cursor="+next, nil).Body).Decode(page)
+	assert.Len(t, page.Comments, 1)
+	assert.Equal(t, "guest", page.Comments[0].Body)
+	assert.Empty(t, page.NextCursor)
+	assert.Equal(t, http.StatusBadRequest, testRequest(s, guestToken, http.MethodGet, "/user/1/task/1/comment?cursor=x", nil).Code)
+
+	// only the author edits a comment
+	guestURL := fmt.Sprintf("/user/1/task/1/comment/%d", guestComment.ID)
+	assert.Equal(t, http.StatusForbidden, testRequest(s, ownerToken, http.MethodPatch, guestURL, map[string]string{"body": "edited"}).Code)
+	rec = testRequest(s, guestToken, http.MethodPatch, guestURL, map[string]string{"body": "edited"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	guestComment = &model.Comment{}
+	json.NewDecoder(rec.Body).Decode(guestComment)
+	assert.Equal(t, "edited", guestComment.Body)
+	assert.NotNil(t, guestComment.EditedAt)
+
+	// the owner of the task moderates the comments, the editors do not
+	assert.Equal(t, http.StatusForbidden, testRequest(s, guestToken, http.MethodDelete, "/user/1/task/1/comment/1", nil).Code)
+	assert.Equal(t, http.StatusNoContent, testRequest(s, ownerToken, http.MethodDelete, guestURL, nil).Code)
+	assert.Equal(t, http.StatusNotFound, testRequest(s, ownerToken, http.MethodDelete, guestURL, nil).Code)
+
+	assert.Equal(t, http.StatusNoContent, testRequest(s, ownerToken, http.MethodDelete, "/user/1/task/1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, testRequest(s, ownerToken, http.MethodGet, "/user/1/task/1/comment", nil).Code)
+}
+
+func TestServer_HandleAttachments(t *testing.T) {
+	cfg := config.InitConfig()
+	cfg.Attachments.Dir = t.TempDir()
+	cfg.Attachments.MaxSize = 1024
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	u := model.TestUser(t)
+	s.store.User().Create(u)
+
+	token, _ := s.tokenService.GenerateAccessToken(u.ID)
+
+	assert.Equal(t, http.StatusCreated, testRequest(s, token, http.MethodPost, "/user/1/task", map[string]interface{}{
+		"title":    "release",
+		"deadline": time.Now().Add(time.Hour).UTC().Format("2006-01-02 15:04:05"),
+	}).Code)
+
+	upload := func(name string, content []byte) *httptest.ResponseRecorder {
+		b := &bytes.Buffer{}
+		mw := multipart.NewWriter(b)
+		fw, _ := mw.CreateFormFile("file", name)
+		fw.Write(content)
+		mw.Close()
+
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/user/1/task/1/attachment", b)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 100)...)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, upload("tool.exe", []byte("MZ\x90\x00\x03\x00\x00\x00")).Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, upload("big.png", append(png, make([]byte, 1024)...)).Code)
+	assert.Equal(t, http.StatusBadRequest, testRequest(s, token, http.MethodPost, "/user/1/task/1/attachment", nil).Code)
+
+	rec := upload(`C:\shots\screen.png`, png)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	a := &model.Attachment{}
+	json.NewDecoder(rec.Body).Decode(a)
+	assert.Equal(t, "screen.png", a.Name)
+	assert.Equal(t, "image/png", a.ContentType)
+	assert.Equal(t, int64(len(png)), a.Size)
+
+	attachments := []*model.Attachment{}
+	json.NewDecoder(testRequest(s, token, http.MethodGet, "/user/1/task/1/attachment", nil).Body).Decode(&attachments)
+	assert.Len(t, attachments, 1)
+
+	rec = testRequest(s, token, http.MethodGet, fmt.Sprintf("/user/1/task/1/attachment/%d", a.ID), nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+	assert.Equal(t, png, rec.Body.Bytes())
+
+	// the signed URL is fetched without a bearer token
+	signed, err := url.Parse(a.URL)
+	assert.NoError(t, err)
+	fetch := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, target, nil)
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec = fetch(signed.RequestURI())
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, png, rec.Body.Bytes())
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), "screen.png")
+
+	tampered := signed.Query()
+	tampered.Set("expires", strconv.FormatInt(time.Now().Add(time.Hour*24).Unix(), 10))
+	assert.Equal(t, http.StatusForbidden, fetch(signed.Path+"?"+tampered.Encode()).Code)
+
+	// a server whose links expire right away hands out expired links
+	expiring := *cfg
+	expiring.Attachments.URLTTL = -time.Minute
+	s2 := newServer(s.store, logger.InitLogger(cfg.Env), &expiring)
+	rec = testRequest(s2, token, http.MethodGet, "/user/1/task/1/attachment", nil)
+	json.NewDecoder(rec.Body).Decode(&attachments)
+	expired, _ := url.Parse(attachments[0].URL)
+	assert.Equal(t, http.StatusForbidden, fetch(expired.RequestURI()).Code)
+
+	assert.Equal(t, http.StatusNoContent, testRequest(s, token, http.MethodDelete, fmt.Sprintf("/user/1/task/1/attachment/%d", a.ID), nil).Code)
+	assert.Equal(t, http.StatusNotFound, fetch(signed.RequestURI()).Code)
+	assert.Equal(t, http.StatusNotFound, testRequest(s, token, http.MethodGet, fmt.Sprintf("/user/1/task/1/attachment/%d", a.ID), nil).Code)
+}
+
+func TestServer_HandleTaskDependencies(t *testing.T) {
+	cfg := config.InitConfig()
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	u := model.TestUser(t)
+	s.store.User().Create(u)
+	token, _ := s.tokenService.GenerateAccessToken(u.ID)
+
+	for i, title := range []string{"deploy", "build", "test"} {
+		assert.Equal(t, http.StatusCreated, testRequest(s, token, http.MethodPost, "/user/1/task", map[string]interface{}{
+			"title":    title,
+			"deadline": time.Now().Add(time.Duration(i+1) * time.Hour).UTC().Format("2006-01-02 15:04:05"),
+		}).Code)
+	}
+
+	depend := func(taskID, blockedBy int) int {
+		return testRequest(s, token, http.MethodPost, fmt.Sprintf("/user/1/task/%d/dependency", taskID), map[string]int{"blocked_by": blockedBy}).Code
+	}
+
+	// build, then test, then deploy
+	assert.Equal(t, http.StatusCreated, depend(1, 3))
+	assert.Equal(t, http.StatusCreated, depend(3, 2))
+	assert.Equal(t, http.StatusUnprocessableEntity, depend(2, 1))
+	assert.Equal(t, http.StatusUnprocessableEntity, depend(2, 2))
+	assert.Equal(t, http.StatusNotFound, depend(2, 4))
+
+	task := &model.Task{}
+	json.NewDecoder(testRequest(s, token, http.MethodGet, "/user/1/task/3", nil).Body).Decode(task)
+	assert.Equal(t, []int{2}, task.BlockedBy)
+	assert.Equal(t, []int{1}, task.Blocking)
+
+	tasks := []*model.Task{}
+	rec := testRequest(s, token, http.MethodGet, "/user/1/task/ordered", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	json.NewDecoder(rec.Body).Decode(&tasks)
+	ids := []int{}
+	for _, task := range tasks {
+		ids = append(ids, task.TaskID)
+	}
+	assert.Equal(t, []int{2, 3, 1}, ids)
+
+	complete := func(taskID int, force bool) int {
+		return testRequest(s, token, http.MethodPatch, fmt.Sprintf("/user/1/task/%d", taskID), map[string]bool{"complete": true, "force": force}).Code
+	}
+
+	assert.Equal(t, http.StatusConflict, complete(3, false))
+	assert.Equal(t, http.StatusOK, complete(2, false))
+	assert.Equal(t, http.StatusOK, complete(3, false))
+
+	assert.Equal(t, http.StatusNoContent, testRequest(s, token, http.MethodDelete, "/user/1/task/1/dependency/3", nil).Code)
+	assert.Equal(t, http.StatusNotFound, testRequest(s, token, http.MethodDelete, "/user/1/task/1/dependency/3", nil).Code)
+
+	assert.Equal(t, http.StatusCreated, depend(1, 2))
+	assert.Equal(t, http.StatusCreated, testRequest(s, token, http.MethodPost, "/user/1/task", map[string]interface{}{
+		"title":    "review",
+		"deadline": time.Now().Add(time.Hour).UTC().Format("2006-01-02 15:04:05"),
+	}).Code)
+	assert.Equal(t, http.StatusCreated, depend(1, 4))
+	assert.Equal(t, http.StatusConflict, complete(1, false))
+	assert.Equal(t, http.StatusOK, complete(1, true))
+
+	// a blocked subtask is not completed along with its parent
+	parentID := 5
+	for _, body := range []map[string]interface{}{
+		{"title": "release"},
+		{"title": "changelog", "parent_task_id": parentID},
+		{"title": "sign-off"},
+	} {
+		body["deadline"] = time.Now().Add(time.Hour).UTC().Format("2006-01-02 15:04:05")
+		assert.Equal(t, http.StatusCreated, testRequest(s, token, http.MethodPost, "/user/1/task", body).Code)
+	}
+	assert.Equal(t, http.StatusCreated, depend(6, 7))
+
+	completeTree := func(force bool) int {
+		return testRequest(s, token, http.MethodPatch, "/user/1/task/5", map[string]bool{"complete": true, "complete_subtasks": true, "force": force}).Code
+	}
+
+	assert.Equal(t, http.StatusConflict, completeTree(false))
+	task = &model.Task{}
+	json.NewDecoder(testRequest(s, token, http.MethodGet, "/user/1/task/6", nil).Body).Decode(task)
+	assert.False(t, *task.Complete)
+
+	assert.Equal(t, http.StatusOK, completeTree(true))
+	task = &model.Task{}
+	json.NewDecoder(testRequest(s, token, http.MethodGet, "/user/1/task/6", nil).Body).Decode(task)
+	assert.True(t, *task.Complete)
+
+	// replacing a task does not complete it past an open blocker either
+	deadline := time.Now().Add(time.Hour).UTC().Format("2006-01-02 15:04:05")
+	assert.Equal(t, http.StatusCreated, testRequest(s, token, http.MethodPost, "/user/1/task", map[string]interface{}{
+		"title":    "publish",
+		"deadline": deadline,
+	}).Code)
+	assert.Equal(t, http.StatusCreated, depend(8, 7))
+	assert.Equal(t, http.StatusConflict, testRequest(s, token, http.MethodPut, "/user/1/task/8", map[string]interface{}{
+		"title":    "publish",
+		"deadline": deadline,
+		"complete": true,
+	}).Code)
+	task = &model.Task{}
+	json.NewDecoder(testRequest(s, token, http.MethodGet, "/user/1/task/8", nil).Body).Decode(task)
+	assert.False(t, *task.Complete)
+}
+
+func TestServer_HandleTimeTracking(t *testing.T) {
+	cfg := config.InitConfig()
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	u := model.TestUser(t)
+	s.store.User().Create(u)
+	token, _ := s.tokenService.GenerateAccessToken(u.ID)
+
+	for _, tags := range [][]string{{"billing"}, {}} {
+		assert.Equal(t, http.StatusCreated, testRequest(s, token, http.MethodPost, "/user/1/task", map[string]interface{}{
+			"title":    "work",
+			"deadline": time.Now().Add(time.Hour).UTC().Format("2006-01-02 15:04:05"),
+			"tags":     tags,
+		}).Code)
+	}
+
+	// a single timer runs at a time
+	rec := testRequest(s, token, http.MethodPost, "/user/1/task/1/timer/start", nil)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	timer := &model.TimeEntry{}
+	json.NewDecoder(rec.Body).Decode(timer)
+	assert.True(t, timer.Running())
+	assert.Equal(t, http.StatusConflict, testRequest(s, token, http.MethodPost, "/user/1/task/2/timer/start", nil).Code)
+	assert.Equal(t, http.StatusNotFound, testRequest(s, token, http.MethodPost, "/user/1/task/2/timer/stop", nil).Code)
+
+	rec = testRequest(s, token, http.MethodPost, "/user/1/task/1/timer/stop", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	timer = &model.TimeEntry{}
+	json.NewDecoder(rec.Body).Decode(timer)
+	assert.False(t, timer.Running())
+	assert.Equal(t, http.StatusCreated, testRequest(s, token, http.MethodPost, "/user/1/task/2/timer/start", nil).Code)
+	assert.Equal(t, http.StatusOK, testRequest(s, token, http.MethodPost, "/user/1/task/2/timer/stop", nil).Code)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, testRequest(s, token, http.MethodPost, "/user/1/task/2/time_entry", map[string]string{
+		"started_at": "2025-08-24 09:00:00",
+	}).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, testRequest(s, token, http.MethodPost, "/user/1/task/2/time_entry", map[string]string{
+		"started_at": "2025-08-24 09:00:00",
+		"stopped_at": "2025-08-24 08:00:00",
+	}).Code)
+
+	rec = testRequest(s, token, http.MethodPost, "/user/1/task/2/time_entry", map[string]string{
+		"started_at": "2025-08-24 09:00:00",
+		"stopped_at": "2025-08-24 10:30:00",
+		"note":       "review",
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	entry := &model.TimeEntry{}
+	json.NewDecoder(rec.Body).Decode(entry)
+	assert.Equal(t, int64(90*60), entry.Duration)
+
+	entryURL := fmt.Sprintf("/user/1/task/2/time_entry/%d", entry.ID)
+	rec = testRequest(s, token, http.MethodPatch, entryURL, map[string]string{"stopped_at": "2025-08-24 11:00:00"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	json.NewDecoder(rec.Body).Decode(entry)
+	assert.Equal(t, int64(2*60*60), entry.Duration)
+	assert.Equal(t, "review", entry.Note)
+
+	entries := []*model.TimeEntry{}
+	json.NewDecoder(testRequest(s, token, http.MethodGet, "/user/1/task/2/time_entry", nil).Body).Decode(&entries)
+	assert.Len(t, entries, 2)
+
+	task := &model.Task{}
+	json.NewDecoder(testRequest(s, token, http.MethodGet, "/user/1/task/2", nil).Body).Decode(task)
+	assert.GreaterOrEqual(t, task.TimeSpent, int64(2*60*60))
+
+	report := &model.TimeReport{}
+	rec = testRequest(s, token, http.MethodGet, "/user/1/time_report?from=2025-08-24T00:00:00Z&to=2025-08-25T00:00:00Z&group_by=tag", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	json.NewDecoder(rec.Body).Decode(report)
+	assert.Len(t, report.Rows, 1)
+	assert.Equal(t, "", report.Rows[0].Group)
+	assert.Equal(t, int64(2*60*60), report.Duration)
+
+	rec = testRequest(s, token, http.MethodGet, "/user/1/time_report?from=2025-08-24T00:00:00Z&to=2025-08-25T00:00:00Z&format=csv", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "day,duration,hours\n2025-08-24,7200,2.00\n", rec.Body.String())
+
+	// the rows by list carry the ID of their list, lists may share a name
+	rec = testRequest(s, token, http.MethodPost, "/user/1/list", map[string]string{"name": "Work"})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	list := &model.List{}
+	json.NewDecoder(rec.Body).Decode(list)
+	assert.Equal(t, http.StatusOK, testRequest(s, token, http.MethodPatch, "/user/1/task/2", map[string]interface{}{"list_id": list.ID}).Code)
+
+	report = &model.TimeReport{}
+	json.NewDecoder(testRequest(s, token, http.MethodGet, "/user/1/time_report?from=2025-08-24T00:00:00Z&to=2025-08-25T00:00:00Z&group_by=list", nil).Body).Decode(report)
+	assert.Len(t, report.Rows, 1)
+	assert.Equal(t, "Work", report.Rows[0].Group)
+	assert.Equal(t, &list.ID, report.Rows[0].ListID)
+
+	rec = testRequest(s, token, http.MethodGet, "/user/1/time_report?from=2025-08-24T00:00:00Z&to=2025-08-25T00:00:00Z&group_by=list&format=csv", nil)
+	assert.Equal(t, fmt.Sprintf("list,list_id,duration,hours\nWork,%d,7200,2.00\n", list.ID), rec.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, testRequest(s, token, http.MethodGet, "/user/1/time_report?from=2025-08-24T00:00:00Z", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, testRequest(s, token, http.MethodGet, "/user/1/time_report?from=2025-08-24T00:00:00Z&to=2025-08-25T00:00:00Z&format=xml", nil).Code)
+
+	assert.Equal(t, http.StatusNoContent, testRequest(s, token, http.MethodDelete, entryURL, nil).Code)
+	assert.Equal(t, http.StatusNotFound, testRequest(s, token, http.MethodDelete, entryURL, nil).Code)
+}
+
+func TestServer_HandleStats(t *testing.T) {
+	cfg := config.InitConfig()
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	u := model.TestUser(t)
+	s.store.User().Create(u)
+	token, _ := s.tokenService.GenerateAccessToken(u.ID)
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusCreated, testRequest(s, token, http.MethodPost, "/user/1/task", map[string]interface{}{
+			"title":    "work",
+			"deadline": time.Now().Add(time.Hour).UTC().Format("2006-01-02 15:04:05"),
+		}).Code)
+	}
+
+	// the deadline of a task created through the API is in the future
+	title, past := "late", time.Now().Add(-time.Hour).UTC()
+	assert.NoError(t, s.store.Todo().Create(&model.Task{UserID: u.ID, Title: &title, Deadline: &past}))
+
+	complete := func(taskID int, complete bool) {
+		assert.Equal(t, http.StatusOK, testRequest(s, token, http.MethodPatch, fmt.Sprintf("/user/1/task/%d", taskID), map[string]bool{"complete": complete}).Code)
+	}
+
+	// the completion time follows the complete flag
+	complete(1, true)
+	task := &model.Task{}
+	json.NewDecoder(testRequest(s, token, http.MethodGet, "/user/1/task/1", nil).Body).Decode(task)
+	assert.NotNil(t, task.CompletedAt)
+
+	complete(1, false)
+	task = &model.Task{}
+	json.NewDecoder(testRequest(s, token, http.MethodGet, "/user/1/task/1", nil).Body).Decode(task)
+	assert.Nil(t, task.CompletedAt)
+
+	complete(1, true)
+	complete(2, true)
+	assert.Equal(t, http.StatusNoContent, testRequest(s, token, http.MethodDelete, "/user/1/task/3", nil).Code)
+
+	rec := testRequest(s, token, http.MethodGet, "/user/1/stats?windows=1&windows=7", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	stats := &model.TaskStats{}
+	json.NewDecoder(rec.Body).Decode(stats)
+	assert.Equal(t, 3, stats.Total)
+	assert.Equal(t, 1, stats.Open)
+	assert.Equal(t, 2, stats.Complete)
+	assert.Equal(t, 1, stats.Overdue)
+	assert.Equal(t, 1, stats.Trashed)
+	assert.NotNil(t, stats.AverageLeadTime)
+	assert.Len(t, stats.Windows, 2)
+	assert.Equal(t, 3, stats.Windows[0].Created)
+	assert.Equal(t, 2, stats.Windows[0].Completed)
+	assert.InDelta(t, 2.0/3, stats.Windows[0].CompletionRate, 0.001)
+	assert.Equal(t, model.TaskStatsStreak{Current: 1, Longest: 1}, stats.Streak)
+
+	assert.Equal(t, http.StatusBadRequest, testRequest(s, token, http.MethodGet, "/user/1/stats?windows=0", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, testRequest(s, token, http.MethodGet, "/user/1/stats?windows=7&windows=7", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, testRequest(s, token, http.MethodGet, "/user/1/stats?from=today", nil).Code)
+}
+
+func TestServer_HandleWorkflow(t *testing.T) {
+	cfg := config.InitConfig()
+	s := newServer(teststore.New(), logger.InitLogger(cfg.Env), cfg)
+	u := model.TestUser(t)
+	s.store.User().Create(u)
+	token, _ := s.tokenService.GenerateAccessToken(u.ID)
+
+	rec := testRequest(s, token, http.MethodPost, "/user/1/list", map[string]string{"name": "Board"})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	list := &model.List{}
+	json.NewDecoder(rec.Body).Decode(list)
+	listURL := fmt.Sprintf("/user/1/list/%d", list.ID)
+
+	// a list without a workflow uses the default one
+	workflow := &model.Workflow{}
+	json.NewDecoder(testRequest(s, token, http.MethodGet, listURL+"/workflow", nil).Body).Decode(workflow)
+	assert.Equal(t, model.DefaultWorkflow(), workflow)
+
+	rec = testRequest(s, token, http.MethodPost, "/user/1/task", map[string]interface{}{
+		"title":    "card",
+		"list_id":  list.ID,
+		"deadline": time.Now().Add(time.Hour).UTC().Format("2006-01-02 15:04:05"),
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	task := &model.Task{}
+	json.NewDecoder(rec.Body).Decode(task)
+	assert.Equal(t, model.StatusTodo, *task.Status)
+	taskURL := fmt.Sprintf("/user/1/task/%d", task.TaskID)
+
+	kanban := map[string]interface{}{
+		"statuses": []map[string]interface{}{
+			{"name": "todo"},
+			{"name": "in progress"},
+			{"name": "review"},
+			{"name": "done", "done": true},
+		},
+		"transitions": map[string][]string{
+			"todo":        {"in progress"},
+			"in progress": {"todo", "review"},
+		},
+	}
+	assert.Equal(t, http.StatusUnprocessableEntity, testRequest(s, token, http.MethodPut, listURL+"/workflow", map[string]interface{}{
+		"statuses": []map[string]interface{}{{"name": "todo"}},
+	}).Code)
+	assert.Equal(t, http.StatusOK, testRequest(s, token, http.MethodPut, listURL+"/workflow", kanban).Code)
+
+	get := func() *model.Task {
+		task := &model.Task{}
+		json.NewDecoder(testRequest(s, token, http.MethodGet, taskURL, nil).Body).Decode(task)
+		return task
+	}
+	move := func(status string) int {
+		return testRequest(s, token, http.MethodPatch, taskURL, map[string]string{"status": status}).Code
+	}
+
+	// the complete flag follows the status
+	assert.Equal(t, http.StatusOK, move("in progress"))
+	assert.Equal(t, "in progress", *get().Status)
+	assert.Equal(t, http.StatusUnprocessableEntity, move("done"))
+	assert.Equal(t, http.StatusUnprocessableEntity, move("blocked"))
+	assert.Equal(t, http.StatusOK, move("review"))
+	assert.Equal(t, http.StatusOK, move("done"))
+	assert.True(t, *get().Complete)
+	assert.NotNil(t, get().CompletedAt)
+
+	// reopening takes the first open status
+	assert.Equal(t, http.StatusOK, testRequest(s, token, http.MethodPatch, taskURL, map[string]bool{"complete": false}).Code)
+	assert.Equal(t, "todo", *get().Status)
+	assert.Equal(t, http.StatusUnprocessableEntity, testRequest(s, token, http.MethodPatch, taskURL, map[string]interface{}{
+		"status":   "todo",
+		"complete": true,
+	}).Code)
+
+	assert.Equal(t, http.StatusOK, move("in progress"))
+
+	// a CalDAV client follows the workflow as well
+	rec = testRequest(s, token, http.MethodPost, "/user/1/app_password", map[string]string{"name": "phone"})
+	created := map[string]interface{}{}
+	json.NewDecoder(rec.Body).Decode(&created)
+	uid := fmt.Sprintf("task-%d@taskmanager-api", task.TaskID)
+	due := time.Now().Add(48 * time.Hour).UTC().Format("20060102T150405Z")
+	rec = httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPut, "/dav/calendars/1/tasks/"+uid+".ics", strings.NewReader(
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VTODO\r\nUID:"+uid+"\r\nSUMMARY:card\r\nDUE:"+due+
+			"\r\nSTATUS:COMPLETED\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"))
+	req.SetBasicAuth(u.Email, created["password"].(string))
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "in progress", *get().Status)
+
+	rec = testRequest(s, token, http.MethodGet, listURL+"/board", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	board := &model.Board{}
+	json.NewDecoder(rec.Body).Decode(board)
+	assert.Len(t, board.Columns, 4)
+	assert.Equal(t, "in progress", board.Columns[1].Status)
+	if assert.Len(t, board.Columns[1].Tasks, 1) {
+		assert.Equal(t, task.TaskID, board.Columns[1].Tasks[0].TaskID)
+	}
+
+	// the tasks in a removed status fall back to the default one
+	assert.Equal(t, http.StatusOK, testRequest(s, token, http.MethodDelete, listURL+"/workflow", nil).Code)
+	assert.Equal(t, model.StatusTodo, *get().Status)
+	assert.False(t, *get().Complete)
+
+	assert.Equal(t, http.StatusNotFound, testRequest(s, token, http.MethodGet, listURL+"/columns", nil).Code)
+}
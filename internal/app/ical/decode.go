@@ -0,0 +1,217 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+)
+
+const (
+	dateLayout     = "20060102"
+	floatingLayout = "20060102T150405"
+)
+
+var textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+var (
+	errNoTodo     = errors.New("the calendar has no VTODO")
+	errManyTodos  = errors.New("the calendar has more than one VTODO")
+	errNoUID      = errors.New("the VTODO has no UID")
+	errInvalidDue = errors.New("the VTODO has an invalid DUE")
+)
+
+// Todo is a VTODO read from a client. Task holds the title, description,
+// deadline, completion and tags of the VTODO, the tags only when the VTODO
+// has CATEGORIES.
+type Todo struct {
+	UID           string
+	Task          *model.Task
+	HasCategories bool
+}
+
+// Decode reads the only VTODO of a calendar. The components inside the
+// VTODO, like its alarms, and the properties that have no field in a task
+// are skipped.
+func Decode(r io.Reader) (*Todo, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var todo *Todo
+	depth := 0
+	completed := false
+
+	for _, l := range lines {
+		name, params, value := parseLine(l)
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VTODO") && depth == 0:
+			if todo != nil {
+				return nil, errManyTodos
+			}
+			todo = &Todo{Task: &model.Task{}}
+			depth = 1
+			continue
+		case name == "BEGIN" && depth > 0:
+			depth++
+			continue
+		case name == "END" && depth > 0:
+			depth--
+			continue
+		}
+
+		// only the properties of the VTODO itself are read
+		if depth != 1 {
+			continue
+		}
+
+		switch name {
+		case "UID":
+			todo.UID = unescape(value)
+		case "SUMMARY":
+			title := unescape(value)
+			todo.Task.Title = &title
+		case "DESCRIPTION":
+			description := unescape(value)
+			todo.Task.Description = &description
+		case "DUE":
+			due, err := parseTime(params, value)
+			if err != nil {
+				return nil, errInvalidDue
+			}
+			todo.Task.Deadline = &due
+		case "STATUS":
+			completed = completed || strings.EqualFold(value, "COMPLETED")
+		case "COMPLETED":
+			completed = true
+		case "CATEGORIES":
+			todo.HasCategories = true
+			for _, tag := range splitList(value) {
+				if tag = strings.TrimSpace(unescape(tag)); tag != "" {
+					todo.Task.Tags = append(todo.Task.Tags, tag)
+				}
+			}
+		}
+	}
+
+	if todo == nil {
+		return nil, errNoTodo
+	}
+
+	if todo.UID == "" {
+		return nil, errNoUID
+	}
+
+	todo.Task.Complete = &completed
+	todo.Task.Tags = model.NormalizeTags(todo.Task.Tags)
+
+	return todo, nil
+}
+
+// unfold returns the content lines, a line that starts with a space or a
+// tab continues the one before it.
+func unfold(r io.Reader) ([]string, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	lines := []string{}
+	for sc.Scan() {
+		l := strings.TrimSuffix(sc.Text(), "\r")
+
+		if (strings.HasPrefix(l, " ") || strings.HasPrefix(l, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += l[1:]
+			continue
+		}
+
+		if l != "" {
+			lines = append(lines, l)
+		}
+	}
+
+	return lines, sc.Err()
+}
+
+// parseLine splits a content line into its upper case name, its parameters
+// and its value. The colon that ends the name and parameters may not be in
+// a quoted parameter value.
+func parseLine(l string) (string, map[string]string, string) {
+	quoted := false
+	end := -1
+
+	for i := 0; i < len(l) && end < 0; i++ {
+		switch l[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				end = i
+			}
+		}
+	}
+
+	if end < 0 {
+		return strings.ToUpper(l), nil, ""
+	}
+
+	parts := strings.Split(l[:end], ";")
+	params := map[string]string{}
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(p, "=")
+		params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+
+	return strings.ToUpper(parts[0]), params, l[end+1:]
+}
+
+// parseTime reads a DATE or DATE-TIME value. A date is the start of the day
+// in UTC, as is a floating time or a time in a zone that is not known.
+func parseTime(params map[string]string, value string) (time.Time, error) {
+	if strings.EqualFold(params["VALUE"], "DATE") || len(value) == len(dateLayout) {
+		return time.Parse(dateLayout, value)
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(utcLayout, value)
+	}
+
+	loc := time.UTC
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil {
+			loc = l
+		}
+	}
+
+	t, err := time.ParseInLocation(floatingLayout, value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return t.UTC(), nil
+}
+
+// splitList splits a list value at the commas that are not escaped.
+func splitList(value string) []string {
+	items := []string{}
+	start := 0
+
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			items = append(items, value[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(items, value[start:])
+}
+
+func unescape(s string) string {
+	return textUnescaper.Replace(s)
+}
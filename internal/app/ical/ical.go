@@ -0,0 +1,181 @@
+// Package ical writes tasks as the VTODO components of an RFC 5545
+// calendar, the format of the calendar feed and of CalDAV, and reads the
+// VTODOs that CalDAV clients send.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+)
+
+const (
+	prodID = "-//taskmanager-api//tasks//EN"
+
+	// uidDomain makes the UIDs of the tasks globally unique.
+	uidDomain = "taskmanager-api"
+
+	// lineLength is the most octets of a line before it is folded.
+	lineLength = 75
+
+	utcLayout = "20060102T150405Z"
+)
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// Encoder writes a calendar, Close completes it.
+type Encoder struct {
+	w      *bufio.Writer
+	name   string
+	now    time.Time
+	header bool
+}
+
+// NewEncoder returns an encoder of a calendar with the given display name.
+func NewEncoder(w io.Writer, name string) *Encoder {
+	return &Encoder{
+		w:    bufio.NewWriter(w),
+		name: name,
+		now:  time.Now().UTC(),
+	}
+}
+
+// UID returns the UID of the VTODO of the task, it stays the same for the
+// life of the task.
+func UID(taskID int) string {
+	return fmt.Sprintf("task-%d@%s", taskID, uidDomain)
+}
+
+// ParseUID returns the ID of the task of a UID given by UID.
+func ParseUID(uid string) (int, bool) {
+	s, ok := strings.CutSuffix(uid, "@"+uidDomain)
+	if !ok {
+		return 0, false
+	}
+
+	s, ok = strings.CutPrefix(s, "task-")
+	if !ok {
+		return 0, false
+	}
+
+	id, err := strconv.Atoi(s)
+	if err != nil || id <= 0 || strconv.Itoa(id) != s {
+		return 0, false
+	}
+
+	return id, true
+}
+
+// TaskUID returns the UID of the VTODO of the task, the one of its client
+// for a task created over CalDAV.
+func TaskUID(t *model.Task) string {
+	if t.ICalUID != nil {
+		return *t.ICalUID
+	}
+
+	return UID(t.TaskID)
+}
+
+func (e *Encoder) Encode(t *model.Task) error {
+	e.writeHeader()
+
+	e.line("BEGIN", "VTODO")
+	e.line("UID", escape(TaskUID(t)))
+	e.line("DTSTAMP", e.now.Format(utcLayout))
+	e.line("CREATED", t.CreatedAt.UTC().Format(utcLayout))
+	e.line("SEQUENCE", fmt.Sprint(max(t.Version-1, 0)))
+
+	if t.Title != nil {
+		e.line("SUMMARY", escape(*t.Title))
+	}
+
+	if t.Description != nil && *t.Description != "" {
+		e.line("DESCRIPTION", escape(*t.Description))
+	}
+
+	if t.Deadline != nil {
+		e.line("DUE", t.Deadline.UTC().Format(utcLayout))
+	}
+
+	if t.Complete != nil && *t.Complete {
+		e.line("STATUS", "COMPLETED")
+		e.line("PERCENT-COMPLETE", "100")
+	} else {
+		e.line("STATUS", "NEEDS-ACTION")
+	}
+
+	if len(t.Tags) > 0 {
+		tags := make([]string, len(t.Tags))
+		for i, tag := range t.Tags {
+			tags[i] = escape(tag)
+		}
+		e.line("CATEGORIES", strings.Join(tags, ","))
+	}
+
+	if t.ParentTaskID != nil {
+		parent := UID(*t.ParentTaskID)
+		if t.ParentICalUID != nil {
+			parent = *t.ParentICalUID
+		}
+		e.line("RELATED-TO", escape(parent))
+	}
+
+	e.line("END", "VTODO")
+
+	return nil
+}
+
+func (e *Encoder) Close() error {
+	e.writeHeader()
+	e.line("END", "VCALENDAR")
+
+	return e.w.Flush()
+}
+
+func (e *Encoder) writeHeader() {
+	if e.header {
+		return
+	}
+
+	e.header = true
+
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", prodID)
+	e.line("CALSCALE", "GREGORIAN")
+	e.line("X-WR-CALNAME", escape(e.name))
+}
+
+// line writes a content line, folded after lineLength octets without
+// splitting a character. The errors are reported by the flush in Close.
+func (e *Encoder) line(name string, value string) {
+	s := name + ":" + value
+	limit := lineLength
+
+	for len(s) > limit {
+		n := limit
+		for !utf8.RuneStart(s[n]) {
+			n--
+		}
+
+		e.w.WriteString(s[:n])
+		e.w.WriteString("\r\n ")
+		s = s[n:]
+
+		// the space that starts a continuation line takes an octet
+		limit = lineLength - 1
+	}
+
+	e.w.WriteString(s)
+	e.w.WriteString("\r\n")
+}
+
+func escape(s string) string {
+	return textEscaper.Replace(s)
+}
@@ -0,0 +1,97 @@
+package ical_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/ical"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+)
+
+func TestEncoder(t *testing.T) {
+	task := model.TestTask(t, 1)
+	task.TaskID = 7
+	task.Version = 3
+	title := "buy milk, eggs; bread"
+	task.Title = &title
+	description := strings.Repeat("ж", 60) + "\nsecond line"
+	task.Description = &description
+	complete := true
+	task.Complete = &complete
+	task.Tags = []string{"home", "x,y"}
+	parentID := 2
+	task.ParentTaskID = &parentID
+
+	b := &bytes.Buffer{}
+	enc := ical.NewEncoder(b, "Tasks")
+	assert.NoError(t, enc.Encode(task))
+	assert.NoError(t, enc.Close())
+
+	out := b.String()
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VTODO\r\nEND:VCALENDAR\r\n"))
+
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+	}
+
+	unfolded := strings.ReplaceAll(out, "\r\n ", "")
+	assert.Contains(t, unfolded, "UID:task-7@taskmanager-api\r\n")
+	assert.Contains(t, unfolded, "SEQUENCE:2\r\n")
+	assert.Contains(t, unfolded, `SUMMARY:buy milk\, eggs\; bread`+"\r\n")
+	assert.Contains(t, unfolded, "DESCRIPTION:"+strings.Repeat("ж", 60)+`\nsecond line`+"\r\n")
+	assert.Contains(t, unfolded, "DUE:"+task.Deadline.UTC().Format("20060102T150405Z")+"\r\n")
+	assert.Contains(t, unfolded, "STATUS:COMPLETED\r\n")
+	assert.Contains(t, unfolded, `CATEGORIES:home,x\,y`+"\r\n")
+	assert.Contains(t, unfolded, "RELATED-TO:task-2@taskmanager-api\r\n")
+}
+
+func TestEncoder_Empty(t *testing.T) {
+	b := &bytes.Buffer{}
+	assert.NoError(t, ical.NewEncoder(b, "Tasks").Close())
+	assert.NotContains(t, b.String(), "VTODO")
+	assert.True(t, strings.HasSuffix(b.String(), "END:VCALENDAR\r\n"))
+}
+
+func TestDecode(t *testing.T) {
+	in := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VTIMEZONE\r\nTZID:Europe/Berlin\r\nEND:VTIMEZONE\r\n" +
+		"BEGIN:VTODO\r\nUID:abc-1\r\nSUMMARY:buy milk\\, eggs\r\nDESCRIPTION:first\\nsec\r\n ond\r\n" +
+		"DUE;TZID=\"Europe/Berlin\":20300102T100000\r\nSTATUS:NEEDS-ACTION\r\nCATEGORIES:home,x\\,y\r\n" +
+		"BEGIN:VALARM\r\nSUMMARY:alarm\r\nEND:VALARM\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+
+	todo, err := ical.Decode(strings.NewReader(in))
+	assert.NoError(t, err)
+	assert.Equal(t, "abc-1", todo.UID)
+	assert.Equal(t, "buy milk, eggs", *todo.Task.Title)
+	assert.Equal(t, "first\nsecond", *todo.Task.Description)
+	assert.Equal(t, "2030-01-02T09:00:00Z", todo.Task.Deadline.Format(time.RFC3339))
+	assert.False(t, *todo.Task.Complete)
+	assert.True(t, todo.HasCategories)
+	assert.Equal(t, []string{"home", "x,y"}, todo.Task.Tags)
+
+	todo, err = ical.Decode(strings.NewReader("BEGIN:VCALENDAR\nBEGIN:VTODO\nUID:b\nDUE;VALUE=DATE:20300102\nCOMPLETED:20300101T000000Z\nEND:VTODO\nEND:VCALENDAR\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "2030-01-02T00:00:00Z", todo.Task.Deadline.Format(time.RFC3339))
+	assert.True(t, *todo.Task.Complete)
+	assert.False(t, todo.HasCategories)
+
+	_, err = ical.Decode(strings.NewReader("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"))
+	assert.Error(t, err)
+
+	_, err = ical.Decode(strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nSUMMARY:x\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"))
+	assert.Error(t, err)
+}
+
+func TestParseUID(t *testing.T) {
+	id, ok := ical.ParseUID(ical.UID(42))
+	assert.True(t, ok)
+	assert.Equal(t, 42, id)
+
+	for _, uid := range []string{"abc", "task-x@taskmanager-api", "task-042@taskmanager-api", "task-1@other"} {
+		_, ok := ical.ParseUID(uid)
+		assert.False(t, ok, uid)
+	}
+}
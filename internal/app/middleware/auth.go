@@ -7,11 +7,13 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
 )
 
 type tokenClaims struct {
-	UserID int `json:"user_id"`
+	UserID      int `json:"user_id"`
+	WorkspaceID int `json:"workspace_id,omitempty"`
 	jwt.StandardClaims
 }
 
@@ -41,7 +43,20 @@ func AuthMiddleware(secret []byte, s store.Store) func(http.Handler) http.Handle
 				http.Error(w, err.Error(), http.StatusUnauthorized)
 				return
 		}
-			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), CtxKeyUser, u)))
+			ctx := context.WithValue(r.Context(), CtxKeyUser, u)
+
+			// the token of a team workspace is only good while the user is
+			// a member of it
+			if claims.WorkspaceID != model.PersonalWorkspaceID {
+				m, err := s.Workspace().FindMember(claims.WorkspaceID, u.ID)
+				if err != nil {
+					http.Error(w, "not a member of the workspace", http.StatusUnauthorized)
+					return
+				}
+				ctx = context.WithValue(ctx, CtxKeyWorkspace, m)
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+// BasicAuthMiddleware signs in the clients that send the email of a user
+// and one of the app passwords of the user with HTTP Basic auth. The
+// password of the account is not accepted.
+func BasicAuthMiddleware(realm string, s store.Store) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			email, password, ok := r.BasicAuth()
+			if !ok {
+				unauthorized(w, realm)
+				return
+			}
+
+			u, err := s.User().FindByEmail(email)
+			if err != nil {
+				unauthorized(w, realm)
+				return
+			}
+
+			if _, err := s.AppPassword().Authenticate(u.ID, password); err != nil {
+				unauthorized(w, realm)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), CtxKeyUser, u)))
+		})
+	}
+}
+
+func unauthorized(w http.ResponseWriter, realm string) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="`+realm+`", charset="UTF-8"`)
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
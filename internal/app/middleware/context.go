@@ -4,4 +4,7 @@ type ctxKey string
 
 const (
 	CtxKeyUser ctxKey = "user"
+	// CtxKeyWorkspace holds the *model.WorkspaceMember of the user in the
+	// active team workspace, it is not set in the personal workspace.
+	CtxKeyWorkspace ctxKey = "workspace"
 )
\ No newline at end of file
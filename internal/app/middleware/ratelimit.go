@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitMiddleware lets through at most limit requests per period for
+// each key, the key of a request is given by the key function. The budget
+// of a key refills steadily over the period. A request over the limit is
+// answered with 429 Too Many Requests.
+func RateLimitMiddleware(limit int, period time.Duration, key func(*http.Request) string) func(http.Handler) http.Handler {
+	l := NewRateLimiter(limit, period)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !l.Allow(w, key(r)) {
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimiter is the limit of RateLimitMiddleware for the handlers that
+// only know the key of a request once they have looked into it.
+type RateLimiter struct {
+	limit  float64
+	rate   float64
+	period time.Duration
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewRateLimiter(limit int, period time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:   float64(limit),
+		rate:    float64(limit) / period.Seconds(),
+		period:  period,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow spends a request of the key. A request over the limit is answered
+// with 429 Too Many Requests and Allow returns false.
+func (l *RateLimiter) Allow(w http.ResponseWriter, key string) bool {
+	if wait := l.take(key, time.Now()); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		return false
+	}
+
+	return true
+}
+
+// ClientAddr is the IP address the request comes from, the key of the
+// requests that do not carry a known credential.
+func ClientAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take spends a request of the key. It returns how long to wait when the
+// key has none left.
+func (l *RateLimiter) take(key string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.limit, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.limit, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+
+	b.tokens--
+
+	return 0
+}
+
+// sweep forgets the keys that have been refilled completely, once a period.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.period {
+		return
+	}
+
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= l.period {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// AppPassword lets a client that only knows HTTP Basic auth, like a CalDAV
+// client, sign in with the email of the user. The password is random and
+// only known right after it is created, the store keeps its hash.
+type AppPassword struct {
+	ID           int        `json:"id"`
+	UserID       int        `json:"user_id"`
+	Name         string     `json:"name"`
+	Password     string     `json:"password,omitempty"`
+	PasswordHash string     `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
+}
+
+func (p *AppPassword) Validation() error {
+	return validation.ValidateStruct(
+		p,
+		validation.Field(&p.Name, validation.Required, validation.Length(1, 100)),
+	)
+}
+
+// BeforeCreate generates the password.
+func (p *AppPassword) BeforeCreate() error {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+
+	p.Password = fmt.Sprintf("%x", b)
+	p.PasswordHash = HashAppPassword(p.Password)
+
+	return nil
+}
+
+// HashAppPassword hashes an app password. The passwords are random, a
+// plain hash is enough to keep them from being read from the store.
+func HashAppPassword(password string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(password)))
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"crypto/rand"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// attachmentTypes are the content types a file may have to be attached, as
+// sniffed from its first bytes.
+var attachmentTypes = map[string]bool{
+	"application/pdf":           true,
+	"image/gif":                 true,
+	"image/jpeg":                true,
+	"image/png":                 true,
+	"image/webp":                true,
+	"text/plain; charset=utf-8": true,
+}
+
+// Attachment is a file attached to a task of the user. Its contents are
+// kept in a blob store under Key, URL is a signed download URL that is
+// filled in when the attachment is returned.
+type Attachment struct {
+	ID          int       `json:"id"`
+	UserID      int       `json:"user_id"`
+	TaskID      int       `json:"task_id"`
+	UploadedBy  int       `json:"uploaded_by"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Key         string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	URL         string    `json:"url,omitempty"`
+}
+
+// BeforeCreate generates the key of the blob and keeps only the base name
+// of the file the client sent.
+func (a *Attachment) BeforeCreate() error {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+
+	a.Key = fmt.Sprintf("%x", b)
+	a.Name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(a.Name, `\`, "/")))
+	if a.Name == "." || a.Name == "/" {
+		a.Name = ""
+	}
+
+	return nil
+}
+
+func (a *Attachment) Validation() error {
+	return validation.ValidateStruct(
+		a,
+		validation.Field(&a.Name, validation.Required, validation.Length(1, 255)),
+		validation.Field(&a.ContentType, validation.Required),
+	)
+}
+
+// AttachmentTypeAllowed reports whether a file of the sniffed content type
+// may be attached.
+func AttachmentTypeAllowed(contentType string) bool {
+	return attachmentTypes[contentType]
+}
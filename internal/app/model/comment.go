@@ -0,0 +1,83 @@
+package model
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+const (
+	DefaultCommentLimit = 20
+	MaxCommentLimit     = 100
+	maxCommentBody      = 10000
+)
+
+// Comment is a message of the discussion of a task. UserID is the owner of
+// the task and AuthorID the user who wrote the comment, only the author
+// edits it. EditedAt is set once the comment has been edited.
+type Comment struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	TaskID    int        `json:"task_id"`
+	AuthorID  int        `json:"author_id"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+}
+
+func (c *Comment) Validation() error {
+	c.Body = strings.TrimSpace(c.Body)
+
+	return validation.ValidateStruct(
+		c,
+		validation.Field(&c.Body, validation.Required, validation.Length(1, maxCommentBody)),
+	)
+}
+
+// CommentQuery describes a page of the comments of a task, the oldest ones
+// first. AfterID is the last comment of the previous page.
+type CommentQuery struct {
+	Limit   int
+	AfterID int
+}
+
+type CommentPage struct {
+	Comments   []*Comment `json:"comments"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+func NewCommentQuery() *CommentQuery {
+	return &CommentQuery{Limit: DefaultCommentLimit}
+}
+
+func (q *CommentQuery) Validation() error {
+	if q.Limit < 1 || q.Limit > MaxCommentLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxCommentLimit)
+	}
+
+	return nil
+}
+
+// EncodeCommentCursor returns the cursor of the page after the comment.
+func EncodeCommentCursor(c *Comment) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(c.ID)))
+}
+
+// DecodeCommentCursor returns the ID of the comment the cursor points to.
+func DecodeCommentCursor(s string) (int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, errInvalidCursor
+	}
+
+	id, err := strconv.Atoi(string(b))
+	if err != nil || id <= 0 {
+		return 0, errInvalidCursor
+	}
+
+	return id, nil
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"time"
+)
+
+// Feed is the secret calendar feed of a user. Whoever knows the token can
+// read the tasks of the user, so only its hash is stored and the token is
+// known only right after the feed is created.
+type Feed struct {
+	UserID    int       `json:"user_id"`
+	Token     string    `json:"token,omitempty"`
+	TokenHash string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BeforeCreate generates a new token for the feed.
+func (f *Feed) BeforeCreate() error {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+
+	f.Token = fmt.Sprintf("%x", b)
+	f.TokenHash = HashFeedToken(f.Token)
+
+	return nil
+}
+
+func HashFeedToken(token string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(token)))
+}
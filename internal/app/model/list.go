@@ -0,0 +1,44 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+const InboxListName = "Inbox"
+
+// List groups the tasks of a user. Every user has an inbox list that is
+// created together with the user and cannot be deleted. Role is set on the
+// lists that other users share with the user. The inbox is in the personal
+// workspace of the user, the lists of a team workspace have its WorkspaceID.
+// Workflow is nil for the lists that use the default workflow.
+type List struct {
+	ID          int       `json:"id"`
+	UserID      int       `json:"user_id"`
+	WorkspaceID *int      `json:"workspace_id,omitempty"`
+	Name        string    `json:"name"`
+	Inbox       bool      `json:"inbox"`
+	Role        string    `json:"role,omitempty"`
+	Workflow    *Workflow `json:"workflow,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (l *List) Validation() error {
+	l.Name = strings.TrimSpace(l.Name)
+
+	return validation.ValidateStruct(
+		l,
+		validation.Field(&l.Name, validation.Required, validation.Length(1, 100)),
+	)
+}
+
+// TaskWorkflow is the workflow the tasks of the list go through.
+func (l *List) TaskWorkflow() *Workflow {
+	if l.Workflow == nil {
+		return DefaultWorkflow()
+	}
+
+	return l.Workflow
+}
@@ -0,0 +1,118 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+const maxReminderOffset = 365 * 24 * time.Hour
+
+// reminderUnits are the units a reminder offset can be written in, from the
+// largest to the smallest.
+var reminderUnits = []struct {
+	suffix string
+	d      time.Duration
+}{
+	{"w", 7 * 24 * time.Hour},
+	{"d", 24 * time.Hour},
+	{"h", time.Hour},
+	{"m", time.Minute},
+}
+
+// ReminderOffset is how long before the deadline of a task its reminder is
+// sent. It is written as a number followed by a unit, for example 30m, 1h,
+// 1d or 2w.
+type ReminderOffset time.Duration
+
+func ParseReminderOffset(s string) (ReminderOffset, error) {
+	s = strings.TrimSpace(s)
+
+	for _, u := range reminderUnits {
+		if !strings.HasSuffix(s, u.suffix) {
+			continue
+		}
+
+		n, err := strconv.Atoi(strings.TrimSuffix(s, u.suffix))
+		if err != nil {
+			break
+		}
+
+		return ReminderOffset(time.Duration(n) * u.d), nil
+	}
+
+	return 0, fmt.Errorf("invalid reminder offset %q", s)
+}
+
+func (o ReminderOffset) String() string {
+	for _, u := range reminderUnits {
+		if time.Duration(o)%u.d == 0 {
+			return fmt.Sprintf("%d%s", time.Duration(o)/u.d, u.suffix)
+		}
+	}
+
+	return time.Duration(o).String()
+}
+
+func (o ReminderOffset) MarshalJSON() ([]byte, error) {
+	return json.Marshal(o.String())
+}
+
+func (o *ReminderOffset) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	offset, err := ParseReminderOffset(s)
+	if err != nil {
+		return err
+	}
+
+	*o = offset
+
+	return nil
+}
+
+// Reminder notifies the user of a task that its deadline is approaching. A
+// reminder is sent once per deadline, moving the deadline of the task arms
+// it again.
+type Reminder struct {
+	ID       int            `json:"id"`
+	UserID   int            `json:"user_id"`
+	TaskID   int            `json:"task_id"`
+	Offset   ReminderOffset `json:"offset"`
+	RemindAt *time.Time     `json:"remind_at,omitempty"`
+	SentAt   *time.Time     `json:"sent_at,omitempty"`
+
+	// Task is filled in for the reminders that are due, it is what the
+	// notification is about.
+	Task *Task `json:"task,omitempty"`
+}
+
+func (r *Reminder) Validation() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.Offset, validation.By(func(interface{}) error {
+			if r.Offset < ReminderOffset(time.Minute) {
+				return errors.New("must be at least 1m")
+			}
+
+			if r.Offset > ReminderOffset(maxReminderOffset) {
+				return errors.New("must be at most 365d")
+			}
+
+			return nil
+		})),
+	)
+}
+
+// Due reports whether the reminder has to be sent for the given deadline.
+func (r *Reminder) Due(deadline time.Time, now time.Time) bool {
+	return !deadline.Add(-time.Duration(r.Offset)).After(now)
+}
@@ -0,0 +1,50 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+)
+
+func TestParseReminderOffset(t *testing.T) {
+	testCases := []struct {
+		offset   string
+		expected time.Duration
+		isValid  bool
+	}{
+		{offset: "30m", expected: 30 * time.Minute, isValid: true},
+		{offset: "1h", expected: time.Hour, isValid: true},
+		{offset: "1d", expected: 24 * time.Hour, isValid: true},
+		{offset: "2w", expected: 14 * 24 * time.Hour, isValid: true},
+		{offset: "", isValid: false},
+		{offset: "1y", isValid: false},
+		{offset: "h", isValid: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.offset, func(t *testing.T) {
+			o, err := model.ParseReminderOffset(tc.offset)
+			if !tc.isValid {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, time.Duration(o))
+			assert.Equal(t, tc.offset, o.String())
+		})
+	}
+}
+
+func TestReminder_Validation(t *testing.T) {
+	r := &model.Reminder{Offset: model.ReminderOffset(time.Hour)}
+	assert.NoError(t, r.Validation())
+
+	r.Offset = 0
+	assert.Error(t, r.Validation())
+
+	r.Offset = model.ReminderOffset(400 * 24 * time.Hour)
+	assert.Error(t, r.Validation())
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// The roles of a share, each role may do what the roles before it may.
+// A viewer reads the shared tasks, an editor also changes them and an owner
+// also shares them with other users.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleOwner  = "owner"
+)
+
+const (
+	ShareStatusPending  = "pending"
+	ShareStatusAccepted = "accepted"
+	ShareStatusDeclined = "declined"
+)
+
+var roleRanks = map[string]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleOwner:  3,
+}
+
+// Share grants a user a role on a list or a task of another user. The
+// subtasks of a shared task and the tasks of a shared list are shared with
+// it. A share is an invitation until the user accepts it.
+type Share struct {
+	ID        int       `json:"id"`
+	OwnerID   int       `json:"owner_id"`
+	ListID    *int      `json:"list_id,omitempty"`
+	TaskID    *int      `json:"task_id,omitempty"`
+	GranteeID int       `json:"grantee_id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	Status    string    `json:"status"`
+	InvitedBy int       `json:"invited_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (s *Share) Validation() error {
+	if (s.ListID == nil) == (s.TaskID == nil) {
+		return errors.New("share either a list_id or a task_id")
+	}
+
+	if s.GranteeID == s.OwnerID {
+		return errors.New("the items of a user cannot be shared with the user")
+	}
+
+	return validation.ValidateStruct(
+		s,
+		validation.Field(&s.Role, validation.Required, validation.In(RoleViewer, RoleEditor, RoleOwner)),
+	)
+}
+
+// RoleAllows reports whether the role may do what the required role may.
+func RoleAllows(role string, required string) bool {
+	return roleRanks[role] > 0 && roleRanks[role] >= roleRanks[required]
+}
+
+// MaxRole returns the role that may do more.
+func MaxRole(a string, b string) string {
+	if roleRanks[b] > roleRanks[a] {
+		return b
+	}
+
+	return a
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+const maxTagLength = 50
+
+type Tag struct {
+	ID     int    `json:"id"`
+	UserID int    `json:"user_id"`
+	Name   string `json:"name"`
+}
+
+func (t *Tag) Validation() error {
+	t.Name = strings.TrimSpace(t.Name)
+
+	return validation.ValidateStruct(
+		t,
+		validation.Field(&t.Name, validation.Required, validation.Length(1, maxTagLength)),
+	)
+}
+
+// NormalizeTags trims tag names and removes duplicates, so that a task
+// always carries a sorted set of tags.
+func NormalizeTags(tags []string) []string {
+	seen := map[string]bool{}
+	normalized := []string{}
+
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+
+	sort.Strings(normalized)
+
+	return normalized
+}
+
+func validateTags(tags []string) error {
+	for _, tag := range tags {
+		if tag == "" {
+			return errors.New("tag cannot be empty")
+		}
+
+		if len(tag) > maxTagLength {
+			return fmt.Errorf("tag %q is longer than %d characters", tag, maxTagLength)
+		}
+	}
+
+	return nil
+}
@@ -2,18 +2,69 @@ package model
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
 )
 
 type Task struct {
-	UserID      int        `json:"user_id"`
-	TaskID      int        `json:"task_id"`
-	Title       *string    `json:"title"`
-	Description *string    `json:"description"`
-	Deadline    *time.Time `json:"deadline"`
-	Complete    *bool      `json:"complete"`
+	UserID       int        `json:"user_id"`
+	TaskID       int        `json:"task_id"`
+	WorkspaceID  *int       `json:"workspace_id,omitempty"`
+	ListID       *int       `json:"list_id"`
+	ParentTaskID *int       `json:"parent_task_id"`
+	Title        *string    `json:"title"`
+	Description  *string    `json:"description"`
+	Deadline     *time.Time `json:"deadline"`
+	Complete     *bool      `json:"complete"`
+	Status       *string    `json:"status"`
+	Tags         []string   `json:"tags"`
+	BlockedBy    []int      `json:"blocked_by"`
+	Blocking     []int      `json:"blocking"`
+	TimeSpent    int64      `json:"time_spent"`
+	RRule        *string    `json:"rrule"`
+	SeriesID     *string    `json:"series_id"`
+	Version      int        `json:"version"`
+	CreatedAt    time.Time  `json:"created_at"`
+	CompletedAt  *time.Time `json:"completed_at"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
+	Children     []*Task    `json:"children,omitempty"`
+
+	// Depth is the level of the deepest task of the subtree once the task
+	// is stored under its parent, it is filled in before Validation.
+	Depth int `json:"-"`
+	// CompleteSubtasks marks all the descendants complete together with
+	// the task.
+	CompleteSubtasks bool `json:"-"`
+	// ForceComplete completes the task even though tasks that block it are
+	// still open.
+	ForceComplete bool `json:"-"`
+	// ClearParent moves the task to the top level on Update, where a nil
+	// ParentTaskID keeps the parent.
+	ClearParent bool `json:"-"`
+	// ActorID is the user making the change, it is recorded in the history
+	// of the task. The owner of the task is assumed when it is not set.
+	ActorID int `json:"-"`
+	// IfVersion is the version the writer expects the stored task to have,
+	// the write fails with store.ErrVersionConflict when it has another.
+	IfVersion *int `json:"-"`
+	// ICalUID is the UID of the iCalendar object of a task created by a
+	// CalDAV client, the other tasks have the UID given by ical.UID.
+	ICalUID *string `json:"-"`
+	// ParentICalUID is the ICalUID of the parent of a subtask, the store
+	// fills it in when it reads the task.
+	ParentICalUID *string `json:"-"`
+	// PastDeadline lets a created or replaced task have a deadline in the
+	// past, for imported historical tasks and for replaced tasks that keep
+	// their deadline.
+	PastDeadline bool `json:"-"`
+	// NextTaskID is the next occurrence of a recurring task that Update or
+	// Replace created when they completed the task.
+	NextTaskID int `json:"-"`
+	// CompletedSubtaskIDs are the descendants that Update completed along
+	// with the task.
+	CompletedSubtaskIDs []int `json:"-"`
 }
 
 type CustomTime struct {
@@ -25,6 +76,7 @@ const timeLayout = "2006-01-02 15:04:05"
 var (
 	getTask = http.MethodGet
 	createTask = http.MethodPost
+	replaceTask = http.MethodPut
 	updateTask = http.MethodPatch
 	deleteTask = http.MethodDelete
 )
@@ -32,12 +84,12 @@ var (
 func (t *Task) Validation(method string) error {
 
 	switch method {
-	case createTask:
+	case createTask, replaceTask:
 		if t.Title == nil || *t.Title == "" {
 			return errors.New("title cannot be empty")
 		}
 	
-		if t.Deadline == nil || time.Now().After(*t.Deadline) {
+		if t.Deadline == nil || (!t.PastDeadline && time.Now().After(*t.Deadline)) {
 			return errors.New("wrong format of deadline: use format YYYY-MM-DD HH:MM:SS")
 		}
 	case updateTask:
@@ -50,9 +102,44 @@ func (t *Task) Validation(method string) error {
 		}
 	}
 
+	if err := validateTags(t.Tags); err != nil {
+		return err
+	}
+
+	if err := t.validateRRule(); err != nil {
+		return err
+	}
+
+	if t.Depth > MaxTaskDepth {
+		return fmt.Errorf("subtasks cannot be nested deeper than %d levels", MaxTaskDepth)
+	}
+
+	if t.CompleteSubtasks && (t.Complete == nil || !*t.Complete) {
+		return errors.New("complete_subtasks requires complete to be true")
+	}
+
+	if t.ForceComplete && (t.Complete == nil || !*t.Complete) {
+		return errors.New("force requires complete to be true")
+	}
+
 	return nil
 }
 
+// ParseTime accepts the YYYY-MM-DD HH:MM:SS layout used in request bodies
+// as well as RFC 3339.
+func ParseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(timeLayout, s); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, errors.New("invalid time format: use YYYY-MM-DD HH:MM:SS or RFC 3339")
+	}
+
+	return t.UTC(), nil
+}
+
 func (ct *CustomTime) UnmarshalJSON(b []byte) error {
 	s := strings.Trim(string(b), `"`)
 	parsedTime, err := time.Parse(timeLayout, s)
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"encoding/json"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+const (
+	BatchAtomic     = "atomic"
+	BatchBestEffort = "best_effort"
+
+	OpCreate   = "create"
+	OpUpdate   = "update"
+	OpDelete   = "delete"
+	OpComplete = "complete"
+
+	// MaxBatchOperations is the most operations a batch may hold.
+	MaxBatchOperations = 1000
+)
+
+// TaskBatch is a list of operations on the tasks of a user that run in a
+// single transaction. An atomic batch is rolled back as soon as one of the
+// operations fails, a best effort one keeps the operations that succeeded.
+type TaskBatch struct {
+	Mode       string           `json:"mode"`
+	Operations []*TaskOperation `json:"operations"`
+}
+
+// TaskOperation is an operation of a batch. Task holds the fields of the
+// task to create or update in the format of the single task endpoints. A
+// set Version is required from the task, like If-Match.
+type TaskOperation struct {
+	Op      string          `json:"op"`
+	TaskID  int             `json:"task_id,omitempty"`
+	Version *int            `json:"version,omitempty"`
+	Task    json.RawMessage `json:"task,omitempty"`
+}
+
+func (b *TaskBatch) Validation() error {
+	return validation.ValidateStruct(
+		b,
+		validation.Field(&b.Mode, validation.In(BatchAtomic, BatchBestEffort)),
+		validation.Field(&b.Operations, validation.Required, validation.Length(1, MaxBatchOperations), validation.Each(validation.NotNil)),
+	)
+}
+
+func (o *TaskOperation) Validation() error {
+	return validation.ValidateStruct(
+		o,
+		validation.Field(&o.Op, validation.Required, validation.In(OpCreate, OpUpdate, OpDelete, OpComplete)),
+		validation.Field(&o.TaskID, validation.By(requiredIf(o.Op != OpCreate))),
+		validation.Field(&o.Task, validation.By(requiredIf(o.Op == OpCreate || o.Op == OpUpdate))),
+	)
+}
+
+// TaskOperationResult reports how an operation of a batch went with the
+// status code the single task endpoint would have answered.
+type TaskOperationResult struct {
+	Op     string `json:"op"`
+	TaskID int    `json:"task_id,omitempty"`
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Task   *Task  `json:"task,omitempty"`
+}
+
+// TaskBatchResult holds a result per operation of a batch, in the order of
+// the operations. Committed is false when nothing of the batch was stored.
+type TaskBatchResult struct {
+	Committed bool                   `json:"committed"`
+	Results   []*TaskOperationResult `json:"results"`
+}
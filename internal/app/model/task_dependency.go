@@ -0,0 +1,75 @@
+package model
+
+import "container/heap"
+
+// SortTopologically orders the tasks so that every task comes after the
+// tasks that block it. Of the tasks that may come next the one with the
+// earliest deadline goes first, the same way the listing orders them. The
+// blocking tasks that are not among the tasks are left out of the order.
+func SortTopologically(tasks []*Task) []*Task {
+	byID := make(map[int]*Task, len(tasks))
+	for _, t := range tasks {
+		byID[t.TaskID] = t
+	}
+
+	waiting := make(map[int]int, len(tasks))
+	ready := &readyTasks{}
+
+	for _, t := range tasks {
+		for _, id := range t.BlockedBy {
+			if _, ok := byID[id]; ok {
+				waiting[t.TaskID]++
+			}
+		}
+
+		if waiting[t.TaskID] == 0 {
+			heap.Push(ready, t)
+		}
+	}
+
+	sorted := make([]*Task, 0, len(tasks))
+
+	for ready.Len() > 0 {
+		t := heap.Pop(ready).(*Task)
+		sorted = append(sorted, t)
+
+		for _, id := range t.Blocking {
+			next, ok := byID[id]
+			if !ok {
+				continue
+			}
+
+			waiting[id]--
+			if waiting[id] == 0 {
+				heap.Push(ready, next)
+			}
+		}
+	}
+
+	return sorted
+}
+
+// readyTasks is a heap of the tasks whose blocking tasks are all sorted.
+type readyTasks []*Task
+
+func (h readyTasks) Len() int { return len(h) }
+
+func (h readyTasks) Less(i, j int) bool {
+	if cmp := compareDeadlines(h[i].Deadline, h[j].Deadline); cmp != 0 {
+		return cmp < 0
+	}
+
+	return h[i].TaskID < h[j].TaskID
+}
+
+func (h readyTasks) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *readyTasks) Push(x any) { *h = append(*h, x.(*Task)) }
+
+func (h *readyTasks) Pop() any {
+	old := *h
+	t := old[len(old)-1]
+	*h = old[:len(old)-1]
+
+	return t
+}
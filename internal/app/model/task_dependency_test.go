@@ -0,0 +1,33 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+)
+
+func TestSortTopologically(t *testing.T) {
+	now := time.Now().UTC()
+	task := func(id int, deadline time.Duration, blockedBy []int, blocking []int) *model.Task {
+		d := now.Add(deadline)
+		return &model.Task{TaskID: id, Deadline: &d, BlockedBy: blockedBy, Blocking: blocking}
+	}
+
+	// 1 blocks 3, 2 blocks 3 and 4, 5 is blocked by a task that is not sorted
+	tasks := []*model.Task{
+		task(3, time.Hour, []int{1, 2}, nil),
+		task(1, 3*time.Hour, nil, []int{3}),
+		task(2, 2*time.Hour, nil, []int{3, 4}),
+		task(4, 4*time.Hour, []int{2}, nil),
+		task(5, 5*time.Hour, []int{9}, nil),
+	}
+
+	ids := []int{}
+	for _, t := range model.SortTopologically(tasks) {
+		ids = append(ids, t.TaskID)
+	}
+
+	assert.Equal(t, []int{2, 1, 3, 4, 5}, ids)
+}
@@ -0,0 +1,152 @@
+package model
+
+import (
+	"reflect"
+	"time"
+)
+
+const (
+	TaskEventCreated  = "created"
+	TaskEventUpdated  = "updated"
+	TaskEventDeleted  = "deleted"
+	TaskEventRestored = "restored"
+)
+
+// TaskEvent is an entry of the history of a task. Changes holds the fields
+// that were set on create, changed on update and lost on delete.
+type TaskEvent struct {
+	ID        int                     `json:"id"`
+	UserID    int                     `json:"user_id"`
+	TaskID    int                     `json:"task_id"`
+	ActorID   int                     `json:"actor_id"`
+	Type      string                  `json:"type"`
+	Changes   map[string]*FieldChange `json:"changes"`
+	CreatedAt time.Time               `json:"created_at"`
+	// WorkspaceID is the workspace of the task, each workspace has its own
+	// sync token.
+	WorkspaceID *int `json:"-"`
+}
+
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// NewTaskEvent describes the change of a task from before to after. Before
+// is nil for a created task and after is nil for a deleted one. The event
+// is nil when an update did not change anything.
+func NewTaskEvent(actorID int, before *Task, after *Task) *TaskEvent {
+	e := &TaskEvent{
+		ActorID: actorID,
+		Type:    TaskEventUpdated,
+		Changes: DiffTasks(before, after),
+	}
+
+	switch {
+	case before == nil:
+		e.Type = TaskEventCreated
+		e.UserID, e.TaskID, e.WorkspaceID = after.UserID, after.TaskID, after.WorkspaceID
+	case after == nil:
+		e.Type = TaskEventDeleted
+		e.UserID, e.TaskID, e.WorkspaceID = before.UserID, before.TaskID, before.WorkspaceID
+	default:
+		e.UserID, e.TaskID, e.WorkspaceID = after.UserID, after.TaskID, after.WorkspaceID
+		if len(e.Changes) == 0 {
+			return nil
+		}
+	}
+
+	if e.ActorID == 0 {
+		e.ActorID = e.UserID
+	}
+
+	return e
+}
+
+// NewTaskRestoredEvent records that the task was taken out of the trash.
+func NewTaskRestoredEvent(actorID int, t *Task) *TaskEvent {
+	if actorID == 0 {
+		actorID = t.UserID
+	}
+
+	return &TaskEvent{
+		UserID:      t.UserID,
+		TaskID:      t.TaskID,
+		ActorID:     actorID,
+		WorkspaceID: t.WorkspaceID,
+		Type:        TaskEventRestored,
+		Changes:     map[string]*FieldChange{},
+	}
+}
+
+// DiffTasks returns the fields whose values differ between the tasks, a nil
+// task has no values.
+func DiffTasks(before *Task, after *Task) map[string]*FieldChange {
+	b, a := before.fields(), after.fields()
+	changes := map[string]*FieldChange{}
+
+	for name := range a {
+		if !reflect.DeepEqual(b[name], a[name]) {
+			changes[name] = &FieldChange{Before: b[name], After: a[name]}
+		}
+	}
+
+	return changes
+}
+
+// fields returns the recorded fields of the task, unset fields are nil.
+func (t *Task) fields() map[string]interface{} {
+	f := map[string]interface{}{
+		"list_id":        nil,
+		"parent_task_id": nil,
+		"title":          nil,
+		"description":    nil,
+		"deadline":       nil,
+		"complete":       nil,
+		"status":         nil,
+		"tags":           nil,
+		"rrule":          nil,
+	}
+
+	if t == nil {
+		return f
+	}
+
+	if t.ListID != nil {
+		f["list_id"] = *t.ListID
+	}
+
+	if t.ParentTaskID != nil {
+		f["parent_task_id"] = *t.ParentTaskID
+	}
+
+	if t.Title != nil {
+		f["title"] = *t.Title
+	}
+
+	if t.Description != nil {
+		f["description"] = *t.Description
+	}
+
+	if t.Deadline != nil {
+		f["deadline"] = t.Deadline.UTC().Format(time.RFC3339)
+	}
+
+	if t.Complete != nil {
+		f["complete"] = *t.Complete
+	}
+
+	if t.Status != nil {
+		f["status"] = *t.Status
+	}
+
+	if len(t.Tags) > 0 {
+		f["tags"] = append([]string{}, t.Tags...)
+	}
+
+	if t.RRule != nil {
+		f["rrule"] = *t.RRule
+	}
+
+	return f
+}
@@ -0,0 +1,17 @@
+package model
+
+// TaskImportResult reports an import of tasks. Imported counts the tasks
+// that were stored, or that would be stored on a dry run. Nothing is
+// imported when any of the rows has an error.
+type TaskImportResult struct {
+	DryRun   bool               `json:"dry_run"`
+	Imported int                `json:"imported"`
+	Errors   []*TaskImportError `json:"errors"`
+}
+
+// TaskImportError is the error of a row of an imported file, rows are
+// counted from 1 without the header of a CSV file.
+type TaskImportError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
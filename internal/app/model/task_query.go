@@ -0,0 +1,286 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultTaskLimit = 20
+	MaxTaskLimit     = 100
+	maxFilterText    = 200
+)
+
+const (
+	SortDeadline  = "deadline"
+	SortTitle     = "title"
+	SortCreatedAt = "created_at"
+
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// TaskFilter narrows the task listing. Nil fields are not applied.
+type TaskFilter struct {
+	Complete  *bool
+	DueBefore *time.Time
+	DueAfter  *time.Time
+	Overdue   *bool
+	Text      string
+	Tags      []string
+	ListID    *int
+}
+
+// TaskQuery describes a single page request of the task listing.
+type TaskQuery struct {
+	Filter TaskFilter
+	Sort   string
+	Order  string
+	Limit  int
+	Cursor *TaskCursor
+}
+
+// TaskCursor points to the last task of the previous page: the value of the
+// sort field and the task_id used to break ties. Tasks without a deadline
+// sort as if it were later than all the others, so they come last in
+// ascending order and first in descending order.
+type TaskCursor struct {
+	Sort   string     `json:"sort"`
+	Order  string     `json:"order"`
+	Time   *time.Time `json:"time,omitempty"`
+	Title  string     `json:"title,omitempty"`
+	TaskID int        `json:"task_id"`
+}
+
+type TaskPage struct {
+	Tasks      []*Task `json:"tasks"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+func NewTaskQuery() *TaskQuery {
+	return &TaskQuery{
+		Sort:  SortDeadline,
+		Order: OrderAsc,
+		Limit: DefaultTaskLimit,
+	}
+}
+
+func (q *TaskQuery) Validation() error {
+	if q.Limit < 1 || q.Limit > MaxTaskLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxTaskLimit)
+	}
+
+	switch q.Sort {
+	case SortDeadline, SortTitle, SortCreatedAt:
+	default:
+		return fmt.Errorf("unknown sort field %q: use deadline, title or created_at", q.Sort)
+	}
+
+	if q.Order != OrderAsc && q.Order != OrderDesc {
+		return fmt.Errorf("unknown order %q: use asc or desc", q.Order)
+	}
+
+	if q.Filter.DueBefore != nil && q.Filter.DueAfter != nil && !q.Filter.DueAfter.Before(*q.Filter.DueBefore) {
+		return errors.New("due_after must be earlier than due_before")
+	}
+
+	if len(q.Filter.Text) > maxFilterText {
+		return fmt.Errorf("text filter must be at most %d characters", maxFilterText)
+	}
+
+	if err := validateTags(q.Filter.Tags); err != nil {
+		return err
+	}
+
+	if q.Cursor != nil && (q.Cursor.Sort != q.Sort || q.Cursor.Order != q.Order) {
+		return errors.New("cursor does not match the requested sort order")
+	}
+
+	return nil
+}
+
+// Match reports whether the task satisfies the filter at the given moment.
+func (f *TaskFilter) Match(t *Task, now time.Time) bool {
+	complete := t.Complete != nil && *t.Complete
+
+	if f.Complete != nil && complete != *f.Complete {
+		return false
+	}
+
+	if f.ListID != nil && (t.ListID == nil || *t.ListID != *f.ListID) {
+		return false
+	}
+
+	if f.DueBefore != nil && (t.Deadline == nil || !t.Deadline.Before(*f.DueBefore)) {
+		return false
+	}
+
+	if f.DueAfter != nil && (t.Deadline == nil || !t.Deadline.After(*f.DueAfter)) {
+		return false
+	}
+
+	if f.Overdue != nil {
+		overdue := !complete && t.Deadline != nil && t.Deadline.Before(now)
+		if overdue != *f.Overdue {
+			return false
+		}
+	}
+
+	if f.Text != "" {
+		text := strings.ToLower(f.Text)
+		inTitle := t.Title != nil && strings.Contains(strings.ToLower(*t.Title), text)
+		inDescription := t.Description != nil && strings.Contains(strings.ToLower(*t.Description), text)
+		if !inTitle && !inDescription {
+			return false
+		}
+	}
+
+	for _, tag := range f.Tags {
+		found := false
+		for _, name := range t.Tags {
+			if name == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Less reports whether task a goes before task b in the order of the query.
+func (q *TaskQuery) Less(a, b *Task) bool {
+	cmp := 0
+
+	switch q.Sort {
+	case SortDeadline:
+		cmp = compareDeadlines(a.Deadline, b.Deadline)
+	case SortTitle:
+		cmp = strings.Compare(stringValue(a.Title), stringValue(b.Title))
+	case SortCreatedAt:
+		cmp = a.CreatedAt.Compare(b.CreatedAt)
+	}
+
+	if cmp == 0 {
+		cmp = a.TaskID - b.TaskID
+	}
+
+	if q.Order == OrderDesc {
+		return cmp > 0
+	}
+
+	return cmp < 0
+}
+
+func (q *TaskQuery) NewCursor(t *Task) *TaskCursor {
+	c := &TaskCursor{
+		Sort:   q.Sort,
+		Order:  q.Order,
+		TaskID: t.TaskID,
+	}
+
+	switch q.Sort {
+	case SortDeadline:
+		c.Time = t.Deadline
+	case SortTitle:
+		c.Title = stringValue(t.Title)
+	case SortCreatedAt:
+		c.Time = &t.CreatedAt
+	}
+
+	return c
+}
+
+// Task restores the sort key of the cursor as a task, so that it can be
+// compared with Less.
+func (c *TaskCursor) Task() *Task {
+	t := &Task{TaskID: c.TaskID}
+
+	switch c.Sort {
+	case SortDeadline:
+		t.Deadline = c.Time
+	case SortTitle:
+		t.Title = &c.Title
+	case SortCreatedAt:
+		if c.Time != nil {
+			t.CreatedAt = *c.Time
+		}
+	}
+
+	return t
+}
+
+func (c *TaskCursor) Encode() string {
+	b, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeTaskCursor(s string) (*TaskCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	c := &TaskCursor{}
+	if err := json.Unmarshal(b, c); err != nil || c.TaskID <= 0 {
+		return nil, errInvalidCursor
+	}
+
+	return c, nil
+}
+
+// compareDeadlines orders tasks without a deadline after all the others,
+// Less reverses it for the descending order.
+func compareDeadlines(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+
+	return a.Compare(*b)
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
+
+// TaskSearchResult is a task found by full-text search together with its
+// relevance and a fragment of text with the matches highlighted.
+type TaskSearchResult struct {
+	*Task
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// SnippetStart and SnippetStop enclose the matches in the fragments the
+// stores find, they are private use characters that are removed from the
+// text of the tasks beforehand.
+const (
+	SnippetStart = "\ue000"
+	SnippetStop  = "\ue001"
+)
+
+// NewSnippet turns a fragment with the matches enclosed in SnippetStart and
+// SnippetStop into HTML: the text is escaped and the matches are in <b>.
+func NewSnippet(fragment string) string {
+	return strings.NewReplacer(SnippetStart, "<b>", SnippetStop, "</b>").Replace(html.EscapeString(fragment))
+}
@@ -0,0 +1,104 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+)
+
+func TestTaskQuery_Validation(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+
+	testCases := []struct {
+		name    string
+		q       func() *model.TaskQuery
+		isValid bool
+	}{
+		{
+			name: "valid",
+			q: func() *model.TaskQuery {
+				return model.NewTaskQuery()
+			},
+			isValid: true,
+		},
+		{
+			name: "limit too large",
+			q: func() *model.TaskQuery {
+				q := model.NewTaskQuery()
+				q.Limit = model.MaxTaskLimit + 1
+				return q
+			},
+			isValid: false,
+		},
+		{
+			name: "unknown sort field",
+			q: func() *model.TaskQuery {
+				q := model.NewTaskQuery()
+				q.Sort = "priority"
+				return q
+			},
+			isValid: false,
+		},
+		{
+			name: "unknown order",
+			q: func() *model.TaskQuery {
+				q := model.NewTaskQuery()
+				q.Order = "up"
+				return q
+			},
+			isValid: false,
+		},
+		{
+			name: "empty deadline range",
+			q: func() *model.TaskQuery {
+				q := model.NewTaskQuery()
+				q.Filter.DueBefore = &now
+				q.Filter.DueAfter = &later
+				return q
+			},
+			isValid: false,
+		},
+		{
+			name: "cursor of another sort order",
+			q: func() *model.TaskQuery {
+				q := model.NewTaskQuery()
+				q.Cursor = &model.TaskCursor{Sort: model.SortTitle, Order: model.OrderAsc, TaskID: 1}
+				return q
+			},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.q().Validation())
+			} else {
+				assert.Error(t, tc.q().Validation())
+			}
+		})
+	}
+}
+
+func TestTaskQuery_Less(t *testing.T) {
+	dated := model.TestTask(t, 1)
+	dated.TaskID = 1
+	undated := model.TestTask(t, 1)
+	undated.TaskID = 2
+	undated.Deadline = nil
+
+	q := model.NewTaskQuery()
+	assert.True(t, q.Less(dated, undated))
+
+	// tasks without a deadline come first in descending order
+	q.Order = model.OrderDesc
+	assert.True(t, q.Less(undated, dated))
+}
+
+func TestNewSnippet(t *testing.T) {
+	fragment := `<a href="x">` + model.SnippetStart + "report" + model.SnippetStop + " & more"
+	assert.Equal(t, "&lt;a href=&#34;x&#34;&gt;<b>report</b> &amp; more", model.NewSnippet(fragment))
+}
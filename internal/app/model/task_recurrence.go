@@ -0,0 +1,99 @@
+package model
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/rrule"
+)
+
+// BeforeCreate starts a new series for a recurring task that does not
+// belong to one yet.
+func (t *Task) BeforeCreate() error {
+	if t.RRule == nil || *t.RRule == "" || t.SeriesID != nil {
+		return nil
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+
+	seriesID := fmt.Sprintf("%x", b)
+	t.SeriesID = &seriesID
+
+	return nil
+}
+
+// NextOccurrence returns the task that follows the completed one in its
+// series, or nil when the rule is exhausted. The deadline is advanced by the
+// rule past both the current deadline and the given moment, so completing an
+// overdue task does not produce occurrences that are already overdue.
+func (t *Task) NextOccurrence(now time.Time) (*Task, error) {
+	if t.RRule == nil || *t.RRule == "" {
+		return nil, nil
+	}
+
+	if t.Deadline == nil {
+		return nil, errors.New("a recurring task must have a deadline")
+	}
+
+	rule, err := rrule.Parse(*t.RRule)
+	if err != nil {
+		return nil, err
+	}
+
+	it := rule.Iterator(*t.Deadline)
+	it.Next()
+
+	for n := 1; ; n++ {
+		deadline, ok := it.Next()
+		if !ok {
+			return nil, nil
+		}
+
+		if !deadline.After(now) {
+			continue
+		}
+
+		if rule.Count > 0 {
+			rule.Count -= n
+		}
+		next := rule.String()
+		complete := false
+
+		return &Task{
+			UserID:       t.UserID,
+			WorkspaceID:  t.WorkspaceID,
+			ListID:       t.ListID,
+			ParentTaskID: t.ParentTaskID,
+			Title:        t.Title,
+			Description:  t.Description,
+			Deadline:     &deadline,
+			Complete:     &complete,
+			Tags:         append([]string{}, t.Tags...),
+			RRule:        &next,
+			SeriesID:     t.SeriesID,
+		}, nil
+	}
+}
+
+// validateRRule checks the rule and stores it in the canonical form. An
+// empty rule is kept as is, it removes the recurrence on update.
+func (t *Task) validateRRule() error {
+	if t.RRule == nil || *t.RRule == "" {
+		return nil
+	}
+
+	rule, err := rrule.Parse(*t.RRule)
+	if err != nil {
+		return err
+	}
+
+	canonical := rule.String()
+	t.RRule = &canonical
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	maxStatsWindows    = 5
+	maxStatsWindowDays = 365
+)
+
+// DefaultStatsWindows are the windows of the statistics, in days, when the
+// query does not name any.
+var DefaultStatsWindows = []int{7, 30}
+
+// TaskStatsQuery describes the statistics of the tasks of a user at Now.
+// Every window is a number of days that ends at Now.
+type TaskStatsQuery struct {
+	Windows []int
+	Now     time.Time
+}
+
+func NewTaskStatsQuery(now time.Time) *TaskStatsQuery {
+	return &TaskStatsQuery{Windows: DefaultStatsWindows, Now: now.UTC()}
+}
+
+func (q *TaskStatsQuery) Validation() error {
+	if len(q.Windows) == 0 || len(q.Windows) > maxStatsWindows {
+		return fmt.Errorf("between 1 and %d windows are allowed", maxStatsWindows)
+	}
+
+	seen := map[int]bool{}
+	for _, days := range q.Windows {
+		if days < 1 || days > maxStatsWindowDays {
+			return fmt.Errorf("a window is between 1 and %d days", maxStatsWindowDays)
+		}
+
+		if seen[days] {
+			return errors.New("the windows must be different")
+		}
+		seen[days] = true
+	}
+
+	return nil
+}
+
+// TaskStats are the statistics of the tasks of a user. The counts are of
+// the tasks that are not in the trash, except for Trashed, and the overdue
+// tasks are open tasks past their deadline. AverageLeadTime is the average
+// time in seconds from the creation to the completion of the complete
+// tasks, nil without any.
+type TaskStats struct {
+	Total           int                `json:"total"`
+	Open            int                `json:"open"`
+	Complete        int                `json:"complete"`
+	Overdue         int                `json:"overdue"`
+	Trashed         int                `json:"trashed"`
+	AverageLeadTime *float64           `json:"average_lead_time"`
+	Windows         []*TaskStatsWindow `json:"windows"`
+	Streak          TaskStatsStreak    `json:"streak"`
+}
+
+// TaskStatsWindow counts the tasks created and completed in the last Days
+// days. CompletionRate is the share of the tasks created in the window that
+// are complete, 0 when none were created.
+type TaskStatsWindow struct {
+	Days           int     `json:"days"`
+	Created        int     `json:"created"`
+	Completed      int     `json:"completed"`
+	CompletionRate float64 `json:"completion_rate"`
+}
+
+// NewTaskStatsWindow computes the completion rate of the window from the
+// number of the tasks created in it that are complete.
+func NewTaskStatsWindow(days int, created int, createdComplete int, completed int) *TaskStatsWindow {
+	w := &TaskStatsWindow{Days: days, Created: created, Completed: completed}
+
+	if created > 0 {
+		w.CompletionRate = float64(createdComplete) / float64(created)
+	}
+
+	return w
+}
+
+// TaskStatsStreak counts the days in a row, in UTC, with at least one task
+// completed. The current streak ends today, or yesterday while no task has
+// been completed yet today.
+type TaskStatsStreak struct {
+	Current int `json:"current"`
+	Longest int `json:"longest"`
+}
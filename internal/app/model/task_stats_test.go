@@ -0,0 +1,28 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+)
+
+func TestTaskStatsQuery_Validation(t *testing.T) {
+	q := model.NewTaskStatsQuery(time.Now())
+	assert.NoError(t, q.Validation())
+
+	for _, windows := range [][]int{{}, {0}, {366}, {7, 7}, {1, 2, 3, 4, 5, 6}} {
+		q.Windows = windows
+		assert.Error(t, q.Validation(), windows)
+	}
+}
+
+func TestNewTaskStatsWindow(t *testing.T) {
+	w := model.NewTaskStatsWindow(7, 4, 1, 3)
+	assert.Equal(t, 0.25, w.CompletionRate)
+	assert.Equal(t, 3, w.Completed)
+
+	w = model.NewTaskStatsWindow(7, 0, 0, 2)
+	assert.Equal(t, 0.0, w.CompletionRate)
+}
@@ -0,0 +1,10 @@
+package model
+
+// TaskChanges are the changes of the tasks of a user since a sync token.
+// The sync token is the ID of the latest event in the history of the tasks
+// of the user. Only UserID, TaskID and ICalUID are set for deleted tasks.
+type TaskChanges struct {
+	Token   int
+	Changed []*Task
+	Deleted []*Task
+}
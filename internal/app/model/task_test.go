@@ -0,0 +1,173 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+)
+
+func TestTask_Validation(t *testing.T) {
+	testCases := []struct {
+		name    string
+		method  string
+		t       func() *model.Task
+		isValid bool
+	}{
+		{
+			name:   "valid",
+			method: "POST",
+			t: func() *model.Task {
+				return model.TestTask(t, 1)
+			},
+			isValid: true,
+		},
+		{
+			name:   "empty title",
+			method: "POST",
+			t: func() *model.Task {
+				task := model.TestTask(t, 1)
+				task.Title = new(string)
+				return task
+			},
+			isValid: false,
+		},
+		{
+			name:   "too deep",
+			method: "POST",
+			t: func() *model.Task {
+				task := model.TestTask(t, 1)
+				task.Depth = model.MaxTaskDepth + 1
+				return task
+			},
+			isValid: false,
+		},
+		{
+			name:   "invalid rrule",
+			method: "POST",
+			t: func() *model.Task {
+				task := model.TestTask(t, 1)
+				rule := "FREQ=HOURLY"
+				task.RRule = &rule
+				return task
+			},
+			isValid: false,
+		},
+		{
+			name:   "past deadline",
+			method: "PUT",
+			t: func() *model.Task {
+				task := model.TestTask(t, 1)
+				past := time.Now().Add(-time.Hour)
+				task.Deadline = &past
+				return task
+			},
+			isValid: false,
+		},
+		{
+			name:   "kept past deadline",
+			method: "PUT",
+			t: func() *model.Task {
+				task := model.TestTask(t, 1)
+				past := time.Now().Add(-time.Hour)
+				task.Deadline, task.PastDeadline = &past, true
+				return task
+			},
+			isValid: true,
+		},
+		{
+			name:   "complete subtasks without completing the task",
+			method: "PATCH",
+			t: func() *model.Task {
+				return &model.Task{CompleteSubtasks: true}
+			},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.t().Validation(tc.method))
+			} else {
+				assert.Error(t, tc.t().Validation(tc.method))
+			}
+		})
+	}
+}
+
+func TestNewTaskTree(t *testing.T) {
+	parent := 1
+	child := 2
+	tasks := []*model.Task{
+		{TaskID: 1},
+		{TaskID: 2, ParentTaskID: &parent},
+		{TaskID: 3, ParentTaskID: &child},
+		{TaskID: 4, ParentTaskID: &parent},
+	}
+
+	root := model.NewTaskTree(tasks, 1)
+
+	assert.Len(t, root.Children, 2)
+	assert.Equal(t, 3, root.Height())
+	assert.True(t, root.Contains(3))
+	assert.False(t, root.Children[1].Contains(3))
+}
+
+func TestTask_NextOccurrence(t *testing.T) {
+	now := time.Date(2025, 6, 4, 12, 0, 0, 0, time.UTC)
+
+	task := model.TestTask(t, 1)
+	deadline := time.Date(2025, 6, 2, 18, 0, 0, 0, time.UTC) // monday
+	rule := "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=5"
+	task.Deadline = &deadline
+	task.RRule = &rule
+	assert.NoError(t, task.BeforeCreate())
+
+	next, err := task.NextOccurrence(now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 6, 4, 18, 0, 0, 0, time.UTC), *next.Deadline)
+	assert.Equal(t, "FREQ=WEEKLY;COUNT=4;BYDAY=MO,WE", *next.RRule)
+	assert.Equal(t, task.SeriesID, next.SeriesID)
+	assert.False(t, *next.Complete)
+
+	// an overdue task skips the occurrences that have already passed
+	next, err = task.NextOccurrence(now.Add(7 * 24 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 6, 11, 18, 0, 0, 0, time.UTC), *next.Deadline)
+	assert.Equal(t, "FREQ=WEEKLY;COUNT=2;BYDAY=MO,WE", *next.RRule)
+
+	last := "FREQ=WEEKLY;COUNT=1"
+	task.RRule = &last
+	next, err = task.NextOccurrence(now)
+	assert.NoError(t, err)
+	assert.Nil(t, next)
+}
+
+func TestNewTaskEvent(t *testing.T) {
+	before := model.TestTask(t, 1)
+	before.TaskID = 1
+
+	e := model.NewTaskEvent(0, nil, before)
+	assert.Equal(t, model.TaskEventCreated, e.Type)
+	assert.Equal(t, 1, e.ActorID)
+	assert.Equal(t, *before.Title, e.Changes["title"].After)
+	assert.Nil(t, e.Changes["title"].Before)
+
+	after := *before
+	deadline := before.Deadline.Add(time.Hour)
+	after.Deadline = &deadline
+
+	e = model.NewTaskEvent(2, before, &after)
+	assert.Equal(t, model.TaskEventUpdated, e.Type)
+	assert.Equal(t, 2, e.ActorID)
+	assert.Len(t, e.Changes, 1)
+	assert.Equal(t, deadline.Format(time.RFC3339), e.Changes["deadline"].After)
+
+	assert.Nil(t, model.NewTaskEvent(2, before, before))
+
+	e = model.NewTaskEvent(1, before, nil)
+	assert.Equal(t, model.TaskEventDeleted, e.Type)
+	assert.Equal(t, *before.Title, e.Changes["title"].Before)
+}
@@ -0,0 +1,61 @@
+package model
+
+import "sort"
+
+// MaxTaskDepth limits how deep subtasks can be nested, a top-level task is
+// at level 1.
+const MaxTaskDepth = 10
+
+// Height returns the number of levels in the subtree of the task.
+func (t *Task) Height() int {
+	height := 0
+	for _, c := range t.Children {
+		if h := c.Height(); h > height {
+			height = h
+		}
+	}
+
+	return height + 1
+}
+
+// Contains reports whether the task with the given id is in the subtree.
+func (t *Task) Contains(taskID int) bool {
+	if t.TaskID == taskID {
+		return true
+	}
+
+	for _, c := range t.Children {
+		if c.Contains(taskID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewTaskTree nests the tasks under their parents and returns the task with
+// the given id. Children are ordered by deadline the same way the listing is.
+func NewTaskTree(tasks []*Task, rootID int) *Task {
+	byID := map[int]*Task{}
+	for _, t := range tasks {
+		byID[t.TaskID] = t
+	}
+
+	for _, t := range tasks {
+		if t.ParentTaskID == nil || t.TaskID == rootID {
+			continue
+		}
+		if parent, ok := byID[*t.ParentTaskID]; ok {
+			parent.Children = append(parent.Children, t)
+		}
+	}
+
+	q := NewTaskQuery()
+	for _, t := range tasks {
+		sort.Slice(t.Children, func(i, j int) bool {
+			return q.Less(t.Children[i], t.Children[j])
+		})
+	}
+
+	return byID[rootID]
+}
@@ -13,4 +13,18 @@ func TestUser(t *testing.T) *User {
 		RefreshToken: "very-secret-key",
 		RefreshTokenExpire: time.Now().Add(time.Hour),
 	}
-}
\ No newline at end of file
+}
+func TestTask(t *testing.T, userID int) *Task {
+	title := "task"
+	description := "description"
+	deadline := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	complete := false
+
+	return &Task{
+		UserID:      userID,
+		Title:       &title,
+		Description: &description,
+		Deadline:    &deadline,
+		Complete:    &complete,
+	}
+}
@@ -0,0 +1,129 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+const (
+	TimeReportByDay  = "day"
+	TimeReportByList = "list"
+	TimeReportByTag  = "tag"
+
+	maxTimeEntryNote = 1000
+	// maxTimeReportRange is the longest range of a time report.
+	maxTimeReportRange = 366 * 24 * time.Hour
+)
+
+// TimeEntry is time spent on a task. UserID is the owner of the task and
+// TrackerID the user who tracked the time, only the tracker edits the entry.
+// The entry of a running timer is not stopped yet. Duration is the tracked
+// time in seconds, up to now for a running timer.
+type TimeEntry struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	TaskID    int        `json:"task_id"`
+	TrackerID int        `json:"tracker_id"`
+	StartedAt time.Time  `json:"started_at"`
+	StoppedAt *time.Time `json:"stopped_at"`
+	Note      string     `json:"note"`
+	Duration  int64      `json:"duration"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Validation checks an entry created or edited by hand, such an entry has
+// both ends.
+func (e *TimeEntry) Validation() error {
+	return validation.ValidateStruct(
+		e,
+		validation.Field(&e.StartedAt, validation.Required),
+		validation.Field(&e.StoppedAt, validation.Required, validation.By(func(value interface{}) error {
+			if stoppedAt := value.(*time.Time); stoppedAt != nil && stoppedAt.Before(e.StartedAt) {
+				return errors.New("must not be before started_at")
+			}
+			return nil
+		})),
+		validation.Field(&e.Note, validation.Length(0, maxTimeEntryNote)),
+	)
+}
+
+// Running reports whether the entry is a timer that is not stopped yet.
+func (e *TimeEntry) Running() bool {
+	return e.StoppedAt == nil
+}
+
+// SetDuration computes the duration of the entry, a running timer runs
+// until now.
+func (e *TimeEntry) SetDuration(now time.Time) {
+	end := now
+	if e.StoppedAt != nil {
+		end = *e.StoppedAt
+	}
+
+	e.Duration = int64(end.Sub(e.StartedAt) / time.Second)
+	if e.Duration < 0 {
+		e.Duration = 0
+	}
+}
+
+// TimeReportQuery describes a report of the time tracked on the tasks of a
+// user from From up to To, grouped by GroupBy. An entry belongs to the range
+// it was started in.
+type TimeReportQuery struct {
+	From    time.Time
+	To      time.Time
+	GroupBy string
+}
+
+func (q *TimeReportQuery) Validation() error {
+	switch q.GroupBy {
+	case TimeReportByDay, TimeReportByList, TimeReportByTag:
+	default:
+		return fmt.Errorf("invalid group_by: use %s, %s or %s", TimeReportByDay, TimeReportByList, TimeReportByTag)
+	}
+
+	if !q.From.Before(q.To) {
+		return errors.New("from must be before to")
+	}
+
+	if q.To.Sub(q.From) > maxTimeReportRange {
+		return errors.New("the range of a report is at most 366 days")
+	}
+
+	return nil
+}
+
+// TimeReport is the time tracked in the range of the query. The group of a
+// row is a day formatted as YYYY-MM-DD, a list name or a tag name. The
+// tasks without a list or without tags are in the group with an empty name,
+// the time of a task with several tags counts in each of them. Duration is
+// the sum of the rows.
+type TimeReport struct {
+	From     time.Time        `json:"from"`
+	To       time.Time        `json:"to"`
+	GroupBy  string           `json:"group_by"`
+	Rows     []*TimeReportRow `json:"rows"`
+	Duration int64            `json:"duration"`
+}
+
+type TimeReportRow struct {
+	Group string `json:"group"`
+	// ListID tells apart the lists of the rows by list, lists may have the
+	// same name. It is nil for the tasks without a list.
+	ListID   *int  `json:"list_id,omitempty"`
+	Duration int64 `json:"duration"`
+}
+
+// NewTimeReport sums the durations of the rows, the rows keep their order.
+func NewTimeReport(q *TimeReportQuery, rows []*TimeReportRow) *TimeReport {
+	report := &TimeReport{From: q.From, To: q.To, GroupBy: q.GroupBy, Rows: rows}
+
+	for _, row := range rows {
+		report.Duration += row.Duration
+	}
+
+	return report
+}
@@ -0,0 +1,62 @@
+package model_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+)
+
+func TestTimeEntry_Validation(t *testing.T) {
+	start := time.Date(2025, 8, 24, 9, 0, 0, 0, time.UTC)
+	stop := start.Add(90 * time.Minute)
+
+	e := &model.TimeEntry{StartedAt: start, StoppedAt: &stop}
+	assert.NoError(t, e.Validation())
+
+	e.SetDuration(time.Now())
+	assert.Equal(t, int64(90*60), e.Duration)
+
+	e.Note = strings.Repeat("a", 1001)
+	assert.Error(t, e.Validation())
+
+	e.Note = ""
+	e.StoppedAt = nil
+	assert.Error(t, e.Validation())
+	assert.True(t, e.Running())
+
+	e.SetDuration(start.Add(time.Minute))
+	assert.Equal(t, int64(60), e.Duration)
+
+	before := start.Add(-time.Minute)
+	e.StoppedAt = &before
+	assert.Error(t, e.Validation())
+}
+
+func TestTimeReportQuery_Validation(t *testing.T) {
+	from := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name    string
+		q       *model.TimeReportQuery
+		isValid bool
+	}{
+		{name: "day", q: &model.TimeReportQuery{From: from, To: from.AddDate(0, 1, 0), GroupBy: model.TimeReportByDay}, isValid: true},
+		{name: "tag", q: &model.TimeReportQuery{From: from, To: from.AddDate(1, 0, 0), GroupBy: model.TimeReportByTag}, isValid: true},
+		{name: "unknown group", q: &model.TimeReportQuery{From: from, To: from.AddDate(0, 1, 0), GroupBy: "week"}, isValid: false},
+		{name: "empty range", q: &model.TimeReportQuery{From: from, To: from, GroupBy: model.TimeReportByList}, isValid: false},
+		{name: "long range", q: &model.TimeReportQuery{From: from, To: from.AddDate(2, 0, 0), GroupBy: model.TimeReportByList}, isValid: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.q.Validation())
+			} else {
+				assert.Error(t, tc.q.Validation())
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package model
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+const (
+	EventTaskCreated   = "task.created"
+	EventTaskUpdated   = "task.updated"
+	EventTaskDeleted   = "task.deleted"
+	EventTaskCompleted = "task.completed"
+)
+
+var webhookEvents = []interface{}{
+	EventTaskCreated,
+	EventTaskUpdated,
+	EventTaskDeleted,
+	EventTaskCompleted,
+}
+
+// Webhook subscribes a URL to the task events of a user. A webhook without
+// events receives all of them. Every payload is signed with the secret.
+type Webhook struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (w *Webhook) Validation() error {
+	return validation.ValidateStruct(
+		w,
+		validation.Field(&w.URL, validation.Required, validation.By(validateWebhookURL)),
+		validation.Field(&w.Secret, validation.Required, validation.Length(16, 128)),
+		validation.Field(&w.Events, validation.Each(validation.In(webhookEvents...))),
+	)
+}
+
+// BeforeCreate generates the secret of a webhook registered without one.
+func (w *Webhook) BeforeCreate() error {
+	if w.Secret != "" {
+		return nil
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+
+	w.Secret = fmt.Sprintf("%x", b)
+
+	return nil
+}
+
+// Subscribed reports whether the webhook receives the event.
+func (w *Webhook) Subscribed(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validateWebhookURL(value interface{}) error {
+	u, err := url.Parse(value.(string))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("must be an absolute http or https URL")
+	}
+
+	return nil
+}
+
+// reservedNetworks are the networks, besides the loopback, private and
+// link-local ones, that are not reachable on the internet.
+var reservedNetworks = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("100.64.0.0/10"),
+	mustParseCIDR("192.0.0.0/24"),
+	mustParseCIDR("198.18.0.0/15"),
+	mustParseCIDR("240.0.0.0/4"),
+	mustParseCIDR("64:ff9b::/96"),
+}
+
+func mustParseCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return n
+}
+
+// PublicIP reports whether webhooks may be delivered to the address, the
+// server must not post to its own host or to the internal network.
+func PublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+
+	for _, n := range reservedNetworks {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CheckTarget makes sure that the host of the URL only resolves to public
+// addresses. The dispatcher checks the addresses again when it connects,
+// the host may resolve to others by then.
+func (w *Webhook) CheckTarget(lookup func(host string) ([]net.IP, error)) error {
+	if err := validateWebhookURL(w.URL); err != nil {
+		return fmt.Errorf("url: %w", err)
+	}
+
+	u, _ := url.Parse(w.URL)
+
+	ips := []net.IP{net.ParseIP(u.Hostname())}
+	if ips[0] == nil {
+		var err error
+		if ips, err = lookup(u.Hostname()); err != nil {
+			return fmt.Errorf("url: the host %q cannot be resolved", u.Hostname())
+		}
+	}
+
+	for _, ip := range ips {
+		if !PublicIP(ip) {
+			return errors.New("url: must not point to a loopback, private or link-local address")
+		}
+	}
+
+	return nil
+}
+
+// WebhookEvent is the payload posted to the webhooks of a user.
+type WebhookEvent struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	UserID     int       `json:"user_id"`
+	TaskID     int       `json:"task_id"`
+	Task       *Task     `json:"task,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+func NewWebhookEvent(eventType string, userID int, taskID int, t *Task) *WebhookEvent {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	return &WebhookEvent{
+		ID:         fmt.Sprintf("%x", b),
+		Type:       eventType,
+		UserID:     userID,
+		TaskID:     taskID,
+		Task:       t,
+		OccurredAt: time.Now().UTC(),
+	}
+}
+
+// WebhookDelivery records one attempt to deliver an event to a webhook.
+type WebhookDelivery struct {
+	ID         int       `json:"id"`
+	WebhookID  int       `json:"webhook_id"`
+	EventID    string    `json:"event_id"`
+	Event      string    `json:"event"`
+	Attempt    int       `json:"attempt"`
+	StatusCode *int      `json:"status_code"`
+	Error      *string   `json:"error"`
+	Success    bool      `json:"success"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,224 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	StatusTodo = "todo"
+	StatusDone = "done"
+
+	maxWorkflowStatuses = 20
+	maxStatusNameLength = 50
+)
+
+// Workflow is the statuses the tasks of a list go through, in the order of
+// the columns of its board. A done status completes the task, the complete
+// flag of a task follows its status. Transitions lists the statuses a task
+// may move to from a status, the statuses left out of it are unrestricted.
+type Workflow struct {
+	Statuses    []*WorkflowStatus   `json:"statuses"`
+	Transitions map[string][]string `json:"transitions,omitempty"`
+}
+
+type WorkflowStatus struct {
+	Name string `json:"name"`
+	Done bool   `json:"done"`
+}
+
+// DefaultWorkflow is the workflow of the tasks without a list and of the
+// lists that have not set one: the open tasks are todo and the complete
+// ones done.
+func DefaultWorkflow() *Workflow {
+	return &Workflow{
+		Statuses: []*WorkflowStatus{
+			{Name: StatusTodo},
+			{Name: StatusDone, Done: true},
+		},
+	}
+}
+
+func (w *Workflow) Validation() error {
+	if len(w.Statuses) == 0 || len(w.Statuses) > maxWorkflowStatuses {
+		return fmt.Errorf("between 1 and %d statuses are allowed", maxWorkflowStatuses)
+	}
+
+	seen := map[string]bool{}
+	open, done := false, false
+
+	for _, s := range w.Statuses {
+		if s == nil {
+			return errors.New("status cannot be empty")
+		}
+
+		s.Name = strings.TrimSpace(s.Name)
+		if s.Name == "" || len(s.Name) > maxStatusNameLength {
+			return fmt.Errorf("a status name is between 1 and %d characters", maxStatusNameLength)
+		}
+
+		if seen[s.Name] {
+			return fmt.Errorf("status %q is listed twice", s.Name)
+		}
+		seen[s.Name] = true
+
+		if s.Done {
+			done = true
+		} else {
+			open = true
+		}
+	}
+
+	if !open || !done {
+		return errors.New("a workflow needs at least one open and one done status")
+	}
+
+	for from, to := range w.Transitions {
+		if !seen[from] {
+			return fmt.Errorf("transition from unknown status %q", from)
+		}
+
+		for _, name := range to {
+			if !seen[name] {
+				return fmt.Errorf("transition to unknown status %q", name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Status returns the status with the name, nil if the workflow has none.
+func (w *Workflow) Status(name string) *WorkflowStatus {
+	for _, s := range w.Statuses {
+		if s.Name == name {
+			return s
+		}
+	}
+
+	return nil
+}
+
+// DefaultStatus is the status of the tasks with the complete flag that have
+// not been given one: the first status that agrees with the flag.
+func (w *Workflow) DefaultStatus(complete bool) string {
+	for _, s := range w.Statuses {
+		if s.Done == complete {
+			return s.Name
+		}
+	}
+
+	if complete {
+		return StatusDone
+	}
+
+	return StatusTodo
+}
+
+// CanTransition reports whether a task may move from a status to another.
+func (w *Workflow) CanTransition(from string, to string) error {
+	allowed, ok := w.Transitions[from]
+	if !ok || from == to {
+		return nil
+	}
+
+	for _, name := range allowed {
+		if name == to {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("a task cannot move from %q to %q", from, to)
+}
+
+// Apply sets the status and the complete flag of the task that is written
+// over before, nil for a new task. A given status sets the flag, a given
+// flag alone keeps the status when it agrees with the flag and otherwise
+// takes the default status. A task moved from a list whose status the
+// workflow does not have takes the default status as well. Both are left
+// unset when the write does not touch them.
+func (w *Workflow) Apply(t *Task, before *Task) error {
+	from := ""
+	if before != nil && before.Status != nil && w.Status(*before.Status) != nil {
+		from = *before.Status
+	}
+
+	var to string
+
+	switch {
+	case t.Status != nil:
+		s := w.Status(strings.TrimSpace(*t.Status))
+		if s == nil {
+			return fmt.Errorf("unknown status %q", *t.Status)
+		}
+
+		if t.Complete != nil && *t.Complete != s.Done {
+			return fmt.Errorf("complete does not agree with status %q", s.Name)
+		}
+
+		to = s.Name
+	case t.Complete != nil:
+		to = w.DefaultStatus(*t.Complete)
+		if from != "" && w.Status(from).Done == *t.Complete {
+			to = from
+		}
+	case before == nil:
+		to = w.DefaultStatus(false)
+	case from == "":
+		to = w.DefaultStatus(before.Complete != nil && *before.Complete)
+	default:
+		return nil
+	}
+
+	if from != "" {
+		if err := w.CanTransition(from, to); err != nil {
+			return err
+		}
+	}
+
+	done := w.Status(to).Done
+	t.Status, t.Complete = &to, &done
+
+	return nil
+}
+
+// Board is the tasks of a list grouped by their status, the columns are in
+// the order of the workflow of the list.
+type Board struct {
+	ListID  int            `json:"list_id"`
+	Columns []*BoardColumn `json:"columns"`
+}
+
+type BoardColumn struct {
+	Status string  `json:"status"`
+	Done   bool    `json:"done"`
+	Tasks  []*Task `json:"tasks"`
+}
+
+// NewBoard places the tasks in the columns of their status, a task with a
+// status the workflow does not have is placed in the column of the default
+// status.
+func NewBoard(listID int, w *Workflow, tasks []*Task) *Board {
+	b := &Board{ListID: listID, Columns: make([]*BoardColumn, 0, len(w.Statuses))}
+	columns := map[string]*BoardColumn{}
+
+	for _, s := range w.Statuses {
+		c := &BoardColumn{Status: s.Name, Done: s.Done, Tasks: []*Task{}}
+		b.Columns = append(b.Columns, c)
+		columns[s.Name] = c
+	}
+
+	for _, t := range tasks {
+		var c *BoardColumn
+		if t.Status != nil {
+			c = columns[*t.Status]
+		}
+		if c == nil {
+			c = columns[w.DefaultStatus(t.Complete != nil && *t.Complete)]
+		}
+		c.Tasks = append(c.Tasks, t)
+	}
+
+	return b
+}
@@ -0,0 +1,119 @@
+package model_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+)
+
+func kanban() *model.Workflow {
+	return &model.Workflow{
+		Statuses: []*model.WorkflowStatus{
+			{Name: "todo"},
+			{Name: "in progress"},
+			{Name: "review"},
+			{Name: "done", Done: true},
+		},
+		Transitions: map[string][]string{
+			"todo":        {"in progress"},
+			"in progress": {"todo", "review"},
+		},
+	}
+}
+
+func TestWorkflow_Validation(t *testing.T) {
+	assert.NoError(t, model.DefaultWorkflow().Validation())
+	assert.NoError(t, kanban().Validation())
+
+	testCases := []struct {
+		name   string
+		modify func(*model.Workflow)
+	}{
+		{"no statuses", func(w *model.Workflow) { w.Statuses = nil }},
+		{"empty name", func(w *model.Workflow) { w.Statuses[1].Name = " " }},
+		{"long name", func(w *model.Workflow) { w.Statuses[1].Name = strings.Repeat("a", 51) }},
+		{"same name", func(w *model.Workflow) { w.Statuses[1].Name = "todo" }},
+		{"no done status", func(w *model.Workflow) { w.Statuses[3].Done = false }},
+		{"no open status", func(w *model.Workflow) { w.Statuses = w.Statuses[3:] }},
+		{"unknown from", func(w *model.Workflow) { w.Transitions["blocked"] = []string{"todo"} }},
+		{"unknown to", func(w *model.Workflow) { w.Transitions["todo"] = []string{"blocked"} }},
+	}
+
+	for _, tc := range testCases {
+		w := kanban()
+		tc.modify(w)
+		assert.Error(t, w.Validation(), tc.name)
+	}
+}
+
+func TestWorkflow_Apply(t *testing.T) {
+	w := kanban()
+	status := func(s string) *string { return &s }
+	flag := func(b bool) *bool { return &b }
+
+	// a new task starts in the first open status
+	task := &model.Task{}
+	assert.NoError(t, w.Apply(task, nil))
+	assert.Equal(t, "todo", *task.Status)
+	assert.False(t, *task.Complete)
+
+	before := &model.Task{Status: status("in progress"), Complete: flag(false)}
+
+	// the status sets the complete flag
+	task = &model.Task{Status: status("review")}
+	assert.NoError(t, w.Apply(task, before))
+	assert.False(t, *task.Complete)
+
+	// the status is kept while it agrees with the flag
+	task = &model.Task{Complete: flag(false)}
+	assert.NoError(t, w.Apply(task, before))
+	assert.Equal(t, "in progress", *task.Status)
+
+	// a write that does not touch the status leaves it alone
+	task = &model.Task{}
+	assert.NoError(t, w.Apply(task, before))
+	assert.Nil(t, task.Status)
+	assert.Nil(t, task.Complete)
+
+	// restricted transitions
+	assert.Error(t, w.Apply(&model.Task{Status: status("done")}, before))
+	assert.Error(t, w.Apply(&model.Task{Complete: flag(true)}, before))
+	assert.NoError(t, w.Apply(&model.Task{Status: status("done")}, &model.Task{Status: status("review")}))
+
+	assert.Error(t, w.Apply(&model.Task{Status: status("blocked")}, before))
+	assert.Error(t, w.Apply(&model.Task{Status: status("done"), Complete: flag(false)}, nil))
+
+	// a task from another workflow takes the default status of its flag
+	task = &model.Task{}
+	assert.NoError(t, w.Apply(task, &model.Task{Status: status("closed"), Complete: flag(true)}))
+	assert.Equal(t, "done", *task.Status)
+	assert.True(t, *task.Complete)
+}
+
+func TestNewBoard(t *testing.T) {
+	status := func(s string) *string { return &s }
+	complete := true
+
+	tasks := []*model.Task{
+		{TaskID: 1, Status: status("review")},
+		{TaskID: 2, Status: status("todo")},
+		{TaskID: 3, Status: status("closed"), Complete: &complete},
+		{TaskID: 4},
+	}
+
+	b := model.NewBoard(1, kanban(), tasks)
+	assert.Len(t, b.Columns, 4)
+
+	ids := map[string][]int{}
+	for _, c := range b.Columns {
+		for _, task := range c.Tasks {
+			ids[c.Status] = append(ids[c.Status], task.TaskID)
+		}
+	}
+
+	assert.Equal(t, map[string][]int{"todo": {2, 4}, "review": {1}, "done": {3}}, ids)
+	assert.Equal(t, "in progress", b.Columns[1].Status)
+	assert.Empty(t, b.Columns[1].Tasks)
+}
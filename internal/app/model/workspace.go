@@ -0,0 +1,50 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// PersonalWorkspaceID is the workspace of the tasks and lists that a user
+// keeps for themselves. It has no members and is not stored, its tasks and
+// lists have no workspace_id.
+const PersonalWorkspaceID = 0
+
+// Workspace separates the tasks and lists of a team from the ones of other
+// teams. The members act on the tasks and lists of each other in the
+// workspace with the role of their membership, see the roles of Share.
+// Role is the role of the user the workspace is returned to.
+type Workspace struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedBy int       `json:"created_by"`
+	Role      string    `json:"role,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (w *Workspace) Validation() error {
+	w.Name = strings.TrimSpace(w.Name)
+
+	return validation.ValidateStruct(
+		w,
+		validation.Field(&w.Name, validation.Required, validation.Length(1, 100)),
+	)
+}
+
+// WorkspaceMember is the membership of a user in a workspace.
+type WorkspaceMember struct {
+	WorkspaceID int       `json:"workspace_id"`
+	UserID      int       `json:"user_id"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (m *WorkspaceMember) Validation() error {
+	return validation.ValidateStruct(
+		m,
+		validation.Field(&m.Role, validation.Required, validation.In(RoleViewer, RoleEditor, RoleOwner)),
+	)
+}
@@ -0,0 +1,491 @@
+// Package rrule parses and expands the subset of RFC 5545 recurrence rules
+// used by recurring tasks: FREQ, INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY,
+// BYMONTH and WKST.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxPeriods stops the expansion of rules whose filters never match, such
+// as FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30.
+const maxPeriods = 10000
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// WeekdayNum is an entry of BYDAY. N selects the n-th weekday of the month,
+// counting from the end when negative; zero selects every such weekday.
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      *time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	WeekStart  time.Weekday
+}
+
+// Parse reads a rule such as "FREQ=WEEKLY;BYDAY=MO,WE", the "RRULE:" prefix
+// is optional.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, errors.New("rrule: empty rule")
+	}
+
+	r := &Rule{Interval: 1, WeekStart: time.Monday}
+	seen := map[string]bool{}
+
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || value == "" {
+			return nil, fmt.Errorf("rrule: invalid part %q", part)
+		}
+
+		if seen[name] {
+			return nil, fmt.Errorf("rrule: %s is given more than once", name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			r.Freq = Frequency(value)
+			if r.Freq != Daily && r.Freq != Weekly && r.Freq != Monthly && r.Freq != Yearly {
+				err = fmt.Errorf("rrule: unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = parsePositive(name, value)
+		case "COUNT":
+			r.Count, err = parsePositive(name, value)
+		case "UNTIL":
+			r.Until, err = parseUntil(value)
+		case "BYDAY":
+			r.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseIntList(name, value, -31, 31)
+		case "BYMONTH":
+			var months []int
+			months, err = parseIntList(name, value, 1, 12)
+			for _, m := range months {
+				r.ByMonth = append(r.ByMonth, time.Month(m))
+			}
+		case "WKST":
+			wd, ok := weekdays[value]
+			if !ok {
+				err = fmt.Errorf("rrule: invalid WKST %q", value)
+			}
+			r.WeekStart = wd
+		default:
+			err = fmt.Errorf("rrule: unsupported rule part %s", name)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *Rule) validate() error {
+	if r.Freq == "" {
+		return errors.New("rrule: FREQ is required")
+	}
+
+	if r.Count > 0 && r.Until != nil {
+		return errors.New("rrule: COUNT and UNTIL cannot be used together")
+	}
+
+	if r.Freq == Weekly && len(r.ByMonthDay) > 0 {
+		return errors.New("rrule: BYMONTHDAY cannot be used with FREQ=WEEKLY")
+	}
+
+	for _, d := range r.ByDay {
+		if d.N == 0 {
+			continue
+		}
+		if r.Freq != Monthly && r.Freq != Yearly {
+			return errors.New("rrule: numbered BYDAY is only allowed with FREQ=MONTHLY or FREQ=YEARLY")
+		}
+		if r.Freq == Yearly && len(r.ByMonth) == 0 {
+			return errors.New("rrule: numbered BYDAY with FREQ=YEARLY requires BYMONTH")
+		}
+	}
+
+	return nil
+}
+
+// String returns the rule in its canonical form.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+
+	if r.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Count))
+	}
+
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+
+	if len(r.ByMonth) > 0 {
+		months := []string{}
+		for _, m := range r.ByMonth {
+			months = append(months, strconv.Itoa(int(m)))
+		}
+		parts = append(parts, "BYMONTH="+strings.Join(months, ","))
+	}
+
+	if len(r.ByMonthDay) > 0 {
+		days := []string{}
+		for _, d := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(d))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+
+	if len(r.ByDay) > 0 {
+		days := []string{}
+		for _, d := range r.ByDay {
+			day := weekdayName(d.Weekday)
+			if d.N != 0 {
+				day = strconv.Itoa(d.N) + day
+			}
+			days = append(days, day)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayName(r.WeekStart))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Iterator returns the occurrences of the rule anchored at dtstart. The
+// first occurrence is always dtstart itself, as RFC 5545 requires.
+func (r *Rule) Iterator(dtstart time.Time) *Iterator {
+	return &Iterator{rule: r, dtstart: dtstart}
+}
+
+// All returns at most limit first occurrences of the rule.
+func (r *Rule) All(dtstart time.Time, limit int) []time.Time {
+	occurrences := []time.Time{}
+
+	it := r.Iterator(dtstart)
+	for len(occurrences) < limit {
+		t, ok := it.Next()
+		if !ok {
+			break
+		}
+		occurrences = append(occurrences, t)
+	}
+
+	return occurrences
+}
+
+type Iterator struct {
+	rule    *Rule
+	dtstart time.Time
+	period  int
+	pending []time.Time
+	emitted int
+}
+
+// Next returns the next occurrence, ok is false once the rule is exhausted.
+func (it *Iterator) Next() (time.Time, bool) {
+	r := it.rule
+
+	if r.Count > 0 && it.emitted >= r.Count {
+		return time.Time{}, false
+	}
+
+	var next time.Time
+
+	if it.emitted == 0 {
+		next = it.dtstart
+	} else {
+		for len(it.pending) == 0 {
+			if it.period >= maxPeriods {
+				return time.Time{}, false
+			}
+
+			for _, t := range r.candidates(it.dtstart, it.period) {
+				if t.After(it.dtstart) {
+					it.pending = append(it.pending, t)
+				}
+			}
+			it.period++
+		}
+
+		next, it.pending = it.pending[0], it.pending[1:]
+	}
+
+	if r.Until != nil && next.After(*r.Until) {
+		return time.Time{}, false
+	}
+
+	it.emitted++
+
+	return next, true
+}
+
+// candidates returns the sorted occurrences within the n-th period after the
+// one of dtstart.
+func (r *Rule) candidates(dtstart time.Time, n int) []time.Time {
+	step := n * r.Interval
+	y, m, d := dtstart.Date()
+	h, min, sec := dtstart.Clock()
+	loc := dtstart.Location()
+
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, h, min, sec, 0, loc)
+	}
+
+	days := []time.Time{}
+
+	switch r.Freq {
+	case Daily:
+		day := at(y, m, d+step)
+		if r.matchMonth(day.Month()) && r.matchMonthDay(day) && r.matchWeekday(day) {
+			days = append(days, day)
+		}
+	case Weekly:
+		offset := (int(dtstart.Weekday()) - int(r.WeekStart) + 7) % 7
+		weekStart := at(y, m, d-offset+7*step)
+		for i := 0; i < 7; i++ {
+			day := at(weekStart.Year(), weekStart.Month(), weekStart.Day()+i)
+			if len(r.ByDay) == 0 && day.Weekday() != dtstart.Weekday() {
+				continue
+			}
+			if r.matchMonth(day.Month()) && r.matchWeekday(day) {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		first := at(y, m+time.Month(step), 1)
+		if r.matchMonth(first.Month()) {
+			days = r.monthDays(first, d)
+		}
+	case Yearly:
+		months := r.ByMonth
+		if len(months) == 0 {
+			if len(r.ByDay) > 0 || len(r.ByMonthDay) > 0 {
+				months = []time.Month{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+			} else {
+				months = []time.Month{m}
+			}
+		}
+		for _, month := range months {
+			days = append(days, r.monthDays(at(y+step, month, 1), d)...)
+		}
+	}
+
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Before(days[j])
+	})
+
+	return days
+}
+
+// monthDays expands BYMONTHDAY and BYDAY within the month that starts at
+// first. Without either of them the day of dtstart is used, months that are
+// too short for it are skipped.
+func (r *Rule) monthDays(first time.Time, dtstartDay int) []time.Time {
+	length := first.AddDate(0, 1, -1).Day()
+	days := []time.Time{}
+
+	for day := 1; day <= length; day++ {
+		t := first.AddDate(0, 0, day-1)
+
+		switch {
+		case len(r.ByMonthDay) == 0 && len(r.ByDay) == 0:
+			if day != dtstartDay {
+				continue
+			}
+		case !r.matchMonthDay(t):
+			continue
+		}
+
+		if len(r.ByDay) > 0 && !r.matchNumberedWeekday(t, length) {
+			continue
+		}
+
+		days = append(days, t)
+	}
+
+	return days
+}
+
+func (r *Rule) matchMonth(m time.Month) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+
+	for _, month := range r.ByMonth {
+		if month == m {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Rule) matchMonthDay(t time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+
+	length := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+
+	for _, d := range r.ByMonthDay {
+		if d == t.Day() || (d < 0 && length+d+1 == t.Day()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Rule) matchWeekday(t time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+
+	for _, d := range r.ByDay {
+		if d.Weekday == t.Weekday() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Rule) matchNumberedWeekday(t time.Time, monthLength int) bool {
+	fromStart := (t.Day()-1)/7 + 1
+	fromEnd := -((monthLength-t.Day())/7 + 1)
+
+	for _, d := range r.ByDay {
+		if d.Weekday != t.Weekday() {
+			continue
+		}
+		if d.N == 0 || d.N == fromStart || d.N == fromEnd {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parsePositive(name, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("rrule: %s must be a positive number", name)
+	}
+
+	return n, nil
+}
+
+func parseUntil(value string) (*time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if layout == "20060102" {
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return &t, nil
+		}
+	}
+
+	return nil, fmt.Errorf("rrule: invalid UNTIL %q", value)
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	days := []WeekdayNum{}
+
+	for _, item := range strings.Split(value, ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("rrule: invalid BYDAY %q", item)
+		}
+
+		wd, ok := weekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("rrule: invalid BYDAY %q", item)
+		}
+
+		d := WeekdayNum{Weekday: wd}
+		if prefix := item[:len(item)-2]; prefix != "" {
+			n, err := strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, fmt.Errorf("rrule: invalid BYDAY %q", item)
+			}
+			d.N = n
+		}
+
+		days = append(days, d)
+	}
+
+	return days, nil
+}
+
+func parseIntList(name, value string, min, max int) ([]int, error) {
+	list := []int{}
+
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(item)
+		if err != nil || n == 0 || n < min || n > max {
+			return nil, fmt.Errorf("rrule: invalid %s %q", name, item)
+		}
+		list = append(list, n)
+	}
+
+	return list, nil
+}
+
+func weekdayName(wd time.Weekday) string {
+	for name, d := range weekdays {
+		if d == wd {
+			return name
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,160 @@
+package rrule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/rrule"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rule     string
+		expected string
+		isValid  bool
+	}{
+		{
+			name:     "weekly",
+			rule:     "FREQ=WEEKLY;BYDAY=MO,WE",
+			expected: "FREQ=WEEKLY;BYDAY=MO,WE",
+			isValid:  true,
+		},
+		{
+			name:     "with prefix and lower case",
+			rule:     "RRULE:freq=monthly;bymonthday=-1;count=3",
+			expected: "FREQ=MONTHLY;COUNT=3;BYMONTHDAY=-1",
+			isValid:  true,
+		},
+		{
+			name:     "numbered weekday",
+			rule:     "FREQ=MONTHLY;BYDAY=-1FR;INTERVAL=2",
+			expected: "FREQ=MONTHLY;INTERVAL=2;BYDAY=-1FR",
+			isValid:  true,
+		},
+		{
+			name:    "without frequency",
+			rule:    "BYDAY=MO",
+			isValid: false,
+		},
+		{
+			name:    "unknown frequency",
+			rule:    "FREQ=HOURLY",
+			isValid: false,
+		},
+		{
+			name:    "unsupported part",
+			rule:    "FREQ=DAILY;BYHOUR=10",
+			isValid: false,
+		},
+		{
+			name:    "count and until",
+			rule:    "FREQ=DAILY;COUNT=2;UNTIL=20300101",
+			isValid: false,
+		},
+		{
+			name:    "numbered weekday in weekly rule",
+			rule:    "FREQ=WEEKLY;BYDAY=1MO",
+			isValid: false,
+		},
+		{
+			name:    "invalid weekday",
+			rule:    "FREQ=WEEKLY;BYDAY=XX",
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := rrule.Parse(tc.rule)
+			if !tc.isValid {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, r.String())
+		})
+	}
+}
+
+func TestRule_All(t *testing.T) {
+	date := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02 15:04", s)
+		return d
+	}
+
+	testCases := []struct {
+		name     string
+		rule     string
+		dtstart  string
+		expected []string
+	}{
+		{
+			name:     "daily with interval",
+			rule:     "FREQ=DAILY;INTERVAL=2",
+			dtstart:  "2025-01-30 09:00",
+			expected: []string{"2025-01-30 09:00", "2025-02-01 09:00", "2025-02-03 09:00"},
+		},
+		{
+			name:     "weekly on monday and wednesday",
+			rule:     "FREQ=WEEKLY;BYDAY=MO,WE",
+			dtstart:  "2025-06-04 18:00", // wednesday
+			expected: []string{"2025-06-04 18:00", "2025-06-09 18:00", "2025-06-11 18:00", "2025-06-16 18:00"},
+		},
+		{
+			name:     "monthly skips short months",
+			rule:     "FREQ=MONTHLY",
+			dtstart:  "2025-01-31 10:00",
+			expected: []string{"2025-01-31 10:00", "2025-03-31 10:00", "2025-05-31 10:00"},
+		},
+		{
+			name:     "last day of the month",
+			rule:     "FREQ=MONTHLY;BYMONTHDAY=-1",
+			dtstart:  "2025-01-31 10:00",
+			expected: []string{"2025-01-31 10:00", "2025-02-28 10:00", "2025-03-31 10:00"},
+		},
+		{
+			name:     "last friday of the month",
+			rule:     "FREQ=MONTHLY;BYDAY=-1FR",
+			dtstart:  "2025-05-30 17:00",
+			expected: []string{"2025-05-30 17:00", "2025-06-27 17:00", "2025-07-25 17:00"},
+		},
+		{
+			name:     "yearly",
+			rule:     "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29",
+			dtstart:  "2024-02-29 08:00",
+			expected: []string{"2024-02-29 08:00", "2028-02-29 08:00"},
+		},
+		{
+			name:     "count",
+			rule:     "FREQ=DAILY;COUNT=2",
+			dtstart:  "2025-01-01 08:00",
+			expected: []string{"2025-01-01 08:00", "2025-01-02 08:00"},
+		},
+		{
+			name:     "until",
+			rule:     "FREQ=WEEKLY;UNTIL=20250115",
+			dtstart:  "2025-01-01 08:00",
+			expected: []string{"2025-01-01 08:00", "2025-01-08 08:00", "2025-01-15 08:00"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := rrule.Parse(tc.rule)
+			assert.NoError(t, err)
+
+			occurrences := []string{}
+			for _, o := range r.All(date(tc.dtstart), len(tc.expected)+1) {
+				occurrences = append(occurrences, o.Format("2006-01-02 15:04"))
+			}
+
+			if len(occurrences) > len(tc.expected) && r.Count == 0 && r.Until == nil {
+				occurrences = occurrences[:len(tc.expected)]
+			}
+			assert.Equal(t, tc.expected, occurrences)
+		})
+	}
+}
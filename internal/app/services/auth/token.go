@@ -9,7 +9,8 @@ import (
 )
 
 type tokenClaims struct {
-	UserID int `json:"user_id"`
+	UserID      int `json:"user_id"`
+	WorkspaceID int `json:"workspace_id,omitempty"`
 	jwt.StandardClaims
 }
 
@@ -22,8 +23,15 @@ func NewTokenService(secret []byte) *tokenService {
 }
 
 func (s *tokenService) GenerateAccessToken(id int) (string, error) {
+	return s.GenerateWorkspaceAccessToken(id, 0)
+}
+
+// GenerateWorkspaceAccessToken generates an access token with the workspace
+// as the active one, 0 is the personal workspace.
+func (s *tokenService) GenerateWorkspaceAccessToken(id int, workspaceID int) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &tokenClaims{
 		UserID: id,
+		WorkspaceID: workspaceID,
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: time.Now().Add(15 * time.Minute).Unix(),
 			IssuedAt: time.Now().Unix(),
@@ -0,0 +1,35 @@
+package blobstore_test
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/services/blobstore"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	s := blobstore.NewFileStore(t.TempDir() + "/blobs")
+
+	assert.NoError(t, s.Put(ctx, "a1", strings.NewReader("hello")))
+
+	rc, err := s.Get(ctx, "a1")
+	assert.NoError(t, err)
+	b, _ := io.ReadAll(rc)
+	rc.Close()
+	assert.Equal(t, "hello", string(b))
+
+	assert.NoError(t, s.Delete(ctx, "a1"))
+	assert.NoError(t, s.Delete(ctx, "a1"))
+
+	_, err = s.Get(ctx, "a1")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	for _, key := range []string{"", "../a1", "dir/a1", ".upload-1"} {
+		assert.Error(t, s.Put(ctx, key, strings.NewReader("hello")), key)
+	}
+}
@@ -0,0 +1,201 @@
+package dispatcher
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+
+	queueSize = 1000
+)
+
+// Dispatcher delivers task events to the webhooks of their user. Events
+// are queued in memory and posted by Run, a failed delivery is retried with
+// an exponential backoff and every attempt is recorded in the store.
+type Dispatcher struct {
+	store       store.Store
+	client      *http.Client
+	log         *slog.Logger
+	maxAttempts int
+	backoff     time.Duration
+	queue       chan *model.WebhookEvent
+}
+
+// New creates a dispatcher that makes at most maxAttempts attempts per
+// delivery, waiting backoff, 2*backoff, 4*backoff... between them. Unless
+// allowPrivate is set, it refuses to connect to loopback, private and
+// link-local addresses, whatever the host of the webhook resolves to.
+func New(store store.Store, maxAttempts int, backoff time.Duration, allowPrivate bool, log *slog.Logger) *Dispatcher {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !allowPrivate {
+		dialer.Control = checkAddress
+	}
+
+	// the deliveries are not sent through a proxy, the dialer could not
+	// check their targets
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Dispatcher{
+		store:       store,
+		client:      &http.Client{Timeout: 10 * time.Second, Transport: transport},
+		log:         log,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		queue:       make(chan *model.WebhookEvent, queueSize),
+	}
+}
+
+// Publish queues the event. The event is dropped when the queue is full.
+func (d *Dispatcher) Publish(e *model.WebhookEvent) {
+	select {
+	case d.queue <- e:
+	default:
+		d.log.Warn("webhook queue is full, event dropped", slog.String("event_id", e.ID), slog.String("event", e.Type))
+	}
+}
+
+// Run delivers the queued events until the context is canceled, then waits
+// for the deliveries in progress. A delivery waiting for its next attempt
+// is abandoned.
+func (d *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-d.queue:
+			webhooks, err := d.store.Webhook().FindByEvent(e.UserID, e.Type)
+			if err != nil {
+				d.log.Error("failed to find webhooks", slog.String("error", err.Error()))
+				continue
+			}
+
+			body, err := json.Marshal(e)
+			if err != nil {
+				d.log.Error("failed to encode webhook event", slog.String("error", err.Error()))
+				continue
+			}
+
+			for _, w := range webhooks {
+				wg.Add(1)
+				go func(w *model.Webhook) {
+					defer wg.Done()
+					d.deliver(ctx, w, e, body)
+				}(w)
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, w *model.Webhook, e *model.WebhookEvent, body []byte) {
+	delay := d.backoff
+
+	for attempt := 1; ; attempt++ {
+		delivery := &model.WebhookDelivery{
+			WebhookID: w.ID,
+			EventID:   e.ID,
+			Event:     e.Type,
+			Attempt:   attempt,
+		}
+
+		// an attempt in progress is finished on shutdown
+		code, err := d.post(context.WithoutCancel(ctx), w, e, body)
+		if code != 0 {
+			delivery.StatusCode = &code
+		}
+		if err != nil {
+			msg := err.Error()
+			delivery.Error = &msg
+		}
+		delivery.Success = err == nil
+
+		if err := d.store.Webhook().CreateDelivery(delivery); err != nil {
+			d.log.Error("failed to record webhook delivery", slog.String("error", err.Error()))
+		}
+
+		if delivery.Success || attempt == d.maxAttempts {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func (d *Dispatcher) post(ctx context.Context, w *model.Webhook, e *model.WebhookEvent, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, e.Type)
+	req.Header.Set(DeliveryHeader, e.ID)
+	req.Header.Set(SignatureHeader, Sign(w.Secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// checkAddress refuses the connections to the addresses webhooks must not
+// reach, it runs after the host has been resolved.
+func checkAddress(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !model.PublicIP(ip) {
+		return fmt.Errorf("webhook address %s is not public", host)
+	}
+
+	return nil
+}
+
+// Sign returns the value of the signature header for the payload, the
+// hex-encoded HMAC-SHA256 of the body keyed with the webhook secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,131 @@
+package dispatcher_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/services/dispatcher"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/teststore"
+)
+
+func TestDispatcher(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []*model.WebhookEvent
+		calls    int
+	)
+
+	secret := "0123456789abcdef0123456789abcdef"
+	done := make(chan struct{})
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		calls++
+		// the first attempt fails and is retried
+		if calls == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		assert.Equal(t, dispatcher.Sign(secret, body), r.Header.Get(dispatcher.SignatureHeader))
+		assert.Equal(t, model.EventTaskCreated, r.Header.Get(dispatcher.EventHeader))
+
+		e := &model.WebhookEvent{}
+		json.Unmarshal(body, e)
+		received = append(received, e)
+		close(done)
+	}))
+	defer receiver.Close()
+
+	s := teststore.New()
+	wh := &model.Webhook{UserID: 1, URL: receiver.URL, Secret: secret, Events: []string{model.EventTaskCreated}}
+	assert.NoError(t, s.Webhook().Create(wh))
+
+	d := dispatcher.New(s, 3, time.Millisecond, true, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(stopped)
+	}()
+
+	title := "write tests"
+	d.Publish(model.NewWebhookEvent(model.EventTaskDeleted, 1, 1, nil))
+	d.Publish(model.NewWebhookEvent(model.EventTaskCreated, 1, 1, &model.Task{UserID: 1, TaskID: 1, Title: &title}))
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not delivered")
+	}
+
+	cancel()
+	<-stopped
+
+	mu.Lock()
+	assert.Len(t, received, 1)
+	assert.Equal(t, "write tests", *received[0].Task.Title)
+	mu.Unlock()
+
+	deliveries, err := s.Webhook().FindDeliveries(1, wh.ID)
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 2)
+	assert.True(t, deliveries[0].Success)
+	assert.Equal(t, 2, deliveries[0].Attempt)
+	assert.False(t, deliveries[1].Success)
+	assert.Equal(t, http.StatusInternalServerError, *deliveries[1].StatusCode)
+}
+
+func TestDispatcher_PrivateTarget(t *testing.T) {
+	received := make(chan struct{}, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+	}))
+	defer receiver.Close()
+
+	s := teststore.New()
+	wh := &model.Webhook{UserID: 1, URL: receiver.URL, Secret: "0123456789abcdef0123456789abcdef"}
+	assert.NoError(t, s.Webhook().Create(wh))
+
+	d := dispatcher.New(s, 1, time.Millisecond, false, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+
+	d.Publish(model.NewWebhookEvent(model.EventTaskDeleted, 1, 1, nil))
+
+	// the receiver listens on the loopback, the dispatcher refuses to
+	// connect to it
+	var deliveries []*model.WebhookDelivery
+	for i := 0; i < 500 && len(deliveries) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+		deliveries, _ = s.Webhook().FindDeliveries(1, wh.ID)
+	}
+
+	assert.Len(t, deliveries, 1)
+	assert.False(t, deliveries[0].Success)
+	assert.Nil(t, deliveries[0].StatusCode)
+	assert.NotNil(t, deliveries[0].Error)
+	assert.Len(t, received, 0)
+}
+
+func TestSign(t *testing.T) {
+	assert.Equal(
+		t,
+		"sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8",
+		dispatcher.Sign("key", []byte("The quick brown fox jumps over the lazy dog")),
+	)
+}
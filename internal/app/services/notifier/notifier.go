@@ -0,0 +1,124 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+)
+
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier posts every reminder as JSON to the given URL.
+func NewWebhookNotifier(url string, client *http.Client) *webhookNotifier {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &webhookNotifier{url: url, client: client}
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, r *model.Reminder) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+type logNotifier struct {
+	log *slog.Logger
+}
+
+// NewLogNotifier writes every reminder to the log.
+func NewLogNotifier(log *slog.Logger) *logNotifier {
+	return &logNotifier{log: log}
+}
+
+func (n *logNotifier) Notify(ctx context.Context, r *model.Reminder) error {
+	attrs := []any{
+		slog.Int("user_id", r.UserID),
+		slog.Int("task_id", r.TaskID),
+		slog.String("offset", r.Offset.String()),
+	}
+
+	if r.Task != nil && r.Task.Title != nil {
+		attrs = append(attrs, slog.String("title", *r.Task.Title))
+	}
+
+	if r.Task != nil && r.Task.Deadline != nil {
+		attrs = append(attrs, slog.Time("deadline", *r.Task.Deadline))
+	}
+
+	n.log.InfoContext(ctx, "task reminder", attrs...)
+
+	return nil
+}
+
+type outboxNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewOutboxNotifier appends every reminder as a line of JSON to a local
+// file, so that the delivered reminders can be inspected in tests and
+// during development.
+func NewOutboxNotifier(path string) *outboxNotifier {
+	return &outboxNotifier{path: path}
+}
+
+func (n *outboxNotifier) Notify(ctx context.Context, r *model.Reminder) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if dir := filepath.Dir(n.path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,75 @@
+package purger
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/services"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+// Purger periodically removes the tasks that have been in the trash for
+// longer than the retention period, together with the files of their
+// attachments.
+type Purger struct {
+	store     store.Store
+	blobs     services.BlobStore
+	retention time.Duration
+	interval  time.Duration
+	log       *slog.Logger
+}
+
+func New(store store.Store, blobs services.BlobStore, retention time.Duration, interval time.Duration, log *slog.Logger) *Purger {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	return &Purger{
+		store:     store,
+		blobs:     blobs,
+		retention: retention,
+		interval:  interval,
+		log:       log,
+	}
+}
+
+// Run purges the trash every interval until the context is canceled.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := p.Purge(time.Now().UTC())
+		if err != nil {
+			p.log.Error("failed to purge the trash", slog.String("error", err.Error()))
+		} else if n > 0 {
+			p.log.Info("trash purged", slog.Int64("tasks", n))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge removes the tasks deleted more than the retention period before the
+// given moment and returns how many were removed. The files of their
+// attachments are removed after the tasks, a file that cannot be removed is
+// only logged.
+func (p *Purger) Purge(now time.Time) (int64, error) {
+	n, keys, err := p.store.Todo().Purge(now.Add(-p.retention))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, key := range keys {
+		if err := p.blobs.Delete(context.Background(), key); err != nil {
+			p.log.Error("failed to remove an attachment", slog.String("key", key), slog.String("error", err.Error()))
+		}
+	}
+
+	return n, nil
+}
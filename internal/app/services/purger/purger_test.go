@@ -0,0 +1,56 @@
+package purger_test
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/services/blobstore"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/services/purger"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/teststore"
+)
+
+func TestPurger_Purge(t *testing.T) {
+	s := teststore.New()
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, s.Todo().Create(model.TestTask(t, 1)))
+	}
+
+	blobs := blobstore.NewFileStore(t.TempDir())
+	a := &model.Attachment{UserID: 1, TaskID: 1, UploadedBy: 1, Name: "notes.txt", ContentType: "text/plain; charset=utf-8"}
+	assert.NoError(t, a.BeforeCreate())
+	assert.NoError(t, blobs.Put(context.Background(), a.Key, strings.NewReader("notes")))
+	assert.NoError(t, s.Attachment().Create(a))
+
+	s.Todo().Delete(1, []int{1, 2}, 1)
+
+	p := purger.New(s, blobs, 24*time.Hour, time.Hour, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	n, err := p.Purge(time.Now().UTC())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	rc, err := blobs.Get(context.Background(), a.Key)
+	assert.NoError(t, err)
+	rc.Close()
+
+	n, err = p.Purge(time.Now().UTC().Add(25 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	trash, _ := s.Todo().Trash(1)
+	assert.Empty(t, trash)
+
+	_, err = blobs.Get(context.Background(), a.Key)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	_, err = s.Todo().FindByID(1, 3)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,89 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/services"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+const batchSize = 100
+
+// Scheduler periodically sends the reminders whose time has come. A
+// reminder that fails to be delivered stays due and is retried on the next
+// tick.
+type Scheduler struct {
+	store    store.Store
+	notifier services.Notifier
+	interval time.Duration
+	log      *slog.Logger
+}
+
+func New(store store.Store, notifier services.Notifier, interval time.Duration, log *slog.Logger) *Scheduler {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	return &Scheduler{
+		store:    store,
+		notifier: notifier,
+		interval: interval,
+		log:      log,
+	}
+}
+
+// Run sends the due reminders every interval until the context is canceled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.SendDue(ctx, time.Now().UTC()); err != nil && ctx.Err() == nil {
+			s.log.Error("failed to send reminders", slog.String("error", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDue sends the reminders that are due at the given moment and returns
+// how many of them were delivered.
+func (s *Scheduler) SendDue(ctx context.Context, now time.Time) (int, error) {
+	sent := 0
+
+	for {
+		reminders, err := s.store.Reminder().FindDue(now, batchSize)
+		if err != nil {
+			return sent, err
+		}
+
+		failed := false
+		for _, r := range reminders {
+			if ctx.Err() != nil {
+				return sent, ctx.Err()
+			}
+
+			if err := s.notifier.Notify(ctx, r); err != nil {
+				s.log.Warn("failed to deliver reminder", slog.Int("reminder_id", r.ID), slog.String("error", err.Error()))
+				failed = true
+				continue
+			}
+
+			if err := s.store.Reminder().MarkSent(r); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+
+		// the failed reminders are found again, they wait for the next tick
+		if failed || len(reminders) < batchSize {
+			return sent, nil
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package scheduler_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/services/notifier"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/services/scheduler"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/teststore"
+)
+
+func TestScheduler_SendDue(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	task := model.TestTask(t, u.ID)
+	s.Todo().Create(task)
+	deadline := *task.Deadline
+
+	s.Reminder().Create(&model.Reminder{UserID: u.ID, TaskID: task.TaskID, Offset: model.ReminderOffset(time.Hour)})
+	s.Reminder().Create(&model.Reminder{UserID: u.ID, TaskID: task.TaskID, Offset: model.ReminderOffset(24 * time.Hour)})
+
+	outbox := filepath.Join(t.TempDir(), "reminders.jsonl")
+	sched := scheduler.New(s, notifier.NewOutboxNotifier(outbox), time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+
+	sent, err := sched.SendDue(ctx, deadline.Add(-25*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	sent, err = sched.SendDue(ctx, deadline.Add(-24*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	// a reminder is sent only once for the same deadline
+	sent, err = sched.SendDue(ctx, deadline.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	reminders := readOutbox(t, outbox)
+	assert.Len(t, reminders, 2)
+	assert.Equal(t, "1d", reminders[0].Offset.String())
+	assert.Equal(t, "1h", reminders[1].Offset.String())
+	assert.Equal(t, *task.Title, *reminders[1].Task.Title)
+
+	// moving the deadline arms the reminders again
+	later := deadline.Add(48 * time.Hour)
+	s.Todo().Update(&model.Task{UserID: u.ID, TaskID: task.TaskID, Deadline: &later})
+
+	sent, err = sched.SendDue(ctx, later.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, sent)
+
+	// complete tasks are not reminded of
+	evenLater := later.Add(48 * time.Hour)
+	complete := true
+	s.Todo().Update(&model.Task{UserID: u.ID, TaskID: task.TaskID, Deadline: &evenLater, Complete: &complete})
+
+	sent, err = sched.SendDue(ctx, evenLater)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+}
+
+func TestScheduler_Run(t *testing.T) {
+	s := teststore.New()
+	sched := scheduler.New(s, notifier.NewOutboxNotifier(filepath.Join(t.TempDir(), "reminders.jsonl")), time.Millisecond, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		sched.Run(ctx)
+		close(done)
+	}()
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("scheduler did not stop")
+	}
+}
+
+func readOutbox(t *testing.T, path string) []*model.Reminder {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	reminders := []*model.Reminder{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		r := &model.Reminder{}
+		if err := json.Unmarshal(scanner.Bytes(), r); err != nil {
+			t.Fatal(err)
+		}
+		reminders = append(reminders, r)
+	}
+
+	return reminders
+}
@@ -1,6 +1,36 @@
 package services
 
+import (
+	"context"
+	"io"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+)
+
 type TokenService interface {
 	GenerateAccessToken(id int) (string, error)
+	// GenerateWorkspaceAccessToken generates an access token that makes the
+	// workspace the active one.
+	GenerateWorkspaceAccessToken(id int, workspaceID int) (string, error)
 	GenerateRefreshToken() (string, error)
-}
\ No newline at end of file
+}
+
+// Notifier delivers a due reminder to the user of its task.
+type Notifier interface {
+	Notify(ctx context.Context, r *model.Reminder) error
+}
+
+// EventPublisher hands task events over for delivery to the webhooks of
+// their user. Publish must not block the request that emits the event.
+type EventPublisher interface {
+	Publish(e *model.WebhookEvent)
+}
+
+// BlobStore keeps the contents of the files attached to tasks under keys
+// chosen by the caller. Get of a key that has no blob returns an error that
+// is fs.ErrNotExist.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
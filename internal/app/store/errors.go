@@ -3,5 +3,10 @@ package store
 import "errors"
 
 var (
-	ErrRecordNotFound = errors.New("record not found")
+	ErrRecordNotFound  = errors.New("record not found")
+	ErrVersionConflict = errors.New("the record has been modified since it was read")
+	ErrTaskBlocked     = errors.New("the task is blocked by tasks that are not complete")
+	ErrDependencyCycle = errors.New("the dependency would create a cycle")
+	ErrTimerRunning    = errors.New("a timer is already running")
+	ErrUIDConflict     = errors.New("the UID is used by another task")
 )
\ No newline at end of file
@@ -0,0 +1,89 @@
+package apppassword_postgres
+
+import (
+	"database/sql"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+type AppPasswordRepository struct {
+	DB *sql.DB
+}
+
+func (r *AppPasswordRepository) Create(p *model.AppPassword) error {
+	if err := p.Validation(); err != nil {
+		return err
+	}
+
+	if err := p.BeforeCreate(); err != nil {
+		return err
+	}
+
+	return r.DB.QueryRow(
+		"INSERT INTO app_passwords (user_id, name, password_hash) VALUES ($1, $2, $3) RETURNING id, created_at",
+		p.UserID,
+		p.Name,
+		p.PasswordHash,
+	).Scan(&p.ID, &p.CreatedAt)
+}
+
+func (r *AppPasswordRepository) FindAll(userID int) ([]*model.AppPassword, error) {
+	rows, err := r.DB.Query(
+		"SELECT id, user_id, name, created_at, last_used_at FROM app_passwords WHERE user_id = $1 ORDER BY id",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	passwords := []*model.AppPassword{}
+
+	for rows.Next() {
+		p := &model.AppPassword{}
+		if err := rows.Scan(&p.ID, &p.UserID, &p.Name, &p.CreatedAt, &p.LastUsedAt); err != nil {
+			return nil, err
+		}
+		passwords = append(passwords, p)
+	}
+
+	return passwords, rows.Err()
+}
+
+func (r *AppPasswordRepository) Delete(userID int, id int) error {
+	res, err := r.DB.Exec("DELETE FROM app_passwords WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *AppPasswordRepository) Authenticate(userID int, password string) (*model.AppPassword, error) {
+	p := &model.AppPassword{}
+
+	if err := r.DB.QueryRow(
+		`UPDATE app_passwords SET last_used_at = (now() AT TIME ZONE 'utc')
+		WHERE user_id = $1 AND password_hash = $2
+		RETURNING id, user_id, name, created_at, last_used_at`,
+		userID,
+		model.HashAppPassword(password),
+	).Scan(&p.ID, &p.UserID, &p.Name, &p.CreatedAt, &p.LastUsedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return p, nil
+}
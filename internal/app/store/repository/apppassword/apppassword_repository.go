@@ -0,0 +1,12 @@
+package apppassword
+
+import "github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+
+type AppPasswordRepository interface {
+	Create(*model.AppPassword) error
+	FindAll(int) ([]*model.AppPassword, error)
+	Delete(int, int) error
+	// Authenticate returns the app password of the user that matches the
+	// password and records that it was used.
+	Authenticate(int, string) (*model.AppPassword, error)
+}
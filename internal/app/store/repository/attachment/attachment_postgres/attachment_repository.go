@@ -0,0 +1,154 @@
+package attachment_postgres
+
+import (
+	"database/sql"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+const attachmentColumns = "a.id, a.user_id, a.task_id, a.uploaded_by, a.name, a.content_type, a.size, a.blob_key, a.created_at"
+
+type AttachmentRepository struct {
+	DB *sql.DB
+}
+
+func (r *AttachmentRepository) Create(a *model.Attachment) error {
+	if err := a.Validation(); err != nil {
+		return err
+	}
+
+	if err := r.DB.QueryRow(
+		`INSERT INTO attachments (user_id, task_id, uploaded_by, name, content_type, size, blob_key)
+		SELECT user_id, task_id, $3, $4, $5, $6, $7 FROM tasks WHERE task_id = $1 AND user_id = $2 AND deleted_at IS NULL
+		RETURNING id, created_at`,
+		a.TaskID,
+		a.UserID,
+		a.UploadedBy,
+		a.Name,
+		a.ContentType,
+		a.Size,
+		a.Key,
+	).Scan(&a.ID, &a.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *AttachmentRepository) FindByTask(userID int, taskID int) ([]*model.Attachment, error) {
+	var exists bool
+	if err := r.DB.QueryRow(
+		"SELECT EXISTS (SELECT 1 FROM tasks WHERE task_id = $1 AND user_id = $2 AND deleted_at IS NULL)",
+		taskID,
+		userID,
+	).Scan(&exists); err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, store.ErrRecordNotFound
+	}
+
+	rows, err := r.DB.Query(
+		"SELECT "+attachmentColumns+" FROM attachments a WHERE a.task_id = $1 AND a.user_id = $2 ORDER BY a.id",
+		taskID,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []*model.Attachment{}
+
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+
+	return attachments, rows.Err()
+}
+
+func (r *AttachmentRepository) FindByID(userID int, taskID int, attachmentID int) (*model.Attachment, error) {
+	return r.findOne(
+		`SELECT `+attachmentColumns+` FROM attachments a JOIN tasks t ON t.task_id = a.task_id
+		WHERE a.id = $1 AND a.task_id = $2 AND a.user_id = $3 AND t.deleted_at IS NULL`,
+		attachmentID,
+		taskID,
+		userID,
+	)
+}
+
+func (r *AttachmentRepository) Find(attachmentID int) (*model.Attachment, error) {
+	return r.findOne(
+		`SELECT `+attachmentColumns+` FROM attachments a JOIN tasks t ON t.task_id = a.task_id
+		WHERE a.id = $1 AND t.deleted_at IS NULL`,
+		attachmentID,
+	)
+}
+
+func (r *AttachmentRepository) Delete(userID int, taskID int, attachmentID int) error {
+	res, err := r.DB.Exec(
+		"DELETE FROM attachments WHERE id = $1 AND task_id = $2 AND user_id = $3",
+		attachmentID,
+		taskID,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *AttachmentRepository) findOne(query string, args ...interface{}) (*model.Attachment, error) {
+	a, err := scanAttachment(r.DB.QueryRow(query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return a, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAttachment(row scanner) (*model.Attachment, error) {
+	a := &model.Attachment{}
+
+	if err := row.Scan(
+		&a.ID,
+		&a.UserID,
+		&a.TaskID,
+		&a.UploadedBy,
+		&a.Name,
+		&a.ContentType,
+		&a.Size,
+		&a.Key,
+		&a.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
@@ -0,0 +1,15 @@
+package attachment
+
+import "github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+
+type AttachmentRepository interface {
+	// Create adds the attachment to a task of the user that is not in the
+	// trash.
+	Create(*model.Attachment) error
+	FindByTask(int, int) ([]*model.Attachment, error)
+	FindByID(int, int, int) (*model.Attachment, error)
+	// Find returns an attachment of a task that is not in the trash by its
+	// ID alone, the signed download URLs carry no user.
+	Find(int) (*model.Attachment, error)
+	Delete(int, int, int) error
+}
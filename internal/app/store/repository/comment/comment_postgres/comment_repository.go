@@ -0,0 +1,175 @@
+package comment_postgres
+
+import (
+	"database/sql"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+type CommentRepository struct {
+	DB *sql.DB
+}
+
+func (r *CommentRepository) Create(c *model.Comment) error {
+	if err := c.Validation(); err != nil {
+		return err
+	}
+
+	if err := r.DB.QueryRow(
+		`INSERT INTO comments (user_id, task_id, author_id, body)
+		SELECT user_id, task_id, $3, $4 FROM tasks WHERE task_id = $1 AND user_id = $2 AND deleted_at IS NULL
+		RETURNING id, created_at`,
+		c.TaskID,
+		c.UserID,
+		c.AuthorID,
+		c.Body,
+	).Scan(&c.ID, &c.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *CommentRepository) FindByTask(userID int, taskID int, q *model.CommentQuery) (*model.CommentPage, error) {
+	var exists bool
+	if err := r.DB.QueryRow(
+		"SELECT EXISTS (SELECT 1 FROM tasks WHERE task_id = $1 AND user_id = $2 AND deleted_at IS NULL)",
+		taskID,
+		userID,
+	).Scan(&exists); err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, store.ErrRecordNotFound
+	}
+
+	// one comment more than the limit tells whether there is a next page
+	rows, err := r.DB.Query(
+		`SELECT id, user_id, task_id, author_id, body, created_at, edited_at
+		FROM comments
+		WHERE task_id = $1 AND user_id = $2 AND id > $3
+		ORDER BY id
+		LIMIT $4`,
+		taskID,
+		userID,
+		q.AfterID,
+		q.Limit+1,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := &model.CommentPage{Comments: []*model.Comment{}}
+
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		page.Comments = append(page.Comments, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(page.Comments) > q.Limit {
+		page.Comments = page.Comments[:q.Limit]
+		page.NextCursor = model.EncodeCommentCursor(page.Comments[q.Limit-1])
+	}
+
+	return page, nil
+}
+
+func (r *CommentRepository) FindByID(userID int, taskID int, commentID int) (*model.Comment, error) {
+	c, err := scanComment(r.DB.QueryRow(
+		`SELECT c.id, c.user_id, c.task_id, c.author_id, c.body, c.created_at, c.edited_at
+		FROM comments c JOIN tasks t ON t.task_id = c.task_id
+		WHERE c.id = $1 AND c.task_id = $2 AND c.user_id = $3 AND t.deleted_at IS NULL`,
+		commentID,
+		taskID,
+		userID,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (r *CommentRepository) Update(c *model.Comment) error {
+	if err := c.Validation(); err != nil {
+		return err
+	}
+
+	if err := r.DB.QueryRow(
+		`UPDATE comments SET body = $1, edited_at = (now() AT TIME ZONE 'utc')
+		WHERE id = $2 AND task_id = $3 AND user_id = $4
+		RETURNING author_id, created_at, edited_at`,
+		c.Body,
+		c.ID,
+		c.TaskID,
+		c.UserID,
+	).Scan(&c.AuthorID, &c.CreatedAt, &c.EditedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *CommentRepository) Delete(userID int, taskID int, commentID int) error {
+	res, err := r.DB.Exec(
+		"DELETE FROM comments WHERE id = $1 AND task_id = $2 AND user_id = $3",
+		commentID,
+		taskID,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanComment(row scanner) (*model.Comment, error) {
+	c := &model.Comment{}
+
+	if err := row.Scan(
+		&c.ID,
+		&c.UserID,
+		&c.TaskID,
+		&c.AuthorID,
+		&c.Body,
+		&c.CreatedAt,
+		&c.EditedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
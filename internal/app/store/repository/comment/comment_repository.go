@@ -0,0 +1,15 @@
+package comment
+
+import "github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+
+type CommentRepository interface {
+	// Create adds the comment to a task of the user that is not in the
+	// trash.
+	Create(*model.Comment) error
+	FindByTask(int, int, *model.CommentQuery) (*model.CommentPage, error)
+	FindByID(int, int, int) (*model.Comment, error)
+	// Update changes the body of the comment and records when it was
+	// edited.
+	Update(*model.Comment) error
+	Delete(int, int, int) error
+}
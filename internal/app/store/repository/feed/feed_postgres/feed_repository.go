@@ -0,0 +1,65 @@
+package feed_postgres
+
+import (
+	"database/sql"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+type FeedRepository struct {
+	DB *sql.DB
+}
+
+func (r *FeedRepository) Save(f *model.Feed) error {
+	if err := f.BeforeCreate(); err != nil {
+		return err
+	}
+
+	return r.DB.QueryRow(
+		`INSERT INTO feeds (user_id, token_hash) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET token_hash = EXCLUDED.token_hash, created_at = (now() AT TIME ZONE 'utc')
+		RETURNING created_at`,
+		f.UserID,
+		f.TokenHash,
+	).Scan(&f.CreatedAt)
+}
+
+func (r *FeedRepository) FindByUser(userID int) (*model.Feed, error) {
+	return r.find("SELECT user_id, token_hash, created_at FROM feeds WHERE user_id = $1", userID)
+}
+
+func (r *FeedRepository) FindByToken(token string) (*model.Feed, error) {
+	return r.find("SELECT user_id, token_hash, created_at FROM feeds WHERE token_hash = $1", model.HashFeedToken(token))
+}
+
+func (r *FeedRepository) Delete(userID int) error {
+	res, err := r.DB.Exec("DELETE FROM feeds WHERE user_id = $1", userID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *FeedRepository) find(query string, arg interface{}) (*model.Feed, error) {
+	f := &model.Feed{}
+
+	if err := r.DB.QueryRow(query, arg).Scan(&f.UserID, &f.TokenHash, &f.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return f, nil
+}
@@ -0,0 +1,13 @@
+package feed
+
+import "github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+
+type FeedRepository interface {
+	// Save stores the feed of the user in place of the previous one, whose
+	// token stops working.
+	Save(*model.Feed) error
+	FindByUser(int) (*model.Feed, error)
+	// FindByToken returns the feed of the token, not of its hash.
+	FindByToken(string) (*model.Feed, error)
+	Delete(int) error
+}
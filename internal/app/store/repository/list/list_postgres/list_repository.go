@@ -0,0 +1,244 @@
+package list_postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/list"
+)
+
+var errDeleteInbox = errors.New("the inbox list cannot be deleted")
+
+type ListRepository struct {
+	DB *sql.DB
+
+	// workspace is the workspace given to InWorkspace, every query keeps to
+	// the lists of that workspace.
+	workspace int
+}
+
+func (r *ListRepository) Create(l *model.List) error {
+	if err := l.Validation(); err != nil {
+		return err
+	}
+
+	l.WorkspaceID = r.workspaceID()
+
+	return r.DB.QueryRow(
+		"INSERT INTO lists (user_id, name, workspace_id) VALUES ($1, $2, $3) RETURNING id, created_at",
+		l.UserID,
+		l.Name,
+		l.WorkspaceID,
+	).Scan(&l.ID, &l.CreatedAt)
+}
+
+// FindAll returns the lists of the user followed by the lists other users
+// share with the user.
+func (r *ListRepository) FindAll(userID int) ([]*model.List, error) {
+	rows, err := r.DB.Query(
+		`SELECT id, user_id, workspace_id, name, inbox, role, workflow, created_at FROM (
+			SELECT id, user_id, workspace_id, name, inbox, '' AS role, workflow, created_at FROM lists WHERE user_id = $1
+			UNION ALL
+			SELECT l.id, l.user_id, l.workspace_id, l.name, l.inbox, s.role, l.workflow, l.created_at
+			FROM lists l JOIN shares s ON s.list_id = l.id
+			WHERE s.grantee_id = $1 AND s.status = 'accepted'
+		) l WHERE workspace_id IS NOT DISTINCT FROM $2 ORDER BY role <> '', inbox DESC, id`,
+		userID,
+		r.workspaceID(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := []*model.List{}
+
+	for rows.Next() {
+		l := &model.List{}
+		var workflow []byte
+		if err := rows.Scan(&l.ID, &l.UserID, &l.WorkspaceID, &l.Name, &l.Inbox, &l.Role, &workflow, &l.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := scanWorkflow(l, workflow); err != nil {
+			return nil, err
+		}
+		lists = append(lists, l)
+	}
+
+	return lists, rows.Err()
+}
+
+func (r *ListRepository) FindByID(userID int, listID int) (*model.List, error) {
+	l := &model.List{}
+	var workflow []byte
+
+	if err := r.DB.QueryRow(
+		"SELECT id, user_id, workspace_id, name, inbox, workflow, created_at FROM lists WHERE id = $1 AND user_id = $2 AND workspace_id IS NOT DISTINCT FROM $3",
+		listID,
+		userID,
+		r.workspaceID(),
+	).Scan(&l.ID, &l.UserID, &l.WorkspaceID, &l.Name, &l.Inbox, &workflow, &l.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	if err := scanWorkflow(l, workflow); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (r *ListRepository) Update(l *model.List) error {
+	if err := l.Validation(); err != nil {
+		return err
+	}
+
+	var workflow []byte
+
+	if err := r.DB.QueryRow(
+		"UPDATE lists SET name = $1 WHERE id = $2 AND user_id = $3 AND workspace_id IS NOT DISTINCT FROM $4 RETURNING workspace_id, inbox, workflow, created_at",
+		l.Name,
+		l.ID,
+		l.UserID,
+		r.workspaceID(),
+	).Scan(&l.WorkspaceID, &l.Inbox, &workflow, &l.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+		return err
+	}
+
+	return scanWorkflow(l, workflow)
+}
+
+// UpdateWorkflow sets the workflow of the list. The tasks whose status the
+// workflow no longer has take the default status of their complete flag,
+// the complete flag of the other tasks follows their status. The changed
+// flags are recorded in the history of the tasks.
+func (r *ListRepository) UpdateWorkflow(l *model.List) error {
+	w := l.TaskWorkflow()
+	if err := w.Validation(); err != nil {
+		return err
+	}
+
+	var workflow []byte
+	if l.Workflow != nil {
+		b, err := json.Marshal(l.Workflow)
+		if err != nil {
+			return err
+		}
+		workflow = b
+	}
+
+	names, done := []string{}, []string{}
+	for _, s := range w.Statuses {
+		names = append(names, s.Name)
+		if s.Done {
+			done = append(done, s.Name)
+		}
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow(
+		"UPDATE lists SET workflow = $1 WHERE id = $2 AND user_id = $3 AND workspace_id IS NOT DISTINCT FROM $4 RETURNING workspace_id, name, inbox, created_at",
+		workflow,
+		l.ID,
+		l.UserID,
+		r.workspaceID(),
+	).Scan(&l.WorkspaceID, &l.Name, &l.Inbox, &l.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+		return err
+	}
+
+	if _, err := tx.Exec(
+		`WITH changed AS (
+			UPDATE tasks t SET
+				status = CASE WHEN t.status = ANY($2) THEN t.status END,
+				complete = CASE WHEN t.status = ANY($2) THEN t.status = ANY($3) ELSE t.complete END,
+				completed_at = CASE
+					WHEN t.status = ANY($3) THEN COALESCE(t.completed_at, now() AT TIME ZONE 'utc')
+					WHEN t.status = ANY($2) THEN NULL
+					ELSE t.completed_at
+				END,
+				version = t.version + 1
+			FROM tasks old
+			WHERE old.task_id = t.task_id AND t.list_id = $1 AND t.status IS NOT NULL
+				AND (t.status <> ALL($2) OR t.complete IS DISTINCT FROM (t.status = ANY($3)))
+			RETURNING t.user_id, t.task_id, t.workspace_id, old.complete AS before, t.complete AS after
+		)
+		INSERT INTO task_events (user_id, task_id, actor_id, type, changes, workspace_id)
+		SELECT user_id, task_id, user_id, $4, jsonb_build_object('complete', jsonb_build_object('before', before, 'after', after)), workspace_id
+		FROM changed WHERE before IS DISTINCT FROM after`,
+		l.ID,
+		pq.Array(names),
+		pq.Array(done),
+		model.TaskEventUpdated,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete removes the list. Its tasks are kept and no longer belong to any
+// list.
+func (r *ListRepository) Delete(userID int, listID int) error {
+	l, err := r.FindByID(userID, listID)
+	if err != nil {
+		return err
+	}
+
+	if l.Inbox {
+		return errDeleteInbox
+	}
+
+	_, err = r.DB.Exec(
+		"DELETE FROM lists WHERE id = $1 AND user_id = $2 AND NOT inbox",
+		listID,
+		userID,
+	)
+
+	return err
+}
+
+func (r *ListRepository) InWorkspace(workspaceID int) list.ListRepository {
+	return &ListRepository{DB: r.DB, workspace: workspaceID}
+}
+
+// scanWorkflow reads the workflow column of the list, NULL for the default
+// workflow.
+func scanWorkflow(l *model.List, workflow []byte) error {
+	if workflow == nil {
+		return nil
+	}
+
+	l.Workflow = &model.Workflow{}
+
+	return json.Unmarshal(workflow, l.Workflow)
+}
+
+// workspaceID is the workspace_id of the lists of the repository, NULL for
+// the personal workspace.
+func (r *ListRepository) workspaceID() *int {
+	if r.workspace == model.PersonalWorkspaceID {
+		return nil
+	}
+
+	id := r.workspace
+
+	return &id
+}
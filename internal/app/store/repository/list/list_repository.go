@@ -0,0 +1,18 @@
+package list
+
+import "github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+
+// ListRepository reads and writes the lists of a single workspace, the
+// personal workspace unless the repository is returned by InWorkspace.
+type ListRepository interface{
+	Create(*model.List) error
+	FindAll(int) ([]*model.List, error)
+	FindByID(int, int) (*model.List, error)
+	Update(*model.List) error
+	// UpdateWorkflow sets the workflow of the list, nil restores the
+	// default one, and fits the statuses of its tasks to it.
+	UpdateWorkflow(*model.List) error
+	Delete(int, int) error
+	// InWorkspace returns the repository of the lists of the workspace.
+	InWorkspace(int) ListRepository
+}
\ No newline at end of file
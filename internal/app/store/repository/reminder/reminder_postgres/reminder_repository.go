@@ -0,0 +1,183 @@
+package reminder_postgres
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+var errReminderExists = errors.New("the task already has a reminder with this offset")
+
+type ReminderRepository struct {
+	DB *sql.DB
+}
+
+func (r *ReminderRepository) Create(rem *model.Reminder) error {
+	if err := rem.Validation(); err != nil {
+		return err
+	}
+
+	if err := r.DB.QueryRow(
+		`INSERT INTO reminders (user_id, task_id, offset_seconds)
+		SELECT user_id, task_id, $3 FROM tasks WHERE task_id = $1 AND user_id = $2 AND deleted_at IS NULL
+		RETURNING id`,
+		rem.TaskID,
+		rem.UserID,
+		int64(time.Duration(rem.Offset)/time.Second),
+	).Scan(&rem.ID); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return errReminderExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *ReminderRepository) FindByTask(userID int, taskID int) ([]*model.Reminder, error) {
+	var exists bool
+	if err := r.DB.QueryRow(
+		"SELECT EXISTS (SELECT 1 FROM tasks WHERE task_id = $1 AND user_id = $2 AND deleted_at IS NULL)",
+		taskID,
+		userID,
+	).Scan(&exists); err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, store.ErrRecordNotFound
+	}
+
+	rows, err := r.DB.Query(
+		`SELECT r.id, r.user_id, r.task_id, r.offset_seconds, t.deadline - r.offset_seconds * interval '1 second', r.sent_at
+		FROM reminders r JOIN tasks t ON t.task_id = r.task_id
+		WHERE r.task_id = $1 AND r.user_id = $2
+		ORDER BY r.offset_seconds`,
+		taskID,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reminders := []*model.Reminder{}
+
+	for rows.Next() {
+		rem, err := scanReminder(rows)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, rem)
+	}
+
+	return reminders, rows.Err()
+}
+
+func (r *ReminderRepository) Delete(userID int, taskID int, reminderID int) error {
+	res, err := r.DB.Exec(
+		"DELETE FROM reminders WHERE id = $1 AND task_id = $2 AND user_id = $3",
+		reminderID,
+		taskID,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *ReminderRepository) FindDue(now time.Time, limit int) ([]*model.Reminder, error) {
+	rows, err := r.DB.Query(
+		`SELECT r.id, r.user_id, r.task_id, r.offset_seconds, t.deadline - r.offset_seconds * interval '1 second', r.sent_at,
+			t.title, t.description, t.deadline, t.complete
+		FROM reminders r JOIN tasks t ON t.task_id = r.task_id
+		WHERE NOT t.complete
+			AND t.deleted_at IS NULL
+			AND t.deadline - r.offset_seconds * interval '1 second' <= $1
+			AND r.sent_deadline IS DISTINCT FROM t.deadline
+		ORDER BY t.deadline - r.offset_seconds * interval '1 second', r.id
+		LIMIT $2`,
+		now,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reminders := []*model.Reminder{}
+
+	for rows.Next() {
+		t := &model.Task{}
+		rem, err := scanReminder(rows, &t.Title, &t.Description, &t.Deadline, &t.Complete)
+		if err != nil {
+			return nil, err
+		}
+
+		t.UserID = rem.UserID
+		t.TaskID = rem.TaskID
+		rem.Task = t
+		reminders = append(reminders, rem)
+	}
+
+	return reminders, rows.Err()
+}
+
+func (r *ReminderRepository) MarkSent(rem *model.Reminder) error {
+	if err := r.DB.QueryRow(
+		"UPDATE reminders SET sent_at = (now() AT TIME ZONE 'utc'), sent_deadline = $1 WHERE id = $2 RETURNING sent_at",
+		rem.Task.Deadline,
+		rem.ID,
+	).Scan(&rem.SentAt); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanReminder(row scanner, extra ...interface{}) (*model.Reminder, error) {
+	rem := &model.Reminder{}
+	var offset int64
+
+	dest := []interface{}{
+		&rem.ID,
+		&rem.UserID,
+		&rem.TaskID,
+		&offset,
+		&rem.RemindAt,
+		&rem.SentAt,
+	}
+
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	rem.Offset = model.ReminderOffset(time.Duration(offset) * time.Second)
+
+	return rem, nil
+}
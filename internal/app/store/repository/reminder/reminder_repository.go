@@ -0,0 +1,19 @@
+package reminder
+
+import (
+	"time"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+)
+
+type ReminderRepository interface {
+	Create(*model.Reminder) error
+	FindByTask(int, int) ([]*model.Reminder, error)
+	Delete(int, int, int) error
+	// FindDue returns at most limit reminders of incomplete tasks that have
+	// to be sent at the given moment, together with their tasks.
+	FindDue(time.Time, int) ([]*model.Reminder, error)
+	// MarkSent records that the reminder has been sent for the deadline of
+	// its task, as returned by FindDue.
+	MarkSent(*model.Reminder) error
+}
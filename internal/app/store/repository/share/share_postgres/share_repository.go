@@ -0,0 +1,144 @@
+package share_postgres
+
+import (
+	"database/sql"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+const shareColumns = "s.id, s.owner_id, s.list_id, s.task_id, s.grantee_id, u.email, s.role, s.status, s.invited_by, s.created_at"
+
+type ShareRepository struct {
+	DB *sql.DB
+}
+
+// Create keeps an accepted share accepted when the role changes, the other
+// shares become invitations again.
+func (r *ShareRepository) Create(s *model.Share) error {
+	if err := s.Validation(); err != nil {
+		return err
+	}
+
+	conflict := "(list_id, grantee_id) WHERE list_id IS NOT NULL"
+	if s.TaskID != nil {
+		conflict = "(task_id, grantee_id) WHERE task_id IS NOT NULL"
+	}
+
+	return r.DB.QueryRow(
+		`INSERT INTO shares (owner_id, list_id, task_id, grantee_id, role, invited_by) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT `+conflict+` DO UPDATE SET
+			role = EXCLUDED.role,
+			invited_by = EXCLUDED.invited_by,
+			status = CASE WHEN shares.status = 'accepted' THEN 'accepted' ELSE 'pending' END
+		RETURNING id, status, created_at`,
+		s.OwnerID,
+		s.ListID,
+		s.TaskID,
+		s.GranteeID,
+		s.Role,
+		s.InvitedBy,
+	).Scan(&s.ID, &s.Status, &s.CreatedAt)
+}
+
+func (r *ShareRepository) FindByID(id int) (*model.Share, error) {
+	s, err := scanShare(r.DB.QueryRow(
+		"SELECT "+shareColumns+" FROM shares s JOIN users u ON u.id = s.grantee_id WHERE s.id = $1",
+		id,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (r *ShareRepository) FindByOwner(ownerID int) ([]*model.Share, error) {
+	return r.findAll("SELECT "+shareColumns+" FROM shares s JOIN users u ON u.id = s.grantee_id WHERE s.owner_id = $1 ORDER BY s.id", ownerID)
+}
+
+func (r *ShareRepository) FindByGrantee(granteeID int) ([]*model.Share, error) {
+	return r.findAll("SELECT "+shareColumns+" FROM shares s JOIN users u ON u.id = s.grantee_id WHERE s.grantee_id = $1 ORDER BY s.id", granteeID)
+}
+
+func (r *ShareRepository) FindGrants(granteeID int, ownerID int) ([]*model.Share, error) {
+	return r.findAll(
+		"SELECT "+shareColumns+" FROM shares s JOIN users u ON u.id = s.grantee_id WHERE s.grantee_id = $1 AND s.owner_id = $2 AND s.status = 'accepted'",
+		granteeID,
+		ownerID,
+	)
+}
+
+func (r *ShareRepository) SetStatus(id int, granteeID int, status string) error {
+	return r.exec("UPDATE shares SET status = $3 WHERE id = $1 AND grantee_id = $2", id, granteeID, status)
+}
+
+func (r *ShareRepository) Delete(ownerID int, id int) error {
+	return r.exec("DELETE FROM shares WHERE id = $2 AND owner_id = $1", ownerID, id)
+}
+
+func (r *ShareRepository) exec(query string, args ...interface{}) error {
+	res, err := r.DB.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *ShareRepository) findAll(query string, args ...interface{}) ([]*model.Share, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := []*model.Share{}
+
+	for rows.Next() {
+		s, err := scanShare(rows)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, s)
+	}
+
+	return shares, rows.Err()
+}
+
+type scanner interface {
+	Scan(...interface{}) error
+}
+
+func scanShare(row scanner) (*model.Share, error) {
+	s := &model.Share{}
+
+	if err := row.Scan(
+		&s.ID,
+		&s.OwnerID,
+		&s.ListID,
+		&s.TaskID,
+		&s.GranteeID,
+		&s.Email,
+		&s.Role,
+		&s.Status,
+		&s.InvitedBy,
+		&s.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,18 @@
+package share
+
+import "github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+
+type ShareRepository interface {
+	// Create invites the grantee, or changes the role of the grantee when
+	// the item is already shared with them.
+	Create(*model.Share) error
+	FindByID(int) (*model.Share, error)
+	FindByOwner(int) ([]*model.Share, error)
+	FindByGrantee(int) ([]*model.Share, error)
+	// FindGrants returns the accepted shares of the items of the owner
+	// with the grantee.
+	FindGrants(int, int) ([]*model.Share, error)
+	// SetStatus answers an invitation of the grantee.
+	SetStatus(int, int, string) error
+	Delete(int, int) error
+}
@@ -3,16 +3,49 @@ package repository
 import (
 	"database/sql"
 
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/apppassword"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/apppassword/apppassword_postgres"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/attachment"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/attachment/attachment_postgres"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/comment"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/comment/comment_postgres"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/feed"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/feed/feed_postgres"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/list"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/list/list_postgres"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/reminder"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/reminder/reminder_postgres"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/share"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/share/share_postgres"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/tag"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/tag/tag_postgres"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/timeentry"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/timeentry/timeentry_postgres"
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/todo"
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/todo/todo_postgres"
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/user"
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/user/user_postgres"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/webhook"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/webhook/webhook_postgres"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/workspace"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/workspace/workspace_postgres"
 )
 
 type Store struct {
 	DB *sql.DB
 	userRepository user.UserRepository
 	todoRepository todo.TodoRepository
+	tagRepository tag.TagRepository
+	listRepository list.ListRepository
+	reminderRepository reminder.ReminderRepository
+	webhookRepository webhook.WebhookRepository
+	feedRepository feed.FeedRepository
+	appPasswordRepository apppassword.AppPasswordRepository
+	shareRepository share.ShareRepository
+	workspaceRepository workspace.WorkspaceRepository
+	commentRepository comment.CommentRepository
+	attachmentRepository attachment.AttachmentRepository
+	timeEntryRepository timeentry.TimeEntryRepository
 }
 
 func New(db *sql.DB) *Store{
@@ -43,4 +76,136 @@ func (s *Store) Todo() todo.TodoRepository {
 	}
 
 	return s.todoRepository
+}
+
+func (s *Store) Tag() tag.TagRepository {
+	if s.tagRepository != nil {
+		return s.tagRepository
+	}
+
+	s.tagRepository = &tag_postgres.TagRepository{
+		DB: s.DB,
+	}
+
+	return s.tagRepository
+}
+
+func (s *Store) List() list.ListRepository {
+	if s.listRepository != nil {
+		return s.listRepository
+	}
+
+	s.listRepository = &list_postgres.ListRepository{
+		DB: s.DB,
+	}
+
+	return s.listRepository
+}
+
+func (s *Store) Reminder() reminder.ReminderRepository {
+	if s.reminderRepository != nil {
+		return s.reminderRepository
+	}
+
+	s.reminderRepository = &reminder_postgres.ReminderRepository{
+		DB: s.DB,
+	}
+
+	return s.reminderRepository
+}
+
+func (s *Store) Webhook() webhook.WebhookRepository {
+	if s.webhookRepository != nil {
+		return s.webhookRepository
+	}
+
+	s.webhookRepository = &webhook_postgres.WebhookRepository{
+		DB: s.DB,
+	}
+
+	return s.webhookRepository
+}
+
+func (s *Store) Feed() feed.FeedRepository {
+	if s.feedRepository != nil {
+		return s.feedRepository
+	}
+
+	s.feedRepository = &feed_postgres.FeedRepository{
+		DB: s.DB,
+	}
+
+	return s.feedRepository
+}
+
+func (s *Store) AppPassword() apppassword.AppPasswordRepository {
+	if s.appPasswordRepository != nil {
+		return s.appPasswordRepository
+	}
+
+	s.appPasswordRepository = &apppassword_postgres.AppPasswordRepository{
+		DB: s.DB,
+	}
+
+	return s.appPasswordRepository
+}
+
+func (s *Store) Share() share.ShareRepository {
+	if s.shareRepository != nil {
+		return s.shareRepository
+	}
+
+	s.shareRepository = &share_postgres.ShareRepository{
+		DB: s.DB,
+	}
+
+	return s.shareRepository
+}
+
+func (s *Store) Workspace() workspace.WorkspaceRepository {
+	if s.workspaceRepository != nil {
+		return s.workspaceRepository
+	}
+
+	s.workspaceRepository = &workspace_postgres.WorkspaceRepository{
+		DB: s.DB,
+	}
+
+	return s.workspaceRepository
+}
+
+func (s *Store) Comment() comment.CommentRepository {
+	if s.commentRepository != nil {
+		return s.commentRepository
+	}
+
+	s.commentRepository = &comment_postgres.CommentRepository{
+		DB: s.DB,
+	}
+
+	return s.commentRepository
+}
+
+func (s *Store) Attachment() attachment.AttachmentRepository {
+	if s.attachmentRepository != nil {
+		return s.attachmentRepository
+	}
+
+	s.attachmentRepository = &attachment_postgres.AttachmentRepository{
+		DB: s.DB,
+	}
+
+	return s.attachmentRepository
+}
+
+func (s *Store) TimeEntry() timeentry.TimeEntryRepository {
+	if s.timeEntryRepository != nil {
+		return s.timeEntryRepository
+	}
+
+	s.timeEntryRepository = &timeentry_postgres.TimeEntryRepository{
+		DB: s.DB,
+	}
+
+	return s.timeEntryRepository
 }
\ No newline at end of file
@@ -0,0 +1,105 @@
+package tag_postgres
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+var errTagExists = errors.New("a tag with this name already exists")
+
+type TagRepository struct {
+	DB *sql.DB
+}
+
+func (r *TagRepository) Create(t *model.Tag) error {
+	if err := t.Validation(); err != nil {
+		return err
+	}
+
+	if err := r.DB.QueryRow(
+		"INSERT INTO tags (user_id, name) VALUES ($1, $2) RETURNING id",
+		t.UserID,
+		t.Name,
+	).Scan(&t.ID); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return errTagExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *TagRepository) FindAll(userID int) ([]*model.Tag, error) {
+	rows, err := r.DB.Query(
+		"SELECT id, user_id, name FROM tags WHERE user_id = $1 ORDER BY name",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []*model.Tag{}
+
+	for rows.Next() {
+		t := &model.Tag{}
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Name); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+
+	return tags, rows.Err()
+}
+
+func (r *TagRepository) Update(t *model.Tag) error {
+	if err := t.Validation(); err != nil {
+		return err
+	}
+
+	res, err := r.DB.Exec(
+		"UPDATE tags SET name = $1 WHERE id = $2 AND user_id = $3",
+		t.Name,
+		t.ID,
+		t.UserID,
+	)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return errTagExists
+		}
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+func (r *TagRepository) Delete(userID int, tagID int) error {
+	res, err := r.DB.Exec(
+		"DELETE FROM tags WHERE id = $1 AND user_id = $2",
+		tagID,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+func checkAffected(res sql.Result) error {
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,10 @@
+package tag
+
+import "github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+
+type TagRepository interface{
+	Create(*model.Tag) error
+	FindAll(int) ([]*model.Tag, error)
+	Update(*model.Tag) error
+	Delete(int, int) error
+}
\ No newline at end of file
@@ -0,0 +1,309 @@
+package timeentry_postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/timeentry"
+)
+
+type TimeEntryRepository struct {
+	DB *sql.DB
+
+	// workspace is the workspace given to InWorkspace, every query keeps to
+	// the tasks of that workspace.
+	workspace int
+}
+
+const entryColumns = "e.id, e.user_id, e.task_id, e.tracker_id, e.started_at, e.stopped_at, e.note, e.created_at"
+
+// reportGroups maps the groups of model.TimeReportQuery to the joins and the
+// group of the rows. The rows are ordered by their group.
+var reportGroups = map[string]struct {
+	join    string
+	groupBy string
+}{
+	model.TimeReportByDay: {
+		groupBy: "to_char(e.started_at, 'YYYY-MM-DD')",
+	},
+	model.TimeReportByList: {
+		join:    "LEFT JOIN lists l ON l.id = t.list_id",
+		groupBy: "COALESCE(l.name, ''), l.id",
+	},
+	model.TimeReportByTag: {
+		join:    "LEFT JOIN task_tags tt ON tt.task_id = t.task_id LEFT JOIN tags tg ON tg.id = tt.tag_id",
+		groupBy: "COALESCE(tg.name, '')",
+	},
+}
+
+func (r *TimeEntryRepository) Start(e *model.TimeEntry) error {
+	if err := r.DB.QueryRow(
+		`INSERT INTO time_entries (user_id, task_id, tracker_id, started_at, note)
+		SELECT user_id, task_id, $3, (now() AT TIME ZONE 'utc'), $4 FROM tasks
+		WHERE task_id = $1 AND user_id = $2 AND workspace_id IS NOT DISTINCT FROM $5 AND deleted_at IS NULL
+		RETURNING id, started_at, created_at`,
+		e.TaskID,
+		e.UserID,
+		e.TrackerID,
+		e.Note,
+		r.workspaceID(),
+	).Scan(&e.ID, &e.StartedAt, &e.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return store.ErrTimerRunning
+		}
+		return err
+	}
+
+	e.StoppedAt = nil
+	e.SetDuration(time.Now().UTC())
+
+	return nil
+}
+
+func (r *TimeEntryRepository) Stop(userID int, taskID int, trackerID int) (*model.TimeEntry, error) {
+	e, err := scanEntry(r.DB.QueryRow(
+		`UPDATE time_entries e SET stopped_at = GREATEST(e.started_at, now() AT TIME ZONE 'utc')
+		FROM tasks t
+		WHERE t.task_id = e.task_id AND e.task_id = $1 AND e.user_id = $2 AND e.tracker_id = $3 AND e.stopped_at IS NULL
+			AND t.workspace_id IS NOT DISTINCT FROM $4 AND t.deleted_at IS NULL
+		RETURNING `+entryColumns,
+		taskID,
+		userID,
+		trackerID,
+		r.workspaceID(),
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (r *TimeEntryRepository) Create(e *model.TimeEntry) error {
+	if err := e.Validation(); err != nil {
+		return err
+	}
+
+	if err := r.DB.QueryRow(
+		`INSERT INTO time_entries (user_id, task_id, tracker_id, started_at, stopped_at, note)
+		SELECT user_id, task_id, $3, $4, $5, $6 FROM tasks
+		WHERE task_id = $1 AND user_id = $2 AND workspace_id IS NOT DISTINCT FROM $7 AND deleted_at IS NULL
+		RETURNING id, created_at`,
+		e.TaskID,
+		e.UserID,
+		e.TrackerID,
+		e.StartedAt,
+		e.StoppedAt,
+		e.Note,
+		r.workspaceID(),
+	).Scan(&e.ID, &e.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+		return err
+	}
+
+	e.SetDuration(time.Now().UTC())
+
+	return nil
+}
+
+func (r *TimeEntryRepository) FindByTask(userID int, taskID int) ([]*model.TimeEntry, error) {
+	rows, err := r.DB.Query(
+		`SELECT `+entryColumns+`
+		FROM time_entries e JOIN tasks t ON t.task_id = e.task_id
+		WHERE e.task_id = $1 AND e.user_id = $2 AND t.workspace_id IS NOT DISTINCT FROM $3 AND t.deleted_at IS NULL
+		ORDER BY e.started_at, e.id`,
+		taskID,
+		userID,
+		r.workspaceID(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*model.TimeEntry{}
+
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+func (r *TimeEntryRepository) FindByID(userID int, taskID int, entryID int) (*model.TimeEntry, error) {
+	e, err := scanEntry(r.DB.QueryRow(
+		`SELECT `+entryColumns+`
+		FROM time_entries e JOIN tasks t ON t.task_id = e.task_id
+		WHERE e.id = $1 AND e.task_id = $2 AND e.user_id = $3 AND t.workspace_id IS NOT DISTINCT FROM $4 AND t.deleted_at IS NULL`,
+		entryID,
+		taskID,
+		userID,
+		r.workspaceID(),
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (r *TimeEntryRepository) Update(e *model.TimeEntry) error {
+	if err := e.Validation(); err != nil {
+		return err
+	}
+
+	if err := r.DB.QueryRow(
+		`UPDATE time_entries SET started_at = $1, stopped_at = $2, note = $3
+		WHERE id = $4 AND task_id = $5 AND user_id = $6
+		RETURNING tracker_id, created_at`,
+		e.StartedAt,
+		e.StoppedAt,
+		e.Note,
+		e.ID,
+		e.TaskID,
+		e.UserID,
+	).Scan(&e.TrackerID, &e.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+		return err
+	}
+
+	e.SetDuration(time.Now().UTC())
+
+	return nil
+}
+
+func (r *TimeEntryRepository) Delete(userID int, taskID int, entryID int) error {
+	res, err := r.DB.Exec(
+		"DELETE FROM time_entries WHERE id = $1 AND task_id = $2 AND user_id = $3",
+		entryID,
+		taskID,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Report sums the time tracked on the tasks of the user that are not in the
+// trash, a running timer counts until now.
+func (r *TimeEntryRepository) Report(userID int, q *model.TimeReportQuery) (*model.TimeReport, error) {
+	if err := q.Validation(); err != nil {
+		return nil, err
+	}
+
+	group := reportGroups[q.GroupBy]
+
+	rows, err := r.DB.Query(
+		`SELECT `+group.groupBy+`,
+			COALESCE(SUM(EXTRACT(EPOCH FROM COALESCE(e.stopped_at, now() AT TIME ZONE 'utc') - e.started_at)), 0)::bigint
+		FROM time_entries e JOIN tasks t ON t.task_id = e.task_id `+group.join+`
+		WHERE e.user_id = $1 AND t.workspace_id IS NOT DISTINCT FROM $2 AND t.deleted_at IS NULL
+			AND e.started_at >= $3 AND e.started_at < $4
+		GROUP BY `+group.groupBy+`
+		ORDER BY `+group.groupBy,
+		userID,
+		r.workspaceID(),
+		q.From,
+		q.To,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reportRows := []*model.TimeReportRow{}
+
+	for rows.Next() {
+		row := &model.TimeReportRow{}
+		dest := []interface{}{&row.Group, &row.Duration}
+
+		// the rows by list are grouped by the ID of the list as well,
+		// lists may have the same name
+		if q.GroupBy == model.TimeReportByList {
+			dest = []interface{}{&row.Group, &row.ListID, &row.Duration}
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		reportRows = append(reportRows, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return model.NewTimeReport(q, reportRows), nil
+}
+
+func (r *TimeEntryRepository) InWorkspace(workspaceID int) timeentry.TimeEntryRepository {
+	return &TimeEntryRepository{DB: r.DB, workspace: workspaceID}
+}
+
+// workspaceID is the workspace_id of the tasks of the repository, NULL for
+// the personal workspace.
+func (r *TimeEntryRepository) workspaceID() *int {
+	if r.workspace == model.PersonalWorkspaceID {
+		return nil
+	}
+
+	id := r.workspace
+
+	return &id
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanEntry(row scanner) (*model.TimeEntry, error) {
+	e := &model.TimeEntry{}
+
+	if err := row.Scan(
+		&e.ID,
+		&e.UserID,
+		&e.TaskID,
+		&e.TrackerID,
+		&e.StartedAt,
+		&e.StoppedAt,
+		&e.Note,
+		&e.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	e.SetDuration(time.Now().UTC())
+
+	return e, nil
+}
@@ -0,0 +1,24 @@
+package timeentry
+
+import "github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+
+// TimeEntryRepository reads and writes the time entries of the tasks of a
+// single workspace, the personal workspace unless the repository is returned
+// by InWorkspace.
+type TimeEntryRepository interface{
+	// Start creates a running timer of the tracker on a task of the user
+	// that is not in the trash. It fails with store.ErrTimerRunning while
+	// the tracker runs another timer.
+	Start(*model.TimeEntry) error
+	// Stop stops the timer the tracker runs on the task.
+	Stop(int, int, int) (*model.TimeEntry, error)
+	Create(*model.TimeEntry) error
+	FindByTask(int, int) ([]*model.TimeEntry, error)
+	FindByID(int, int, int) (*model.TimeEntry, error)
+	Update(*model.TimeEntry) error
+	Delete(int, int, int) error
+	Report(int, *model.TimeReportQuery) (*model.TimeReport, error)
+	// InWorkspace returns the repository of the time entries of the tasks
+	// of the workspace.
+	InWorkspace(int) TimeEntryRepository
+}
\ No newline at end of file
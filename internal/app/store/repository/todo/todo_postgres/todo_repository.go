@@ -2,61 +2,323 @@ package todo_postgres
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/lib/pq"
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/todo"
+)
+
+var (
+	errNotInTrash    = errors.New("the task is not in the trash")
+	errParentInTrash = errors.New("the parent task is in the trash, restore it first")
+	errUnderItself   = errors.New("a task cannot be moved under itself or its subtasks")
+	errNoParent      = errors.New("parent task not found")
 )
 
 type TodoRepository struct {
 	DB *sql.DB
+
+	// tx is the transaction of the repository handed to the function of
+	// InTx, all the queries of such a repository go through it.
+	tx *sql.Tx
+
+	// workspace is the workspace given to InWorkspace, every query keeps to
+	// the tasks of that workspace.
+	workspace int
 }
 
-func (r *TodoRepository) Get(id int) (*model.Task, error) {
-	t := &model.Task{}
-	
-	rows, err := r.DB.Query(
-		"SELECT user_id, task_id, title, description, deadline, complete FROM tasks WHERE user_id = $1",
-		id,
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+const taskColumns = `user_id, task_id, workspace_id, list_id, parent_task_id, title, description, deadline, complete, rrule, series_id, ical_uid, version, created_at, deleted_at, completed_at,
+	ARRAY(SELECT tg.name FROM task_tags tt JOIN tags tg ON tg.id = tt.tag_id WHERE tt.task_id = tasks.task_id ORDER BY tg.name),
+	ARRAY(SELECT d.blocked_by FROM task_dependencies d JOIN tasks b ON b.task_id = d.blocked_by WHERE d.task_id = tasks.task_id AND b.deleted_at IS NULL ORDER BY d.blocked_by),
+	ARRAY(SELECT d.task_id FROM task_dependencies d JOIN tasks b ON b.task_id = d.task_id WHERE d.blocked_by = tasks.task_id AND b.deleted_at IS NULL ORDER BY d.task_id),
+	(SELECT COALESCE(SUM(EXTRACT(EPOCH FROM COALESCE(e.stopped_at, now() AT TIME ZONE 'utc') - e.started_at)), 0)::bigint FROM time_entries e WHERE e.task_id = tasks.task_id),
+	COALESCE(tasks.status, (
+		SELECT x.s ->> 'name' FROM lists l, jsonb_array_elements(l.workflow -> 'statuses') WITH ORDINALITY x (s, n)
+		WHERE l.id = tasks.list_id AND COALESCE((x.s ->> 'done')::boolean, false) = COALESCE(tasks.complete, false)
+		ORDER BY x.n LIMIT 1
+	), CASE WHEN tasks.complete THEN 'done' ELSE 'todo' END),
+	(SELECT p.ical_uid FROM tasks p WHERE p.task_id = tasks.parent_task_id)`
+
+// sharedTaskIDs selects the IDs of the tasks shared with the user $1: the
+// shared tasks, the tasks of the shared lists and all their subtasks.
+const sharedTaskIDs = `WITH RECURSIVE shared (task_id) AS (
+		SELECT t.task_id FROM tasks t
+		JOIN shares s ON s.owner_id = t.user_id AND (s.task_id = t.task_id OR s.list_id = t.list_id)
+		WHERE s.grantee_id = $1 AND s.status = 'accepted'
+		UNION
+		SELECT t.task_id FROM tasks t JOIN shared ON t.parent_task_id = shared.task_id
+	)
+	SELECT task_id FROM shared`
+
+// sortExpressions maps the sort fields of model.TaskQuery to SQL. Tasks
+// without a deadline sort as if it were later than all the others: last in
+// ascending order, first in descending order.
+var sortExpressions = map[string]string{
+	model.SortDeadline:  "COALESCE(deadline, 'infinity')",
+	model.SortTitle:     "title",
+	model.SortCreatedAt: "created_at",
+}
+
+// Get includes the tasks that other users share with the user.
+func (r *TodoRepository) Get(userID int, q *model.TaskQuery) (*model.TaskPage, error) {
+	conditions := []string{"(user_id = $1 OR task_id IN (" + sharedTaskIDs + "))", "deleted_at IS NULL"}
+	args := []interface{}{userID}
+
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions = append(conditions, "workspace_id IS NOT DISTINCT FROM "+arg(r.workspaceID()))
+
+	if q.Filter.Complete != nil {
+		conditions = append(conditions, "complete = "+arg(*q.Filter.Complete))
+	}
+
+	if q.Filter.ListID != nil {
+		conditions = append(conditions, "list_id = "+arg(*q.Filter.ListID))
+	}
+
+	if q.Filter.DueBefore != nil {
+		conditions = append(conditions, "deadline < "+arg(*q.Filter.DueBefore))
+	}
+
+	if q.Filter.DueAfter != nil {
+		conditions = append(conditions, "deadline > "+arg(*q.Filter.DueAfter))
+	}
+
+	if q.Filter.Overdue != nil {
+		overdue := fmt.Sprintf("(complete = false AND deadline < %s)", arg(time.Now().UTC()))
+		if !*q.Filter.Overdue {
+			overdue = "NOT COALESCE(" + overdue + ", false)"
+		}
+		conditions = append(conditions, overdue)
+	}
+
+	if q.Filter.Text != "" {
+		pattern := arg("%" + escapeLike(q.Filter.Text) + "%")
+		conditions = append(conditions, fmt.Sprintf("(title ILIKE %s OR description ILIKE %s)", pattern, pattern))
+	}
+
+	if len(q.Filter.Tags) > 0 {
+		conditions = append(conditions, fmt.Sprintf(
+			`task_id IN (
+				SELECT tt.task_id FROM task_tags tt JOIN tags tg ON tg.id = tt.tag_id
+				WHERE tg.name = ANY(%s)
+				GROUP BY tt.task_id HAVING count(*) = %s
+			)`,
+			arg(pq.Array(q.Filter.Tags)),
+			arg(len(q.Filter.Tags)),
+		))
+	}
+
+	sortExpr := sortExpressions[q.Sort]
+	direction, comparison := "ASC", ">"
+	if q.Order == model.OrderDesc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if q.Cursor != nil {
+		var value interface{}
+		switch {
+		case q.Sort == model.SortTitle:
+			value = q.Cursor.Title
+		case q.Cursor.Time != nil:
+			value = *q.Cursor.Time
+		default:
+			value = "infinity"
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s, task_id) %s (%s, %s)", sortExpr, comparison, arg(value), arg(q.Cursor.TaskID)))
+	}
+
+	query := fmt.Sprintf(
+		"SELECT %s FROM tasks WHERE %s ORDER BY %s %s, task_id %s LIMIT %s",
+		taskColumns,
+		strings.Join(conditions, " AND "),
+		sortExpr,
+		direction,
+		direction,
+		arg(q.Limit+1),
 	)
+
+	rows, err := r.db().Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	if rows.Next() {
-		err = rows.Scan(
-			&t.UserID,
-			&t.TaskID,
-			&t.Title,
-			&t.Description,
-			&t.Deadline,
-			&t.Complete,
-		)
+	page := &model.TaskPage{Tasks: []*model.Task{}}
+
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		page.Tasks = append(page.Tasks, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(page.Tasks) > q.Limit {
+		page.Tasks = page.Tasks[:q.Limit]
+		page.NextCursor = q.NewCursor(page.Tasks[q.Limit-1]).Encode()
+	}
+
+	return page, nil
+}
+
+func (r *TodoRepository) Search(userID int, text string, limit int) ([]*model.TaskSearchResult, error) {
+	rows, err := r.db().Query(
+		`SELECT `+taskColumns+`,
+			ts_rank(search, query) AS rank,
+			ts_headline('simple', translate(concat_ws(' ', title, description), $5, ''), query, 'StartSel=`+model.SnippetStart+`, StopSel=`+model.SnippetStop+`, MaxFragments=2')
+		FROM tasks, websearch_to_tsquery('simple', $2) query
+		WHERE user_id = $1 AND workspace_id IS NOT DISTINCT FROM $4 AND deleted_at IS NULL AND search @@ query
+		ORDER BY rank DESC, task_id
+		LIMIT $3`,
+		userID,
+		text,
+		limit,
+		r.workspaceID(),
+		model.SnippetStart+model.SnippetStop,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []*model.TaskSearchResult{}
+
+	for rows.Next() {
+		res := &model.TaskSearchResult{}
+		t, err := scanTask(rows, &res.Rank, &res.Snippet)
 		if err != nil {
 			return nil, err
 		}
-	} else {
-		return nil, sql.ErrNoRows
+		res.Task = t
+		res.Snippet = model.NewSnippet(res.Snippet)
+		results = append(results, res)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (r *TodoRepository) FindByID(userID int, taskID int) (*model.Task, error) {
+	t, err := scanTask(r.db().QueryRow(
+		"SELECT "+taskColumns+" FROM tasks WHERE user_id = $1 AND task_id = $2 AND workspace_id IS NOT DISTINCT FROM $3 AND deleted_at IS NULL",
+		userID,
+		taskID,
+		r.workspaceID(),
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+		return nil, err
 	}
 
 	return t, nil
 }
 
+// FindTree returns the task with all its descendants nested as children.
+func (r *TodoRepository) FindTree(userID int, taskID int) (*model.Task, error) {
+	rows, err := r.db().Query(
+		`WITH RECURSIVE subtree AS (
+			SELECT task_id FROM tasks WHERE task_id = $2 AND user_id = $1 AND workspace_id IS NOT DISTINCT FROM $3 AND deleted_at IS NULL
+			UNION
+			SELECT t.task_id FROM tasks t JOIN subtree s ON t.parent_task_id = s.task_id WHERE t.deleted_at IS NULL
+		)
+		SELECT `+taskColumns+` FROM tasks WHERE task_id IN (SELECT task_id FROM subtree)`,
+		userID,
+		taskID,
+		r.workspaceID(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []*model.Task{}
+
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	root := model.NewTaskTree(tasks, taskID)
+	if root == nil {
+		return nil, store.ErrRecordNotFound
+	}
+
+	return root, nil
+}
+
+// Depth returns the level of the task in its tree, a top-level task is at
+// level 1.
+func (r *TodoRepository) Depth(userID int, taskID int) (int, error) {
+	var depth int
+
+	if err := r.db().QueryRow(
+		`WITH RECURSIVE ancestors AS (
+			SELECT task_id, parent_task_id FROM tasks WHERE task_id = $2 AND user_id = $1 AND workspace_id IS NOT DISTINCT FROM $3 AND deleted_at IS NULL
+			UNION
+			SELECT t.task_id, t.parent_task_id FROM tasks t JOIN ancestors a ON t.task_id = a.parent_task_id
+		)
+		SELECT count(*) FROM ancestors`,
+		userID,
+		taskID,
+		r.workspaceID(),
+	).Scan(&depth); err != nil {
+		return 0, err
+	}
+
+	if depth == 0 {
+		return 0, store.ErrRecordNotFound
+	}
+
+	return depth, nil
+}
+
 func (r *TodoRepository) Create(t *model.Task) error {
-	if err := r.DB.QueryRow(
-		"INSERT INTO tasks (user_id, title, description, deadline, complete) VALUES ($1, $2, $3, $4, $5) RETURNING task_id",
-		t.UserID,
-		t.Title,
-		t.Description,
-		t.Deadline,
-		t.Complete,
-	).Scan(&t.TaskID); err != nil {
+	if err := t.BeforeCreate(); err != nil {
 		return err
 	}
 
-	return nil
+	t.WorkspaceID = r.workspaceID()
+	t.BlockedBy, t.Blocking = []int{}, []int{}
+
+	return r.inTx(func(tx *sql.Tx) error {
+		if err := placeTask(tx, t, nil); err != nil {
+			return err
+		}
+
+		return insertTask(tx, t)
+	})
 }
 
 func (r *TodoRepository) Update(t *model.Task) error {
@@ -65,6 +327,18 @@ func (r *TodoRepository) Update(t *model.Task) error {
 	placeholders := []string{}
 	i := 1
 
+	if t.ListID != nil {
+		placeholders = append(placeholders, fmt.Sprintf("list_id = $%d", i))
+		args = append(args, *t.ListID)
+		i++
+	}
+
+	if t.ParentTaskID != nil || t.ClearParent {
+		placeholders = append(placeholders, fmt.Sprintf("parent_task_id = $%d", i))
+		args = append(args, t.ParentTaskID)
+		i++
+	}
+
 	if t.Title != nil {
 		placeholders = append(placeholders, fmt.Sprintf("title = $%d", i))
 		args = append(args, *t.Title)
@@ -84,42 +358,235 @@ func (r *TodoRepository) Update(t *model.Task) error {
 	}
 
 	if t.Complete != nil {
+		// the moment of the completion is kept until the task is reopened,
+		// a status that no longer agrees with the flag is dropped
 		placeholders = append(placeholders, fmt.Sprintf("complete = $%d", i))
+		placeholders = append(placeholders, fmt.Sprintf("completed_at = CASE WHEN $%d THEN COALESCE(completed_at, now() AT TIME ZONE 'utc') END", i))
+		if t.Status == nil {
+			placeholders = append(placeholders, fmt.Sprintf("status = CASE WHEN complete IS DISTINCT FROM $%d THEN NULL ELSE status END", i))
+		}
 		args = append(args, *t.Complete)
 		i++
 	}
 
-	if len(placeholders) == 0 {
-		return nil
+	if t.Status != nil {
+		placeholders = append(placeholders, fmt.Sprintf("status = $%d", i))
+		args = append(args, *t.Status)
+		i++
 	}
 
-	query += strings.Join(placeholders, ", ")
-	query += fmt.Sprintf(" WHERE task_id = $%d AND user_id = $%d", i, i+1)
-	args = append(args, t.TaskID, t.UserID)
+	if t.RRule != nil {
+		// a rule set on an existing task starts its series, the series of
+		// a task that already has one is kept
+		if err := t.BeforeCreate(); err != nil {
+			return err
+		}
+
+		placeholders = append(placeholders, fmt.Sprintf("rrule = NULLIF($%d, '')", i))
+		args = append(args, *t.RRule)
+		i++
 
-	_, err := r.DB.Exec(query, args...)
-	if err != nil {
+		if t.SeriesID != nil {
+			placeholders = append(placeholders, fmt.Sprintf("series_id = COALESCE(series_id, $%d)", i))
+			args = append(args, *t.SeriesID)
+			i++
+		}
+	}
+
+	return r.inTx(func(tx *sql.Tx) error {
+		before, err := lockTask(tx, t.UserID, t.TaskID, r.workspaceID())
+		if err != nil {
+			return err
+		}
+
+		if err := checkVersion(t, before); err != nil {
+			return err
+		}
+
+		t.WorkspaceID = before.WorkspaceID
+		t.Version = before.Version
+
+		if err := placeTask(tx, t, before); err != nil {
+			return err
+		}
+
+		if t.Complete != nil && *t.Complete && (before.Complete == nil || !*before.Complete) && !t.ForceComplete {
+			if err := checkBlockers(tx, t.TaskID); err != nil {
+				return err
+			}
+		}
+
+		if len(placeholders) == 0 && t.Tags == nil {
+			return nil
+		}
+
+		placeholders = append(placeholders, "version = version + 1")
+		query += strings.Join(placeholders, ", ")
+		query += fmt.Sprintf(" WHERE task_id = $%d AND user_id = $%d RETURNING version", i, i+1)
+		args = append(args, t.TaskID, t.UserID)
+
+		if err := tx.QueryRow(query, args...).Scan(&t.Version); err != nil {
+			return err
+		}
+
+		if t.Tags != nil {
+			if err := setTaskTags(tx, t); err != nil {
+				return err
+			}
+		}
+
+		if t.CompleteSubtasks {
+			if err := completeSubtasks(tx, t); err != nil {
+				return err
+			}
+		}
+
+		return afterChange(tx, t, before)
+	})
+}
+
+func (r *TodoRepository) Replace(t *model.Task) error {
+	if err := t.BeforeCreate(); err != nil {
 		return err
 	}
 
-	return nil
+	return r.inTx(func(tx *sql.Tx) error {
+		before, err := lockTask(tx, t.UserID, t.TaskID, r.workspaceID())
+		if err != nil {
+			return err
+		}
+
+		if err := checkVersion(t, before); err != nil {
+			return err
+		}
+
+		t.WorkspaceID = before.WorkspaceID
+		t.BlockedBy, t.Blocking = before.BlockedBy, before.Blocking
+
+		if err := placeTask(tx, t, before); err != nil {
+			return err
+		}
+
+		if t.Complete != nil && *t.Complete && (before.Complete == nil || !*before.Complete) && !t.ForceComplete {
+			if err := checkBlockers(tx, t.TaskID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.QueryRow(
+			`UPDATE tasks SET list_id = $1, parent_task_id = $2, title = $3, description = $4, deadline = $5, complete = $6,
+				completed_at = CASE WHEN $6 THEN COALESCE(completed_at, now() AT TIME ZONE 'utc') END,
+				status = COALESCE($11, CASE WHEN complete IS DISTINCT FROM $6 THEN NULL ELSE status END),
+				rrule = NULLIF($7, ''), series_id = COALESCE(series_id, $8), version = version + 1
+			WHERE task_id = $9 AND user_id = $10 RETURNING series_id, version, created_at, completed_at`,
+			t.ListID,
+			t.ParentTaskID,
+			t.Title,
+			t.Description,
+			t.Deadline,
+			t.Complete,
+			t.RRule,
+			t.SeriesID,
+			t.TaskID,
+			t.UserID,
+			t.Status,
+		).Scan(&t.SeriesID, &t.Version, &t.CreatedAt, &t.CompletedAt); err != nil {
+			return err
+		}
+
+		if err := setTaskTags(tx, t); err != nil {
+			return err
+		}
+
+		return afterChange(tx, t, before)
+	})
 }
 
-func (r *TodoRepository) Delete(userID int, taskIDs []int) (int64, error) {
+func (r *TodoRepository) Delete(userID int, taskIDs []int, actorID int) (int64, error) {
+	return r.delete(userID, taskIDs, nil, actorID)
+}
+
+// DeleteVersion moves the task to the trash if it still has the version.
+func (r *TodoRepository) DeleteVersion(userID int, taskID int, version int, actorID int) (int64, error) {
+	return r.delete(userID, []int{taskID}, &version, actorID)
+}
+
+// delete moves the tasks and their subtasks to the trash. With a version
+// there is a single task to delete and it has to have that version.
+func (r *TodoRepository) delete(userID int, taskIDs []int, version *int, actorID int) (int64, error) {
 	if len(taskIDs) == 0 {
 		return 0, nil
 	}
-	
-	res, err := r.DB.Exec(
-		"DELETE FROM tasks WHERE user_id = $1 AND task_id = ANY($2)",
-		userID,
-		pq.Array(taskIDs),
-	)
-	if err != nil {
-		return 0, err
-	}
 
-	count, err := res.RowsAffected()
+	var count int64
+
+	err := r.inTx(func(tx *sql.Tx) error {
+		// the subtasks go to the trash together with their parent
+		rows, err := tx.Query(
+			`WITH RECURSIVE subtree AS (
+				SELECT task_id FROM tasks WHERE user_id = $1 AND task_id = ANY($2) AND workspace_id IS NOT DISTINCT FROM $3 AND deleted_at IS NULL
+				UNION
+				SELECT t.task_id FROM tasks t JOIN subtree s ON t.parent_task_id = s.task_id WHERE t.deleted_at IS NULL
+			)
+			SELECT `+taskColumns+` FROM tasks WHERE task_id IN (SELECT task_id FROM subtree) ORDER BY task_id FOR UPDATE`,
+			userID,
+			pq.Array(taskIDs),
+			r.workspaceID(),
+		)
+		if err != nil {
+			return err
+		}
+
+		deleted := []*model.Task{}
+		for rows.Next() {
+			t, err := scanTask(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			deleted = append(deleted, t)
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		ids := []int{}
+		for _, t := range deleted {
+			ids = append(ids, t.TaskID)
+
+			if version != nil && t.TaskID == taskIDs[0] {
+				if err := checkVersion(&model.Task{IfVersion: version}, t); err != nil {
+					return err
+				}
+			}
+		}
+
+		if _, err := tx.Exec(
+			"UPDATE tasks SET deleted_at = (now() AT TIME ZONE 'utc'), version = version + 1 WHERE task_id = ANY($1)",
+			pq.Array(ids),
+		); err != nil {
+			return err
+		}
+
+		requested := map[int]bool{}
+		for _, id := range taskIDs {
+			requested[id] = true
+		}
+
+		for _, t := range deleted {
+			if requested[t.TaskID] {
+				count++
+			}
+
+			if err := recordEvent(tx, model.NewTaskEvent(actorID, t, nil)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 	if err != nil {
 		return 0, err
 	}
@@ -127,32 +594,990 @@ func (r *TodoRepository) Delete(userID int, taskIDs []int) (int64, error) {
 	return count, nil
 }
 
-func (r *TodoRepository) FindTaskByTaskID(userID int, taskIDs []int) error {
-	rows, err := r.DB.Query(
-		"SELECT task_id FROM tasks WHERE user_id = $1 AND task_id = ANY($2)",
+// Trash returns the deleted tasks of the user, the most recently deleted
+// first.
+func (r *TodoRepository) Trash(userID int) ([]*model.Task, error) {
+	rows, err := r.db().Query(
+		"SELECT "+taskColumns+" FROM tasks WHERE user_id = $1 AND workspace_id IS NOT DISTINCT FROM $2 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC, task_id",
 		userID,
-		pq.Array(taskIDs),
+		r.workspaceID(),
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer rows.Close()
 
-	foundIDs := map[int]bool{}
+	tasks := []*model.Task{}
 
 	for rows.Next() {
-		var id int
-		if err := rows.Scan(&id); err != nil {
-			return err
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, err
 		}
-		foundIDs[id] = true
+		tasks = append(tasks, t)
 	}
 
-	for _, id := range taskIDs {
-		if !foundIDs[id] {
-			return fmt.Errorf("task with id %d not found", id)
+	return tasks, rows.Err()
+}
+
+// Restore takes the task out of the trash together with the subtasks that
+// were deleted with it.
+func (r *TodoRepository) Restore(userID int, taskID int, actorID int) error {
+	return r.inTx(func(tx *sql.Tx) error {
+		var deletedAt *time.Time
+		var parentDeleted bool
+
+		if err := tx.QueryRow(
+			`SELECT t.deleted_at, p.deleted_at IS NOT NULL
+			FROM tasks t LEFT JOIN tasks p ON p.task_id = t.parent_task_id
+			WHERE t.user_id = $1 AND t.task_id = $2 AND t.workspace_id IS NOT DISTINCT FROM $3
+			FOR UPDATE OF t`,
+			userID,
+			taskID,
+			r.workspaceID(),
+		).Scan(&deletedAt, &parentDeleted); err != nil {
+			if err == sql.ErrNoRows {
+				return store.ErrRecordNotFound
+			}
+			return err
+		}
+
+		if deletedAt == nil {
+			return errNotInTrash
+		}
+
+		if parentDeleted {
+			return errParentInTrash
+		}
+
+		// the UIDs of the deleted tasks may have been given to new tasks
+		var uidTaken bool
+		if err := tx.QueryRow(
+			`WITH RECURSIVE subtree AS (
+				SELECT task_id FROM tasks WHERE task_id = $1
+				UNION
+				SELECT t.task_id FROM tasks t JOIN subtree s ON t.parent_task_id = s.task_id WHERE t.deleted_at = $2
+			)
+			SELECT EXISTS (
+				SELECT 1 FROM tasks t JOIN tasks other ON other.user_id = t.user_id AND other.ical_uid = t.ical_uid
+				WHERE t.task_id IN (SELECT task_id FROM subtree) AND other.deleted_at IS NULL
+			)`,
+			taskID,
+			*deletedAt,
+		).Scan(&uidTaken); err != nil {
+			return err
+		}
+
+		if uidTaken {
+			return store.ErrUIDConflict
+		}
+
+		rows, err := tx.Query(
+			`WITH RECURSIVE subtree AS (
+				SELECT task_id FROM tasks WHERE task_id = $1
+				UNION
+				SELECT t.task_id FROM tasks t JOIN subtree s ON t.parent_task_id = s.task_id WHERE t.deleted_at = $2
+			)
+			UPDATE tasks SET deleted_at = NULL, version = version + 1 WHERE task_id IN (SELECT task_id FROM subtree) RETURNING task_id`,
+			taskID,
+			*deletedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		restored := []int{}
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			restored = append(restored, id)
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, id := range restored {
+			e := model.NewTaskRestoredEvent(actorID, &model.Task{UserID: userID, TaskID: id, WorkspaceID: r.workspaceID()})
+			if err := recordEvent(tx, e); err != nil {
+				return err
+			}
 		}
+
+		return nil
+	})
+}
+
+// Purge permanently removes the tasks of all the users in all the
+// workspaces that were deleted before the given moment. The keys are read in
+// the statement that deletes the tasks, an attachment added in between
+// cannot be left without its blob being removed.
+func (r *TodoRepository) Purge(before time.Time) (int64, []string, error) {
+	rows, err := r.db().Query(
+		`WITH purged AS (
+			DELETE FROM tasks WHERE deleted_at < $1 RETURNING task_id
+		)
+		SELECT p.task_id, a.blob_key FROM purged p LEFT JOIN attachments a ON a.task_id = p.task_id`,
+		before,
+	)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	purged := map[int]bool{}
+	keys := []string{}
+
+	for rows.Next() {
+		var (
+			taskID int
+			key    *string
+		)
+		if err := rows.Scan(&taskID, &key); err != nil {
+			return 0, nil, err
+		}
+
+		purged[taskID] = true
+		if key != nil {
+			keys = append(keys, *key)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+
+	return int64(len(purged)), keys, nil
+}
+
+func (r *TodoRepository) Export(userID int, fn func(*model.Task) error) error {
+	rows, err := r.db().Query(
+		"SELECT "+taskColumns+" FROM tasks WHERE user_id = $1 AND workspace_id IS NOT DISTINCT FROM $2 AND deleted_at IS NULL ORDER BY task_id",
+		userID,
+		r.workspaceID(),
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *TodoRepository) FindByICalUID(userID int, uid string) (*model.Task, error) {
+	t, err := scanTask(r.db().QueryRow(
+		"SELECT "+taskColumns+" FROM tasks WHERE user_id = $1 AND ical_uid = $2 AND workspace_id IS NOT DISTINCT FROM $3 AND deleted_at IS NULL",
+		userID,
+		uid,
+		r.workspaceID(),
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// SyncToken is the last event of the tasks of the user in the workspace.
+func (r *TodoRepository) SyncToken(userID int) (int, error) {
+	var token int
+
+	err := r.db().QueryRow(
+		"SELECT COALESCE(MAX(id), 0) FROM task_events WHERE user_id = $1 AND workspace_id IS NOT DISTINCT FROM $2",
+		userID,
+		r.workspaceID(),
+	).Scan(&token)
+
+	return token, err
+}
+
+// Changes finds the tasks with events after the sync token. A task that is
+// gone for good after the purge of the trash is still reported as deleted.
+func (r *TodoRepository) Changes(userID int, since int) (*model.TaskChanges, error) {
+	token, err := r.SyncToken(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := &model.TaskChanges{Token: token, Changed: []*model.Task{}, Deleted: []*model.Task{}}
+
+	rows, err := r.db().Query(
+		"SELECT "+taskColumns+` FROM tasks
+		WHERE user_id = $1 AND workspace_id IS NOT DISTINCT FROM $4 AND deleted_at IS NULL AND task_id IN (
+			SELECT task_id FROM task_events WHERE user_id = $1 AND workspace_id IS NOT DISTINCT FROM $4 AND id > $2 AND id <= $3
+		)
+		ORDER BY task_id`,
+		userID,
+		since,
+		token,
+		r.workspaceID(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		changes.Changed = append(changes.Changed, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.db().Query(
+		`SELECT DISTINCT e.task_id, t.ical_uid FROM task_events e
+		LEFT JOIN tasks t ON t.task_id = e.task_id
+		WHERE e.user_id = $1 AND e.workspace_id IS NOT DISTINCT FROM $4 AND e.id > $2 AND e.id <= $3
+			AND (t.task_id IS NULL OR t.deleted_at IS NOT NULL)
+		ORDER BY e.task_id`,
+		userID,
+		since,
+		token,
+		r.workspaceID(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t := &model.Task{UserID: userID}
+		if err := rows.Scan(&t.TaskID, &t.ICalUID); err != nil {
+			return nil, err
+		}
+		changes.Deleted = append(changes.Deleted, t)
+	}
+
+	return changes, rows.Err()
+}
+
+// History finds the task in the trash as well, a task stored before its
+// history was recorded has no events.
+func (r *TodoRepository) History(userID int, taskID int) ([]*model.TaskEvent, error) {
+	var exists bool
+	if err := r.db().QueryRow(
+		"SELECT EXISTS (SELECT 1 FROM tasks WHERE task_id = $1 AND user_id = $2 AND workspace_id IS NOT DISTINCT FROM $3)",
+		taskID,
+		userID,
+		r.workspaceID(),
+	).Scan(&exists); err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, store.ErrRecordNotFound
+	}
+
+	rows, err := r.db().Query(
+		`SELECT id, user_id, task_id, actor_id, type, changes, created_at FROM task_events
+		WHERE user_id = $1 AND task_id = $2 ORDER BY id`,
+		userID,
+		taskID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*model.TaskEvent{}
+
+	for rows.Next() {
+		e := &model.TaskEvent{}
+		var changes []byte
+		if err := rows.Scan(&e.ID, &e.UserID, &e.TaskID, &e.ActorID, &e.Type, &changes, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(changes, &e.Changes); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (r *TodoRepository) FindTaskByTaskID(userID int, taskIDs []int) error {
+	rows, err := r.db().Query(
+		"SELECT task_id FROM tasks WHERE user_id = $1 AND task_id = ANY($2) AND workspace_id IS NOT DISTINCT FROM $3 AND deleted_at IS NULL",
+		userID,
+		pq.Array(taskIDs),
+		r.workspaceID(),
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	foundIDs := map[int]bool{}
+
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		foundIDs[id] = true
+	}
+
+	for _, id := range taskIDs {
+		if !foundIDs[id] {
+			return fmt.Errorf("task with id %d not found", id)
+		}
+	}
+
+	return nil
+}
+
+// AddDependency makes the task blocked by another task of the workspace. An
+// edge that would close a cycle fails with store.ErrDependencyCycle.
+func (r *TodoRepository) AddDependency(userID int, taskID int, blockedByID int) error {
+	if taskID == blockedByID {
+		return store.ErrDependencyCycle
+	}
+
+	return r.inTx(func(tx *sql.Tx) error {
+		// two edges added at the same time could close a cycle that neither
+		// of them sees on its own
+		if _, err := tx.Exec("LOCK TABLE task_dependencies IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+			return err
+		}
+
+		for _, id := range []int{taskID, blockedByID} {
+			if _, err := lockTask(tx, userID, id, r.workspaceID()); err != nil {
+				return err
+			}
+		}
+
+		var cycle bool
+		if err := tx.QueryRow(
+			`WITH RECURSIVE blockers (task_id) AS (
+				SELECT blocked_by FROM task_dependencies WHERE task_id = $1
+				UNION
+				SELECT d.blocked_by FROM task_dependencies d JOIN blockers ON d.task_id = blockers.task_id
+			)
+			SELECT EXISTS (SELECT 1 FROM blockers WHERE task_id = $2)`,
+			blockedByID,
+			taskID,
+		).Scan(&cycle); err != nil {
+			return err
+		}
+
+		if cycle {
+			return store.ErrDependencyCycle
+		}
+
+		_, err := tx.Exec(
+			"INSERT INTO task_dependencies (task_id, blocked_by) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+			taskID,
+			blockedByID,
+		)
+		return err
+	})
+}
+
+func (r *TodoRepository) RemoveDependency(userID int, taskID int, blockedByID int) error {
+	res, err := r.db().Exec(
+		`DELETE FROM task_dependencies d USING tasks t
+		WHERE d.task_id = $2 AND d.blocked_by = $3 AND t.task_id = d.task_id
+			AND t.user_id = $1 AND t.workspace_id IS NOT DISTINCT FROM $4 AND t.deleted_at IS NULL`,
+		userID,
+		taskID,
+		blockedByID,
+		r.workspaceID(),
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return store.ErrRecordNotFound
 	}
 
 	return nil
 }
+
+func (r *TodoRepository) Stats(userID int, q *model.TaskStatsQuery) (*model.TaskStats, error) {
+	if err := q.Validation(); err != nil {
+		return nil, err
+	}
+
+	stats := &model.TaskStats{Windows: []*model.TaskStatsWindow{}}
+
+	if err := r.db().QueryRow(
+		`SELECT
+			count(*) FILTER (WHERE deleted_at IS NULL),
+			count(*) FILTER (WHERE deleted_at IS NULL AND NOT COALESCE(complete, false)),
+			count(*) FILTER (WHERE deleted_at IS NULL AND complete),
+			count(*) FILTER (WHERE deleted_at IS NULL AND NOT COALESCE(complete, false) AND deadline < $3),
+			count(*) FILTER (WHERE deleted_at IS NOT NULL),
+			avg(EXTRACT(EPOCH FROM completed_at - created_at)) FILTER (WHERE deleted_at IS NULL AND complete AND completed_at IS NOT NULL)
+		FROM tasks WHERE user_id = $1 AND workspace_id IS NOT DISTINCT FROM $2`,
+		userID,
+		r.workspaceID(),
+		q.Now,
+	).Scan(&stats.Total, &stats.Open, &stats.Complete, &stats.Overdue, &stats.Trashed, &stats.AverageLeadTime); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db().Query(
+		`SELECT w.days,
+			count(t.task_id) FILTER (WHERE t.created_at >= $3::timestamp - make_interval(days => w.days)),
+			count(t.task_id) FILTER (WHERE t.created_at >= $3::timestamp - make_interval(days => w.days) AND t.complete),
+			count(t.task_id) FILTER (WHERE t.completed_at >= $3::timestamp - make_interval(days => w.days))
+		FROM unnest($4::int[]) w (days)
+		LEFT JOIN tasks t ON t.user_id = $1 AND t.workspace_id IS NOT DISTINCT FROM $2 AND t.deleted_at IS NULL
+		GROUP BY w.days
+		ORDER BY w.days`,
+		userID,
+		r.workspaceID(),
+		q.Now,
+		pq.Array(q.Windows),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var days, created, createdComplete, completed int
+		if err := rows.Scan(&days, &created, &createdComplete, &completed); err != nil {
+			return nil, err
+		}
+		stats.Windows = append(stats.Windows, model.NewTaskStatsWindow(days, created, createdComplete, completed))
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// the days with a completion in a row share the same distance to their
+	// rank, each such island is a streak
+	if err := r.db().QueryRow(
+		`WITH days AS (
+			SELECT DISTINCT completed_at::date AS day FROM tasks
+			WHERE user_id = $1 AND workspace_id IS NOT DISTINCT FROM $2 AND deleted_at IS NULL AND completed_at IS NOT NULL
+		), streaks AS (
+			SELECT max(day) AS last_day, count(*) AS length
+			FROM (SELECT day, day - (row_number() OVER (ORDER BY day))::int AS island FROM days) d
+			GROUP BY island
+		)
+		SELECT
+			COALESCE(max(length) FILTER (WHERE last_day >= ($3::timestamp)::date - 1), 0),
+			COALESCE(max(length), 0)
+		FROM streaks`,
+		userID,
+		r.workspaceID(),
+		q.Now,
+	).Scan(&stats.Streak.Current, &stats.Streak.Longest); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// InTx runs fn with a repository whose queries all belong to a single
+// transaction. It is committed when fn returns nil and rolled back
+// otherwise. A write of that repository that fails is undone on its own, fn
+// may go on with the other writes.
+func (r *TodoRepository) InTx(fn func(todo.TodoRepository) error) error {
+	if r.tx != nil {
+		return fn(r)
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&TodoRepository{DB: r.DB, tx: tx, workspace: r.workspace}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *TodoRepository) InWorkspace(workspaceID int) todo.TodoRepository {
+	return &TodoRepository{DB: r.DB, tx: r.tx, workspace: workspaceID}
+}
+
+func (r *TodoRepository) Workspace() int {
+	return r.workspace
+}
+
+// workspaceID is the workspace_id of the tasks of the repository, NULL for
+// the personal workspace.
+func (r *TodoRepository) workspaceID() *int {
+	if r.workspace == model.PersonalWorkspaceID {
+		return nil
+	}
+
+	id := r.workspace
+
+	return &id
+}
+
+func (r *TodoRepository) db() querier {
+	if r.tx != nil {
+		return r.tx
+	}
+
+	return r.DB
+}
+
+// inTx runs a write in a transaction of its own, or in a savepoint of the
+// transaction of the repository.
+func (r *TodoRepository) inTx(fn func(tx *sql.Tx) error) error {
+	if r.tx != nil {
+		if _, err := r.tx.Exec("SAVEPOINT todo_write"); err != nil {
+			return err
+		}
+
+		if err := fn(r.tx); err != nil {
+			r.tx.Exec("ROLLBACK TO SAVEPOINT todo_write")
+			return err
+		}
+
+		_, err := r.tx.Exec("RELEASE SAVEPOINT todo_write")
+		return err
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// setTaskTags replaces the tags of the task, creating the tags of the user
+// that do not exist yet.
+func setTaskTags(tx *sql.Tx, t *model.Task) error {
+	t.Tags = model.NormalizeTags(t.Tags)
+
+	if _, err := tx.Exec("DELETE FROM task_tags WHERE task_id = $1", t.TaskID); err != nil {
+		return err
+	}
+
+	if len(t.Tags) == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(
+		"INSERT INTO tags (user_id, name) SELECT $1, unnest($2::varchar[]) ON CONFLICT (user_id, name) DO NOTHING",
+		t.UserID,
+		pq.Array(t.Tags),
+	); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(
+		"INSERT INTO task_tags (task_id, tag_id) SELECT $1, id FROM tags WHERE user_id = $2 AND name = ANY($3)",
+		t.TaskID,
+		t.UserID,
+		pq.Array(t.Tags),
+	)
+
+	return err
+}
+
+func insertTask(tx *sql.Tx, t *model.Task) error {
+	if t.RRule != nil && *t.RRule == "" {
+		t.RRule = nil
+	}
+
+	if err := tx.QueryRow(
+		"INSERT INTO tasks (user_id, list_id, parent_task_id, title, description, deadline, complete, completed_at, rrule, series_id, ical_uid, workspace_id, status) VALUES ($1, $2, $3, $4, $5, $6, $7, CASE WHEN $7 THEN now() AT TIME ZONE 'utc' END, NULLIF($8, ''), $9, $10, $11, $12) RETURNING task_id, version, created_at, completed_at",
+		t.UserID,
+		t.ListID,
+		t.ParentTaskID,
+		t.Title,
+		t.Description,
+		t.Deadline,
+		t.Complete,
+		t.RRule,
+		t.SeriesID,
+		t.ICalUID,
+		t.WorkspaceID,
+		t.Status,
+	).Scan(&t.TaskID, &t.Version, &t.CreatedAt, &t.CompletedAt); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return store.ErrUIDConflict
+		}
+		return err
+	}
+
+	if err := setTaskTags(tx, t); err != nil {
+		return err
+	}
+
+	return recordEvent(tx, model.NewTaskEvent(t.ActorID, nil, t))
+}
+
+// lockTask reads the task of the workspace for the rest of the transaction.
+func lockTask(tx *sql.Tx, userID int, taskID int, workspaceID *int) (*model.Task, error) {
+	t, err := scanTask(tx.QueryRow(
+		"SELECT "+taskColumns+" FROM tasks WHERE user_id = $1 AND task_id = $2 AND workspace_id IS NOT DISTINCT FROM $3 AND deleted_at IS NULL FOR UPDATE",
+		userID,
+		taskID,
+		workspaceID,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// placeTask checks again, with the rows locked, that the task can go under
+// its new parent: the handlers check the tree before the transaction and it
+// may have changed since. The ancestors of the parent are locked one by one
+// up to the root and the subtree of a stored task all at once, so that two
+// tasks cannot be moved under each other at the same time.
+func placeTask(tx *sql.Tx, t *model.Task, before *model.Task) error {
+	if t.ParentTaskID == nil || (before != nil && before.ParentTaskID != nil && *before.ParentTaskID == *t.ParentTaskID) {
+		return nil
+	}
+
+	depth := 0
+	seen := map[int]bool{}
+
+	for id := t.ParentTaskID; id != nil; depth++ {
+		if *id == t.TaskID || seen[*id] {
+			return errUnderItself
+		}
+		seen[*id] = true
+
+		var parentID *int
+		if err := tx.QueryRow(
+			"SELECT parent_task_id FROM tasks WHERE task_id = $1 AND user_id = $2 AND workspace_id IS NOT DISTINCT FROM $3 AND deleted_at IS NULL FOR UPDATE",
+			*id,
+			t.UserID,
+			t.WorkspaceID,
+		).Scan(&parentID); err != nil {
+			if err == sql.ErrNoRows {
+				return errNoParent
+			}
+			return err
+		}
+
+		id = parentID
+	}
+
+	height := 1
+
+	if before != nil {
+		rows, err := tx.Query(
+			`WITH RECURSIVE subtree AS (
+				SELECT task_id, 1 AS level FROM tasks WHERE task_id = $1
+				UNION
+				SELECT t.task_id, s.level + 1 FROM tasks t JOIN subtree s ON t.parent_task_id = s.task_id
+				WHERE t.deleted_at IS NULL AND s.level <= $2
+			)
+			SELECT s.level FROM tasks t JOIN subtree s ON s.task_id = t.task_id ORDER BY t.task_id FOR UPDATE OF t`,
+			t.TaskID,
+			model.MaxTaskDepth,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var level int
+			if err := rows.Scan(&level); err != nil {
+				return err
+			}
+			if level > height {
+				height = level
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			return err
+		}
+	}
+
+	if depth+height > model.MaxTaskDepth {
+		return fmt.Errorf("subtasks cannot be nested deeper than %d levels", model.MaxTaskDepth)
+	}
+
+	return nil
+}
+
+// checkBlockers fails with store.ErrTaskBlocked while a task that blocks the
+// task is still open.
+func checkBlockers(tx *sql.Tx, taskID int) error {
+	var blocked bool
+	if err := tx.QueryRow(
+		`SELECT EXISTS (
+			SELECT 1 FROM task_dependencies d JOIN tasks b ON b.task_id = d.blocked_by
+			WHERE d.task_id = $1 AND NOT COALESCE(b.complete, false) AND b.deleted_at IS NULL
+		)`,
+		taskID,
+	).Scan(&blocked); err != nil {
+		return err
+	}
+
+	if blocked {
+		return store.ErrTaskBlocked
+	}
+
+	return nil
+}
+
+// checkVersion fails when the writer of t expects another version than the
+// one of the stored task.
+func checkVersion(t *model.Task, stored *model.Task) error {
+	if t.IfVersion != nil && *t.IfVersion != stored.Version {
+		return store.ErrVersionConflict
+	}
+
+	return nil
+}
+
+// afterChange records the change of t in its history and creates the next
+// occurrence of a recurring task that has just been completed, its ID goes
+// to t.NextTaskID.
+func afterChange(tx *sql.Tx, t *model.Task, before *model.Task) error {
+	after, err := lockTask(tx, before.UserID, before.TaskID, before.WorkspaceID)
+	if err != nil {
+		return err
+	}
+
+	if err := recordEvent(tx, model.NewTaskEvent(t.ActorID, before, after)); err != nil {
+		return err
+	}
+
+	if (before.Complete != nil && *before.Complete) || after.Complete == nil || !*after.Complete {
+		return nil
+	}
+
+	next, err := after.NextOccurrence(time.Now().UTC())
+	if err != nil || next == nil {
+		return err
+	}
+
+	next.ActorID = t.ActorID
+
+	if err := insertTask(tx, next); err != nil {
+		return err
+	}
+
+	t.NextTaskID = next.TaskID
+
+	// the next occurrence is reminded of the same way, none of its
+	// reminders has been sent
+	_, err = tx.Exec(
+		`INSERT INTO reminders (user_id, task_id, offset_seconds)
+		SELECT user_id, $2, offset_seconds FROM reminders WHERE task_id = $1`,
+		after.TaskID,
+		next.TaskID,
+	)
+
+	return err
+}
+
+// subtasks selects the IDs of the descendants of the task $1 of the user $2
+// in the workspace $3 that are not in the trash.
+const subtasks = `WITH RECURSIVE subtree AS (
+			SELECT task_id FROM tasks WHERE parent_task_id = $1 AND user_id = $2 AND workspace_id IS NOT DISTINCT FROM $3 AND deleted_at IS NULL
+			UNION
+			SELECT t.task_id FROM tasks t JOIN subtree s ON t.parent_task_id = s.task_id WHERE t.deleted_at IS NULL
+		)`
+
+// completeSubtasks marks all the descendants of the task complete, they
+// take the default done status of their list. Unless the task is forced
+// complete, it fails with store.ErrTaskBlocked when an open descendant is
+// blocked by an open task outside of the completed subtree.
+func completeSubtasks(tx *sql.Tx, t *model.Task) error {
+	if !t.ForceComplete {
+		var blocked bool
+		if err := tx.QueryRow(
+			subtasks+`
+			SELECT EXISTS (
+				SELECT 1 FROM task_dependencies d
+				JOIN tasks sub ON sub.task_id = d.task_id
+				JOIN tasks b ON b.task_id = d.blocked_by
+				WHERE d.task_id IN (SELECT task_id FROM subtree) AND NOT COALESCE(sub.complete, false)
+					AND NOT COALESCE(b.complete, false) AND b.deleted_at IS NULL
+					AND b.task_id <> $1 AND b.task_id NOT IN (SELECT task_id FROM subtree)
+			)`,
+			t.TaskID,
+			t.UserID,
+			t.WorkspaceID,
+		).Scan(&blocked); err != nil {
+			return err
+		}
+
+		if blocked {
+			return store.ErrTaskBlocked
+		}
+	}
+
+	rows, err := tx.Query(
+		subtasks+`
+		UPDATE tasks SET complete = true, completed_at = now() AT TIME ZONE 'utc', status = NULL, version = version + 1 WHERE task_id IN (SELECT task_id FROM subtree) AND NOT complete
+		RETURNING task_id`,
+		t.TaskID,
+		t.UserID,
+		t.WorkspaceID,
+	)
+	if err != nil {
+		return err
+	}
+
+	var completed []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		completed = append(completed, id)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	t.CompletedSubtaskIDs = completed
+
+	incomplete, complete := false, true
+	for _, id := range completed {
+		e := model.NewTaskEvent(
+			t.ActorID,
+			&model.Task{UserID: t.UserID, TaskID: id, WorkspaceID: t.WorkspaceID, Complete: &incomplete},
+			&model.Task{UserID: t.UserID, TaskID: id, WorkspaceID: t.WorkspaceID, Complete: &complete},
+		)
+		if err := recordEvent(tx, e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordEvent writes the event to the history of its task, a nil event is
+// skipped.
+func recordEvent(tx *sql.Tx, e *model.TaskEvent) error {
+	if e == nil {
+		return nil
+	}
+
+	changes, err := json.Marshal(e.Changes)
+	if err != nil {
+		return err
+	}
+
+	return tx.QueryRow(
+		"INSERT INTO task_events (user_id, task_id, actor_id, type, changes, workspace_id) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at",
+		e.UserID,
+		e.TaskID,
+		e.ActorID,
+		e.Type,
+		changes,
+		e.WorkspaceID,
+	).Scan(&e.ID, &e.CreatedAt)
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTask reads the taskColumns of a row followed by the extra columns, if
+// the query selects any.
+func scanTask(row scanner, extra ...interface{}) (*model.Task, error) {
+	t := &model.Task{}
+	var blockedBy, blocking []int64
+
+	dest := []interface{}{
+		&t.UserID,
+		&t.TaskID,
+		&t.WorkspaceID,
+		&t.ListID,
+		&t.ParentTaskID,
+		&t.Title,
+		&t.Description,
+		&t.Deadline,
+		&t.Complete,
+		&t.RRule,
+		&t.SeriesID,
+		&t.ICalUID,
+		&t.Version,
+		&t.CreatedAt,
+		&t.DeletedAt,
+		&t.CompletedAt,
+		pq.Array(&t.Tags),
+		(*pq.Int64Array)(&blockedBy),
+		(*pq.Int64Array)(&blocking),
+		&t.TimeSpent,
+		&t.Status,
+		&t.ParentICalUID,
+	}
+
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	t.BlockedBy = taskIDs(blockedBy)
+	t.Blocking = taskIDs(blocking)
+
+	return t, nil
+}
+
+func taskIDs(ids []int64) []int {
+	res := make([]int, len(ids))
+	for i, id := range ids {
+		res[i] = int(id)
+	}
+
+	return res
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -1,10 +1,60 @@
 package todo
 
-import "github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+import (
+	"time"
 
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+)
+
+// TodoRepository reads and writes the tasks of a single workspace, the
+// personal workspace unless the repository is returned by InWorkspace. The
+// tasks of the other workspaces are never found, only Purge goes through
+// the tasks of all of them.
 type TodoRepository interface{
-	Get(int) (*model.Task, error)
+	Get(int, *model.TaskQuery) (*model.TaskPage, error)
+	Search(int, string, int) ([]*model.TaskSearchResult, error)
+	FindByID(int, int) (*model.Task, error)
+	FindTree(int, int) (*model.Task, error)
+	// FindByICalUID returns the task created by a CalDAV client with the
+	// UID of its iCalendar object.
+	FindByICalUID(int, string) (*model.Task, error)
+	Depth(int, int) (int, error)
 	Create(*model.Task) error
 	Update(*model.Task) error
-	Delete(int, []int) (int64, error)
-}
\ No newline at end of file
+	Replace(*model.Task) error
+	// Delete moves the tasks and their subtasks to the trash, the last
+	// argument is the user deleting them for the history.
+	Delete(int, []int, int) (int64, error)
+	// DeleteVersion deletes a task like Delete if it has the given version.
+	DeleteVersion(int, int, int, int) (int64, error)
+	Trash(int) ([]*model.Task, error)
+	// Restore takes a task out of the trash, the last argument is the user
+	// restoring it for the history.
+	Restore(int, int, int) error
+	// Purge permanently removes the tasks deleted before the moment, it
+	// returns how many and the blob keys of the attachments removed with
+	// them.
+	Purge(time.Time) (int64, []string, error)
+	// Export calls the function with every task of the user in the order of
+	// their IDs, the tasks are read one at a time.
+	Export(int, func(*model.Task) error) error
+	// History returns the events of a task in the order they happened.
+	History(int, int) ([]*model.TaskEvent, error)
+	// SyncToken returns the current sync token of the tasks of the user.
+	SyncToken(int) (int, error)
+	// Changes returns the tasks changed and deleted since the sync token.
+	Changes(int, int) (*model.TaskChanges, error)
+	// AddDependency makes the task blocked by another task, an edge that
+	// would close a cycle fails with store.ErrDependencyCycle.
+	AddDependency(int, int, int) error
+	RemoveDependency(int, int, int) error
+	// Stats computes the statistics of the tasks of the user.
+	Stats(int, *model.TaskStatsQuery) (*model.TaskStats, error)
+	// InTx runs the function with a repository bound to a transaction that
+	// is committed when the function returns nil and rolled back otherwise.
+	InTx(func(TodoRepository) error) error
+	// InWorkspace returns the repository of the tasks of the workspace.
+	InWorkspace(int) TodoRepository
+	// Workspace returns the workspace of the repository.
+	Workspace() int
+}
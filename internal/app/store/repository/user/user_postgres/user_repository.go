@@ -23,7 +23,13 @@ func (r *UserReposiotry) Create(u *model.User) error {
 		return err
 	}
 
-	err := r.DB.QueryRow(
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(
 		"INSERT INTO users (email, encrypted_password) VALUES ($1, $2) RETURNING id",
 		u.Email,
 		u.EncryptedPassword,
@@ -34,9 +40,19 @@ func (r *UserReposiotry) Create(u *model.User) error {
 			err = errors.New("a user with this email already exists")
 			return err
 		}
+		return err
+	}
+
+	// every user starts with an inbox list
+	if _, err := tx.Exec(
+		"INSERT INTO lists (user_id, name, inbox) VALUES ($1, $2, true)",
+		u.ID,
+		model.InboxListName,
+	); err != nil {
+		return err
 	}
 	
-	return nil
+	return tx.Commit()
 }
 
 func (r *UserReposiotry) FindByID(id int) (*model.User, error) {
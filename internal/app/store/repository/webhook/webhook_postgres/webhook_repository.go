@@ -0,0 +1,151 @@
+package webhook_postgres
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+// deliveriesLimit is how many of the latest delivery attempts are listed.
+const deliveriesLimit = 100
+
+type WebhookRepository struct {
+	DB *sql.DB
+}
+
+func (r *WebhookRepository) Create(w *model.Webhook) error {
+	if err := w.BeforeCreate(); err != nil {
+		return err
+	}
+
+	if err := w.Validation(); err != nil {
+		return err
+	}
+
+	if w.Events == nil {
+		w.Events = []string{}
+	}
+
+	return r.DB.QueryRow(
+		"INSERT INTO webhooks (user_id, url, secret, events) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		w.UserID,
+		w.URL,
+		w.Secret,
+		pq.Array(w.Events),
+	).Scan(&w.ID, &w.CreatedAt)
+}
+
+func (r *WebhookRepository) FindAll(userID int) ([]*model.Webhook, error) {
+	return r.find("SELECT id, user_id, url, secret, events, created_at FROM webhooks WHERE user_id = $1 ORDER BY id", userID)
+}
+
+func (r *WebhookRepository) FindByID(userID int, webhookID int) (*model.Webhook, error) {
+	w := &model.Webhook{}
+
+	if err := r.DB.QueryRow(
+		"SELECT id, user_id, url, secret, events, created_at FROM webhooks WHERE id = $1 AND user_id = $2",
+		webhookID,
+		userID,
+	).Scan(&w.ID, &w.UserID, &w.URL, &w.Secret, pq.Array(&w.Events), &w.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (r *WebhookRepository) Delete(userID int, webhookID int) error {
+	res, err := r.DB.Exec(
+		"DELETE FROM webhooks WHERE id = $1 AND user_id = $2",
+		webhookID,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *WebhookRepository) FindByEvent(userID int, event string) ([]*model.Webhook, error) {
+	return r.find(
+		"SELECT id, user_id, url, secret, events, created_at FROM webhooks WHERE user_id = $1 AND (events = '{}' OR $2 = ANY(events)) ORDER BY id",
+		userID,
+		event,
+	)
+}
+
+func (r *WebhookRepository) CreateDelivery(d *model.WebhookDelivery) error {
+	return r.DB.QueryRow(
+		"INSERT INTO webhook_deliveries (webhook_id, event_id, event, attempt, status_code, error, success) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at",
+		d.WebhookID,
+		d.EventID,
+		d.Event,
+		d.Attempt,
+		d.StatusCode,
+		d.Error,
+		d.Success,
+	).Scan(&d.ID, &d.CreatedAt)
+}
+
+func (r *WebhookRepository) FindDeliveries(userID int, webhookID int) ([]*model.WebhookDelivery, error) {
+	if _, err := r.FindByID(userID, webhookID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.DB.Query(
+		`SELECT id, webhook_id, event_id, event, attempt, status_code, error, success, created_at
+		FROM webhook_deliveries WHERE webhook_id = $1 ORDER BY id DESC LIMIT $2`,
+		webhookID,
+		deliveriesLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*model.WebhookDelivery{}
+
+	for rows.Next() {
+		d := &model.WebhookDelivery{}
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.Event, &d.Attempt, &d.StatusCode, &d.Error, &d.Success, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (r *WebhookRepository) find(query string, args ...interface{}) ([]*model.Webhook, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []*model.Webhook{}
+
+	for rows.Next() {
+		w := &model.Webhook{}
+		if err := rows.Scan(&w.ID, &w.UserID, &w.URL, &w.Secret, pq.Array(&w.Events), &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+
+	return webhooks, rows.Err()
+}
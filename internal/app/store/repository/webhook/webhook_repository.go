@@ -0,0 +1,16 @@
+package webhook
+
+import "github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+
+type WebhookRepository interface {
+	Create(*model.Webhook) error
+	FindAll(int) ([]*model.Webhook, error)
+	FindByID(int, int) (*model.Webhook, error)
+	Delete(int, int) error
+	// FindByEvent returns the webhooks of the user subscribed to the event.
+	FindByEvent(int, string) ([]*model.Webhook, error)
+	CreateDelivery(*model.WebhookDelivery) error
+	// FindDeliveries returns the latest delivery attempts of a webhook,
+	// the most recent first.
+	FindDeliveries(int, int) ([]*model.WebhookDelivery, error)
+}
@@ -0,0 +1,262 @@
+package workspace_postgres
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+var errLastOwner = errors.New("a workspace needs an owner")
+
+const memberColumns = "m.workspace_id, m.user_id, u.email, m.role, m.created_at"
+
+type WorkspaceRepository struct {
+	DB *sql.DB
+}
+
+func (r *WorkspaceRepository) Create(w *model.Workspace) error {
+	if err := w.Validation(); err != nil {
+		return err
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow(
+		"INSERT INTO workspaces (name, created_by) VALUES ($1, $2) RETURNING id, created_at",
+		w.Name,
+		w.CreatedBy,
+	).Scan(&w.ID, &w.CreatedAt); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(
+		"INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, $3)",
+		w.ID,
+		w.CreatedBy,
+		model.RoleOwner,
+	); err != nil {
+		return err
+	}
+
+	w.Role = model.RoleOwner
+
+	return tx.Commit()
+}
+
+func (r *WorkspaceRepository) FindByID(id int) (*model.Workspace, error) {
+	w := &model.Workspace{}
+	var createdBy sql.NullInt64
+
+	if err := r.DB.QueryRow(
+		"SELECT id, name, created_by, created_at FROM workspaces WHERE id = $1",
+		id,
+	).Scan(&w.ID, &w.Name, &createdBy, &w.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	w.CreatedBy = int(createdBy.Int64)
+
+	return w, nil
+}
+
+func (r *WorkspaceRepository) FindByUser(userID int) ([]*model.Workspace, error) {
+	rows, err := r.DB.Query(
+		`SELECT w.id, w.name, w.created_by, m.role, w.created_at
+		FROM workspaces w JOIN workspace_members m ON m.workspace_id = w.id
+		WHERE m.user_id = $1 ORDER BY w.id`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workspaces := []*model.Workspace{}
+
+	for rows.Next() {
+		w := &model.Workspace{}
+		var createdBy sql.NullInt64
+		if err := rows.Scan(&w.ID, &w.Name, &createdBy, &w.Role, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		w.CreatedBy = int(createdBy.Int64)
+		workspaces = append(workspaces, w)
+	}
+
+	return workspaces, rows.Err()
+}
+
+func (r *WorkspaceRepository) Update(w *model.Workspace) error {
+	if err := w.Validation(); err != nil {
+		return err
+	}
+
+	var createdBy sql.NullInt64
+
+	if err := r.DB.QueryRow(
+		"UPDATE workspaces SET name = $1 WHERE id = $2 RETURNING created_by, created_at",
+		w.Name,
+		w.ID,
+	).Scan(&createdBy, &w.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+		return err
+	}
+
+	w.CreatedBy = int(createdBy.Int64)
+
+	return nil
+}
+
+func (r *WorkspaceRepository) Delete(id int) error {
+	res, err := r.DB.Exec("DELETE FROM workspaces WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *WorkspaceRepository) FindMember(workspaceID int, userID int) (*model.WorkspaceMember, error) {
+	m, err := scanMember(r.DB.QueryRow(
+		"SELECT "+memberColumns+" FROM workspace_members m JOIN users u ON u.id = m.user_id WHERE m.workspace_id = $1 AND m.user_id = $2",
+		workspaceID,
+		userID,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (r *WorkspaceRepository) FindMembers(workspaceID int) ([]*model.WorkspaceMember, error) {
+	rows, err := r.DB.Query(
+		"SELECT "+memberColumns+" FROM workspace_members m JOIN users u ON u.id = m.user_id WHERE m.workspace_id = $1 ORDER BY m.created_at, m.user_id",
+		workspaceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []*model.WorkspaceMember{}
+
+	for rows.Next() {
+		m, err := scanMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+
+	return members, rows.Err()
+}
+
+func (r *WorkspaceRepository) SaveMember(m *model.WorkspaceMember) error {
+	if err := m.Validation(); err != nil {
+		return err
+	}
+
+	return r.changeMembers(m.WorkspaceID, func(tx *sql.Tx) error {
+		return tx.QueryRow(
+			`INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, $3)
+			ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = EXCLUDED.role
+			RETURNING created_at`,
+			m.WorkspaceID,
+			m.UserID,
+			m.Role,
+		).Scan(&m.CreatedAt)
+	})
+}
+
+func (r *WorkspaceRepository) DeleteMember(workspaceID int, userID int) error {
+	return r.changeMembers(workspaceID, func(tx *sql.Tx) error {
+		res, err := tx.Exec("DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2", workspaceID, userID)
+		if err != nil {
+			return err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if n == 0 {
+			return store.ErrRecordNotFound
+		}
+
+		return nil
+	})
+}
+
+// changeMembers runs the change of the members of the workspace in a
+// transaction that is rolled back when the workspace is left without an
+// owner. The workspace is locked so that two changes cannot each remove one
+// of the last two owners.
+func (r *WorkspaceRepository) changeMembers(workspaceID int, fn func(tx *sql.Tx) error) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT id FROM workspaces WHERE id = $1 FOR UPDATE", workspaceID); err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	var owners int
+	if err := tx.QueryRow(
+		"SELECT count(*) FROM workspace_members WHERE workspace_id = $1 AND role = $2",
+		workspaceID,
+		model.RoleOwner,
+	).Scan(&owners); err != nil {
+		return err
+	}
+
+	if owners == 0 {
+		return errLastOwner
+	}
+
+	return tx.Commit()
+}
+
+type scanner interface {
+	Scan(...interface{}) error
+}
+
+func scanMember(row scanner) (*model.WorkspaceMember, error) {
+	m := &model.WorkspaceMember{}
+
+	if err := row.Scan(&m.WorkspaceID, &m.UserID, &m.Email, &m.Role, &m.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
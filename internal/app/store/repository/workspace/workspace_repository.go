@@ -0,0 +1,23 @@
+package workspace
+
+import "github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+
+type WorkspaceRepository interface {
+	// Create stores the workspace with its creator as its first owner.
+	Create(*model.Workspace) error
+	FindByID(int) (*model.Workspace, error)
+	// FindByUser returns the workspaces the user is a member of, with the
+	// role of the user.
+	FindByUser(int) ([]*model.Workspace, error)
+	Update(*model.Workspace) error
+	// Delete removes the workspace together with its tasks and lists.
+	Delete(int) error
+	FindMember(int, int) (*model.WorkspaceMember, error)
+	FindMembers(int) ([]*model.WorkspaceMember, error)
+	// SaveMember adds the user to the workspace or changes their role. The
+	// last owner of a workspace keeps the role.
+	SaveMember(*model.WorkspaceMember) error
+	// DeleteMember removes the user from the workspace, unless the user is
+	// its last owner.
+	DeleteMember(int, int) error
+}
@@ -1,11 +1,33 @@
 package store
 
 import (
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/apppassword"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/attachment"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/comment"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/feed"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/list"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/reminder"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/share"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/tag"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/timeentry"
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/todo"
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/user"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/webhook"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/workspace"
 )
 
 type Store interface{
 	User() user.UserRepository
 	Todo() todo.TodoRepository
+	Tag() tag.TagRepository
+	List() list.ListRepository
+	Reminder() reminder.ReminderRepository
+	Webhook() webhook.WebhookRepository
+	Feed() feed.FeedRepository
+	AppPassword() apppassword.AppPasswordRepository
+	Share() share.ShareRepository
+	Workspace() workspace.WorkspaceRepository
+	Comment() comment.CommentRepository
+	Attachment() attachment.AttachmentRepository
+	TimeEntry() timeentry.TimeEntryRepository
 }
\ No newline at end of file
@@ -0,0 +1,77 @@
+package apppassword_teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+type AppPasswordRepository struct {
+	AppPasswords map[int]*model.AppPassword
+	lastID       int
+}
+
+func (r *AppPasswordRepository) Create(p *model.AppPassword) error {
+	if err := p.Validation(); err != nil {
+		return err
+	}
+
+	if err := p.BeforeCreate(); err != nil {
+		return err
+	}
+
+	r.lastID++
+	p.ID = r.lastID
+	p.CreatedAt = time.Now().UTC()
+
+	c := *p
+	c.Password = ""
+	r.AppPasswords[p.ID] = &c
+
+	return nil
+}
+
+func (r *AppPasswordRepository) FindAll(userID int) ([]*model.AppPassword, error) {
+	passwords := []*model.AppPassword{}
+	for _, p := range r.AppPasswords {
+		if p.UserID == userID {
+			c := *p
+			passwords = append(passwords, &c)
+		}
+	}
+
+	sort.Slice(passwords, func(i, j int) bool {
+		return passwords[i].ID < passwords[j].ID
+	})
+
+	return passwords, nil
+}
+
+func (r *AppPasswordRepository) Delete(userID int, id int) error {
+	p, ok := r.AppPasswords[id]
+	if !ok || p.UserID != userID {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.AppPasswords, id)
+
+	return nil
+}
+
+func (r *AppPasswordRepository) Authenticate(userID int, password string) (*model.AppPassword, error) {
+	hash := model.HashAppPassword(password)
+
+	for _, p := range r.AppPasswords {
+		if p.UserID == userID && p.PasswordHash == hash {
+			now := time.Now().UTC()
+			p.LastUsedAt = &now
+
+			c := *p
+			return &c, nil
+		}
+	}
+
+	return nil, store.ErrRecordNotFound
+}
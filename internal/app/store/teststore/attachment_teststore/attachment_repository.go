@@ -0,0 +1,94 @@
+package attachment_teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+type AttachmentRepository struct {
+	Attachments map[int]*model.Attachment
+	Tasks       map[int]*model.Task
+
+	lastID int
+}
+
+func (r *AttachmentRepository) Create(a *model.Attachment) error {
+	if err := a.Validation(); err != nil {
+		return err
+	}
+
+	if !r.taskExists(a.UserID, a.TaskID) {
+		return store.ErrRecordNotFound
+	}
+
+	r.lastID++
+	a.ID = r.lastID
+	a.CreatedAt = time.Now().UTC()
+
+	stored := *a
+	r.Attachments[a.ID] = &stored
+
+	return nil
+}
+
+func (r *AttachmentRepository) FindByTask(userID int, taskID int) ([]*model.Attachment, error) {
+	if !r.taskExists(userID, taskID) {
+		return nil, store.ErrRecordNotFound
+	}
+
+	attachments := []*model.Attachment{}
+	for _, a := range r.Attachments {
+		if a.TaskID == taskID {
+			stored := *a
+			attachments = append(attachments, &stored)
+		}
+	}
+
+	sort.Slice(attachments, func(i, j int) bool {
+		return attachments[i].ID < attachments[j].ID
+	})
+
+	return attachments, nil
+}
+
+func (r *AttachmentRepository) FindByID(userID int, taskID int, attachmentID int) (*model.Attachment, error) {
+	a, ok := r.Attachments[attachmentID]
+	if !ok || a.UserID != userID || a.TaskID != taskID || !r.taskExists(userID, taskID) {
+		return nil, store.ErrRecordNotFound
+	}
+
+	stored := *a
+
+	return &stored, nil
+}
+
+func (r *AttachmentRepository) Find(attachmentID int) (*model.Attachment, error) {
+	a, ok := r.Attachments[attachmentID]
+	if !ok || !r.taskExists(a.UserID, a.TaskID) {
+		return nil, store.ErrRecordNotFound
+	}
+
+	stored := *a
+
+	return &stored, nil
+}
+
+func (r *AttachmentRepository) Delete(userID int, taskID int, attachmentID int) error {
+	a, ok := r.Attachments[attachmentID]
+	if !ok || a.UserID != userID || a.TaskID != taskID {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.Attachments, attachmentID)
+
+	return nil
+}
+
+func (r *AttachmentRepository) taskExists(userID int, taskID int) bool {
+	t, ok := r.Tasks[taskID]
+
+	return ok && t.UserID == userID && t.DeletedAt == nil
+}
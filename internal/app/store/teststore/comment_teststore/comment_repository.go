@@ -0,0 +1,107 @@
+package comment_teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+type CommentRepository struct {
+	Comments map[int]*model.Comment
+	Tasks    map[int]*model.Task
+
+	lastID int
+}
+
+func (r *CommentRepository) Create(c *model.Comment) error {
+	if err := c.Validation(); err != nil {
+		return err
+	}
+
+	if !r.taskExists(c.UserID, c.TaskID) {
+		return store.ErrRecordNotFound
+	}
+
+	r.lastID++
+	c.ID = r.lastID
+	c.CreatedAt = time.Now().UTC()
+
+	stored := *c
+	r.Comments[c.ID] = &stored
+
+	return nil
+}
+
+func (r *CommentRepository) FindByTask(userID int, taskID int, q *model.CommentQuery) (*model.CommentPage, error) {
+	if !r.taskExists(userID, taskID) {
+		return nil, store.ErrRecordNotFound
+	}
+
+	page := &model.CommentPage{Comments: []*model.Comment{}}
+	for _, c := range r.Comments {
+		if c.TaskID == taskID && c.ID > q.AfterID {
+			stored := *c
+			page.Comments = append(page.Comments, &stored)
+		}
+	}
+
+	sort.Slice(page.Comments, func(i, j int) bool {
+		return page.Comments[i].ID < page.Comments[j].ID
+	})
+
+	if len(page.Comments) > q.Limit {
+		page.Comments = page.Comments[:q.Limit]
+		page.NextCursor = model.EncodeCommentCursor(page.Comments[q.Limit-1])
+	}
+
+	return page, nil
+}
+
+func (r *CommentRepository) FindByID(userID int, taskID int, commentID int) (*model.Comment, error) {
+	c, ok := r.Comments[commentID]
+	if !ok || c.UserID != userID || c.TaskID != taskID || !r.taskExists(userID, taskID) {
+		return nil, store.ErrRecordNotFound
+	}
+
+	stored := *c
+
+	return &stored, nil
+}
+
+func (r *CommentRepository) Update(c *model.Comment) error {
+	if err := c.Validation(); err != nil {
+		return err
+	}
+
+	stored, ok := r.Comments[c.ID]
+	if !ok || stored.UserID != c.UserID || stored.TaskID != c.TaskID {
+		return store.ErrRecordNotFound
+	}
+
+	now := time.Now().UTC()
+	stored.Body = c.Body
+	stored.EditedAt = &now
+
+	*c = *stored
+
+	return nil
+}
+
+func (r *CommentRepository) Delete(userID int, taskID int, commentID int) error {
+	c, ok := r.Comments[commentID]
+	if !ok || c.UserID != userID || c.TaskID != taskID {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.Comments, commentID)
+
+	return nil
+}
+
+func (r *CommentRepository) taskExists(userID int, taskID int) bool {
+	t, ok := r.Tasks[taskID]
+
+	return ok && t.UserID == userID && t.DeletedAt == nil
+}
@@ -0,0 +1,62 @@
+package feed_teststore
+
+import (
+	"time"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+type FeedRepository struct {
+	Feeds map[int]*model.Feed
+}
+
+func (r *FeedRepository) Save(f *model.Feed) error {
+	if err := f.BeforeCreate(); err != nil {
+		return err
+	}
+
+	f.CreatedAt = time.Now().UTC()
+
+	r.Feeds[f.UserID] = &model.Feed{
+		UserID:    f.UserID,
+		TokenHash: f.TokenHash,
+		CreatedAt: f.CreatedAt,
+	}
+
+	return nil
+}
+
+func (r *FeedRepository) FindByUser(userID int) (*model.Feed, error) {
+	f, ok := r.Feeds[userID]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	c := *f
+
+	return &c, nil
+}
+
+func (r *FeedRepository) FindByToken(token string) (*model.Feed, error) {
+	hash := model.HashFeedToken(token)
+
+	for _, f := range r.Feeds {
+		if f.TokenHash == hash {
+			c := *f
+			return &c, nil
+		}
+	}
+
+	return nil, store.ErrRecordNotFound
+}
+
+func (r *FeedRepository) Delete(userID int) error {
+	if _, ok := r.Feeds[userID]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.Feeds, userID)
+
+	return nil
+}
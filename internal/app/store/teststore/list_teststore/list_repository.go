@@ -0,0 +1,198 @@
+package list_teststore
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/list"
+)
+
+type ListRepository struct {
+	Lists  map[int]*model.List
+	Tasks  map[int]*model.Task
+	Shares map[int]*model.Share
+
+	workspace int
+}
+
+func (r *ListRepository) Create(l *model.List) error {
+	if err := l.Validation(); err != nil {
+		return err
+	}
+
+	l.ID = len(r.Lists) + 1
+	for r.Lists[l.ID] != nil {
+		l.ID++
+	}
+	l.WorkspaceID = r.workspaceID()
+	l.CreatedAt = time.Now().UTC()
+
+	c := *l
+	r.Lists[l.ID] = &c
+
+	return nil
+}
+
+func (r *ListRepository) FindAll(userID int) ([]*model.List, error) {
+	lists := []*model.List{}
+	for _, l := range r.Lists {
+		if l.UserID == userID && r.inWorkspace(l) {
+			c := *l
+			lists = append(lists, &c)
+		}
+	}
+
+	for _, s := range r.Shares {
+		if l, ok := r.Lists[derefID(s.ListID)]; ok && r.inWorkspace(l) && s.GranteeID == userID && s.Status == model.ShareStatusAccepted {
+			c := *l
+			c.Role = s.Role
+			lists = append(lists, &c)
+		}
+	}
+
+	// the lists of the user come first, the inbox before the others
+	sort.Slice(lists, func(i, j int) bool {
+		if (lists[i].Role == "") != (lists[j].Role == "") {
+			return lists[i].Role == ""
+		}
+		if lists[i].Inbox != lists[j].Inbox {
+			return lists[i].Inbox
+		}
+		return lists[i].ID < lists[j].ID
+	})
+
+	return lists, nil
+}
+
+func (r *ListRepository) FindByID(userID int, listID int) (*model.List, error) {
+	l, ok := r.Lists[listID]
+	if !ok || l.UserID != userID || !r.inWorkspace(l) {
+		return nil, store.ErrRecordNotFound
+	}
+
+	c := *l
+
+	return &c, nil
+}
+
+func (r *ListRepository) Update(l *model.List) error {
+	if err := l.Validation(); err != nil {
+		return err
+	}
+
+	stored, ok := r.Lists[l.ID]
+	if !ok || stored.UserID != l.UserID || !r.inWorkspace(stored) {
+		return store.ErrRecordNotFound
+	}
+
+	stored.Name = l.Name
+	*l = *stored
+
+	return nil
+}
+
+// UpdateWorkflow fits the statuses of the tasks to the workflow the same
+// way the postgres repository does, without a history.
+func (r *ListRepository) UpdateWorkflow(l *model.List) error {
+	w := l.TaskWorkflow()
+	if err := w.Validation(); err != nil {
+		return err
+	}
+
+	stored, ok := r.Lists[l.ID]
+	if !ok || stored.UserID != l.UserID || !r.inWorkspace(stored) {
+		return store.ErrRecordNotFound
+	}
+
+	stored.Workflow = l.Workflow
+	*l = *stored
+
+	for _, t := range r.Tasks {
+		if derefID(t.ListID) != l.ID || t.Status == nil {
+			continue
+		}
+
+		s := w.Status(*t.Status)
+		if s == nil {
+			t.Status = nil
+			t.Version++
+			continue
+		}
+
+		if t.Complete != nil && *t.Complete == s.Done {
+			continue
+		}
+
+		complete := s.Done
+		t.Complete = &complete
+		t.CompletedAt = nil
+		if complete {
+			now := time.Now().UTC()
+			t.CompletedAt = &now
+		}
+		t.Version++
+	}
+
+	return nil
+}
+
+func (r *ListRepository) Delete(userID int, listID int) error {
+	l, ok := r.Lists[listID]
+	if !ok || l.UserID != userID || !r.inWorkspace(l) {
+		return store.ErrRecordNotFound
+	}
+
+	if l.Inbox {
+		return errors.New("the inbox list cannot be deleted")
+	}
+
+	for _, t := range r.Tasks {
+		if t.ListID != nil && *t.ListID == listID {
+			t.ListID = nil
+		}
+	}
+
+	for id, s := range r.Shares {
+		if derefID(s.ListID) == listID {
+			delete(r.Shares, id)
+		}
+	}
+
+	delete(r.Lists, listID)
+
+	return nil
+}
+
+func (r *ListRepository) InWorkspace(workspaceID int) list.ListRepository {
+	return &ListRepository{
+		Lists:     r.Lists,
+		Tasks:     r.Tasks,
+		Shares:    r.Shares,
+		workspace: workspaceID,
+	}
+}
+
+func (r *ListRepository) inWorkspace(l *model.List) bool {
+	return derefID(l.WorkspaceID) == r.workspace
+}
+
+func (r *ListRepository) workspaceID() *int {
+	if r.workspace == model.PersonalWorkspaceID {
+		return nil
+	}
+
+	id := r.workspace
+
+	return &id
+}
+
+func derefID(id *int) int {
+	if id == nil {
+		return 0
+	}
+
+	return *id
+}
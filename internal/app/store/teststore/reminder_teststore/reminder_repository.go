@@ -0,0 +1,149 @@
+package reminder_teststore
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+type ReminderRepository struct {
+	Reminders map[int]*model.Reminder
+	Tasks     map[int]*model.Task
+	// LastID is the last ID given to a reminder, the todo repository gives
+	// IDs from it to the reminders of the next occurrences of recurring
+	// tasks.
+	LastID *int
+
+	// sent keeps the deadline each reminder was last sent for
+	sent map[int]time.Time
+}
+
+func (r *ReminderRepository) Create(rem *model.Reminder) error {
+	if err := rem.Validation(); err != nil {
+		return err
+	}
+
+	t, ok := r.Tasks[rem.TaskID]
+	if !ok || t.UserID != rem.UserID || t.DeletedAt != nil {
+		return store.ErrRecordNotFound
+	}
+
+	for _, other := range r.Reminders {
+		if other.TaskID == rem.TaskID && other.Offset == rem.Offset {
+			return errors.New("the task already has a reminder with this offset")
+		}
+	}
+
+	*r.LastID++
+	rem.ID = *r.LastID
+
+	c := *rem
+	r.Reminders[rem.ID] = &c
+
+	return nil
+}
+
+func (r *ReminderRepository) FindByTask(userID int, taskID int) ([]*model.Reminder, error) {
+	t, ok := r.Tasks[taskID]
+	if !ok || t.UserID != userID || t.DeletedAt != nil {
+		return nil, store.ErrRecordNotFound
+	}
+
+	reminders := []*model.Reminder{}
+	for _, rem := range r.Reminders {
+		if rem.TaskID == taskID {
+			reminders = append(reminders, withRemindAt(rem, t))
+		}
+	}
+
+	sort.Slice(reminders, func(i, j int) bool {
+		return reminders[i].Offset < reminders[j].Offset
+	})
+
+	return reminders, nil
+}
+
+func (r *ReminderRepository) Delete(userID int, taskID int, reminderID int) error {
+	rem, ok := r.Reminders[reminderID]
+	if !ok || rem.UserID != userID || rem.TaskID != taskID {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.Reminders, reminderID)
+
+	return nil
+}
+
+func (r *ReminderRepository) FindDue(now time.Time, limit int) ([]*model.Reminder, error) {
+	reminders := []*model.Reminder{}
+
+	for _, rem := range r.Reminders {
+		t, ok := r.Tasks[rem.TaskID]
+		if !ok || t.Deadline == nil || t.DeletedAt != nil || (t.Complete != nil && *t.Complete) {
+			continue
+		}
+
+		if sent, ok := r.sent[rem.ID]; ok && sent.Equal(*t.Deadline) {
+			continue
+		}
+
+		if !rem.Due(*t.Deadline, now) {
+			continue
+		}
+
+		c := withRemindAt(rem, t)
+		c.Task = &model.Task{
+			UserID:      t.UserID,
+			TaskID:      t.TaskID,
+			Title:       t.Title,
+			Description: t.Description,
+			Deadline:    t.Deadline,
+			Complete:    t.Complete,
+		}
+		reminders = append(reminders, c)
+	}
+
+	sort.Slice(reminders, func(i, j int) bool {
+		if !reminders[i].RemindAt.Equal(*reminders[j].RemindAt) {
+			return reminders[i].RemindAt.Before(*reminders[j].RemindAt)
+		}
+		return reminders[i].ID < reminders[j].ID
+	})
+
+	if len(reminders) > limit {
+		reminders = reminders[:limit]
+	}
+
+	return reminders, nil
+}
+
+func (r *ReminderRepository) MarkSent(rem *model.Reminder) error {
+	stored, ok := r.Reminders[rem.ID]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	if r.sent == nil {
+		r.sent = make(map[int]time.Time)
+	}
+
+	now := time.Now().UTC()
+	stored.SentAt = &now
+	rem.SentAt = &now
+	r.sent[rem.ID] = *rem.Task.Deadline
+
+	return nil
+}
+
+func withRemindAt(rem *model.Reminder, t *model.Task) *model.Reminder {
+	c := *rem
+	if t.Deadline != nil {
+		remindAt := t.Deadline.Add(-time.Duration(rem.Offset))
+		c.RemindAt = &remindAt
+	}
+
+	return &c
+}
@@ -0,0 +1,119 @@
+package share_teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+type ShareRepository struct {
+	Shares map[int]*model.Share
+	lastID int
+}
+
+func (r *ShareRepository) Create(s *model.Share) error {
+	if err := s.Validation(); err != nil {
+		return err
+	}
+
+	for _, stored := range r.Shares {
+		if stored.GranteeID == s.GranteeID && sameID(stored.ListID, s.ListID) && sameID(stored.TaskID, s.TaskID) {
+			stored.Role = s.Role
+			stored.InvitedBy = s.InvitedBy
+			if stored.Status != model.ShareStatusAccepted {
+				stored.Status = model.ShareStatusPending
+			}
+
+			s.ID, s.Status, s.CreatedAt = stored.ID, stored.Status, stored.CreatedAt
+
+			return nil
+		}
+	}
+
+	r.lastID++
+	s.ID = r.lastID
+	s.Status = model.ShareStatusPending
+	s.CreatedAt = time.Now().UTC()
+
+	c := *s
+	r.Shares[s.ID] = &c
+
+	return nil
+}
+
+func (r *ShareRepository) FindByID(id int) (*model.Share, error) {
+	s, ok := r.Shares[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	c := *s
+
+	return &c, nil
+}
+
+func (r *ShareRepository) FindByOwner(ownerID int) ([]*model.Share, error) {
+	return r.find(func(s *model.Share) bool {
+		return s.OwnerID == ownerID
+	}), nil
+}
+
+func (r *ShareRepository) FindByGrantee(granteeID int) ([]*model.Share, error) {
+	return r.find(func(s *model.Share) bool {
+		return s.GranteeID == granteeID
+	}), nil
+}
+
+func (r *ShareRepository) FindGrants(granteeID int, ownerID int) ([]*model.Share, error) {
+	return r.find(func(s *model.Share) bool {
+		return s.GranteeID == granteeID && s.OwnerID == ownerID && s.Status == model.ShareStatusAccepted
+	}), nil
+}
+
+func (r *ShareRepository) SetStatus(id int, granteeID int, status string) error {
+	s, ok := r.Shares[id]
+	if !ok || s.GranteeID != granteeID {
+		return store.ErrRecordNotFound
+	}
+
+	s.Status = status
+
+	return nil
+}
+
+func (r *ShareRepository) Delete(ownerID int, id int) error {
+	s, ok := r.Shares[id]
+	if !ok || s.OwnerID != ownerID {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.Shares, id)
+
+	return nil
+}
+
+func (r *ShareRepository) find(match func(*model.Share) bool) []*model.Share {
+	shares := []*model.Share{}
+	for _, s := range r.Shares {
+		if match(s) {
+			c := *s
+			shares = append(shares, &c)
+		}
+	}
+
+	sort.Slice(shares, func(i, j int) bool {
+		return shares[i].ID < shares[j].ID
+	})
+
+	return shares
+}
+
+func sameID(a *int, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
@@ -2,19 +2,76 @@ package teststore
 
 import (
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/apppassword"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/attachment"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/comment"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/feed"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/list"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/reminder"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/share"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/tag"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/timeentry"
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/todo"
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/user"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/webhook"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/workspace"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/teststore/apppassword_teststore"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/teststore/attachment_teststore"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/teststore/comment_teststore"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/teststore/feed_teststore"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/teststore/list_teststore"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/teststore/reminder_teststore"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/teststore/share_teststore"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/teststore/tag_teststore"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/teststore/timeentry_teststore"
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/teststore/todo_teststore"
 	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/teststore/user_teststore"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/teststore/webhook_teststore"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/teststore/workspace_teststore"
 )
 
 type Store struct {
-	userRepository user.UserRepository
-	todoRepository todo.TodoRepository
+	userRepository        user.UserRepository
+	todoRepository        todo.TodoRepository
+	tagRepository         tag.TagRepository
+	listRepository        list.ListRepository
+	reminderRepository    reminder.ReminderRepository
+	webhookRepository     webhook.WebhookRepository
+	feedRepository        feed.FeedRepository
+	appPasswordRepository apppassword.AppPasswordRepository
+	shareRepository       share.ShareRepository
+	workspaceRepository   workspace.WorkspaceRepository
+	commentRepository     comment.CommentRepository
+	attachmentRepository  attachment.AttachmentRepository
+	timeEntryRepository   timeentry.TimeEntryRepository
+
+	// tasks, tags, lists, shares, time entries, reminders and attachments
+	// are shared between the repositories, the same way the tables are
+	// shared in the database.
+	tasks       map[int]*model.Task
+	tags        map[int]*model.Tag
+	lists       map[int]*model.List
+	shares      map[int]*model.Share
+	timeEntries map[int]*model.TimeEntry
+	reminders   map[int]*model.Reminder
+	attachments map[int]*model.Attachment
+
+	// reminderID is the last ID given to a reminder, by the reminder
+	// repository or by the todo repository when it copies the reminders of
+	// a recurring task.
+	reminderID int
 }
 
 func New() *Store {
-	return &Store{}
+	return &Store{
+		tasks:       make(map[int]*model.Task),
+		tags:        make(map[int]*model.Tag),
+		lists:       make(map[int]*model.List),
+		shares:      make(map[int]*model.Share),
+		timeEntries: make(map[int]*model.TimeEntry),
+		reminders:   make(map[int]*model.Reminder),
+		attachments: make(map[int]*model.Attachment),
+	}
 }
 
 func (s *Store) User() user.UserRepository {
@@ -24,6 +81,7 @@ func (s *Store) User() user.UserRepository {
 	
 	s.userRepository = &user_teststore.UserRepository{
 		Users: make(map[int]*model.User),
+		Lists: s.lists,
 	}
 
 	return s.userRepository
@@ -35,8 +93,160 @@ func (s *Store) Todo() todo.TodoRepository {
 	}
 	
 	s.todoRepository = &todo_teststore.TodoRepository{
-		Tasks: make(map[int]map[string]string),
+		Tasks:       s.tasks,
+		Tags:        s.tags,
+		Shares:      s.shares,
+		TimeEntries: s.timeEntries,
+		Lists:       s.lists,
+		Reminders:   s.reminders,
+		Attachments: s.attachments,
+
+		LastReminderID: &s.reminderID,
 	}
 
 	return s.todoRepository
+}
+
+func (s *Store) Tag() tag.TagRepository {
+	if s.tagRepository != nil {
+		return s.tagRepository
+	}
+
+	s.tagRepository = &tag_teststore.TagRepository{
+		Tags:  s.tags,
+		Tasks: s.tasks,
+	}
+
+	return s.tagRepository
+}
+
+func (s *Store) List() list.ListRepository {
+	if s.listRepository != nil {
+		return s.listRepository
+	}
+
+	s.listRepository = &list_teststore.ListRepository{
+		Lists:  s.lists,
+		Tasks:  s.tasks,
+		Shares: s.shares,
+	}
+
+	return s.listRepository
+}
+
+func (s *Store) Reminder() reminder.ReminderRepository {
+	if s.reminderRepository != nil {
+		return s.reminderRepository
+	}
+
+	s.reminderRepository = &reminder_teststore.ReminderRepository{
+		Reminders: s.reminders,
+		Tasks:     s.tasks,
+		LastID:    &s.reminderID,
+	}
+
+	return s.reminderRepository
+}
+
+func (s *Store) Webhook() webhook.WebhookRepository {
+	if s.webhookRepository != nil {
+		return s.webhookRepository
+	}
+
+	s.webhookRepository = &webhook_teststore.WebhookRepository{
+		Webhooks: make(map[int]*model.Webhook),
+	}
+
+	return s.webhookRepository
+}
+
+func (s *Store) Feed() feed.FeedRepository {
+	if s.feedRepository != nil {
+		return s.feedRepository
+	}
+
+	s.feedRepository = &feed_teststore.FeedRepository{
+		Feeds: make(map[int]*model.Feed),
+	}
+
+	return s.feedRepository
+}
+
+func (s *Store) AppPassword() apppassword.AppPasswordRepository {
+	if s.appPasswordRepository != nil {
+		return s.appPasswordRepository
+	}
+
+	s.appPasswordRepository = &apppassword_teststore.AppPasswordRepository{
+		AppPasswords: make(map[int]*model.AppPassword),
+	}
+
+	return s.appPasswordRepository
+}
+
+func (s *Store) Share() share.ShareRepository {
+	if s.shareRepository != nil {
+		return s.shareRepository
+	}
+
+	s.shareRepository = &share_teststore.ShareRepository{
+		Shares: s.shares,
+	}
+
+	return s.shareRepository
+}
+
+func (s *Store) Workspace() workspace.WorkspaceRepository {
+	if s.workspaceRepository != nil {
+		return s.workspaceRepository
+	}
+
+	s.workspaceRepository = &workspace_teststore.WorkspaceRepository{
+		Workspaces: make(map[int]*model.Workspace),
+		Members:    make(map[int]map[int]*model.WorkspaceMember),
+		Tasks:      s.tasks,
+		Lists:      s.lists,
+	}
+
+	return s.workspaceRepository
+}
+
+func (s *Store) Comment() comment.CommentRepository {
+	if s.commentRepository != nil {
+		return s.commentRepository
+	}
+
+	s.commentRepository = &comment_teststore.CommentRepository{
+		Comments: make(map[int]*model.Comment),
+		Tasks:    s.tasks,
+	}
+
+	return s.commentRepository
+}
+
+func (s *Store) Attachment() attachment.AttachmentRepository {
+	if s.attachmentRepository != nil {
+		return s.attachmentRepository
+	}
+
+	s.attachmentRepository = &attachment_teststore.AttachmentRepository{
+		Attachments: s.attachments,
+		Tasks:       s.tasks,
+	}
+
+	return s.attachmentRepository
+}
+
+func (s *Store) TimeEntry() timeentry.TimeEntryRepository {
+	if s.timeEntryRepository != nil {
+		return s.timeEntryRepository
+	}
+
+	s.timeEntryRepository = &timeentry_teststore.TimeEntryRepository{
+		TimeEntries: s.timeEntries,
+		Tasks:       s.tasks,
+		Lists:       s.lists,
+	}
+
+	return s.timeEntryRepository
 }
\ No newline at end of file
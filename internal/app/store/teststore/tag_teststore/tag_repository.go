@@ -0,0 +1,121 @@
+package tag_teststore
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+type TagRepository struct {
+	Tags  map[int]*model.Tag
+	Tasks map[int]*model.Task
+}
+
+func (r *TagRepository) Create(t *model.Tag) error {
+	if err := t.Validation(); err != nil {
+		return err
+	}
+
+	if r.exists(t) {
+		return errors.New("a tag with this name already exists")
+	}
+
+	t.ID = nextID(r.Tags)
+	c := *t
+	r.Tags[t.ID] = &c
+
+	return nil
+}
+
+func (r *TagRepository) FindAll(userID int) ([]*model.Tag, error) {
+	tags := []*model.Tag{}
+	for _, t := range r.Tags {
+		if t.UserID == userID {
+			c := *t
+			tags = append(tags, &c)
+		}
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+
+	return tags, nil
+}
+
+func (r *TagRepository) Update(t *model.Tag) error {
+	if err := t.Validation(); err != nil {
+		return err
+	}
+
+	stored, ok := r.Tags[t.ID]
+	if !ok || stored.UserID != t.UserID {
+		return store.ErrRecordNotFound
+	}
+
+	if r.exists(t) {
+		return errors.New("a tag with this name already exists")
+	}
+
+	r.renameOnTasks(stored.UserID, stored.Name, t.Name)
+	stored.Name = t.Name
+
+	return nil
+}
+
+func (r *TagRepository) Delete(userID int, tagID int) error {
+	stored, ok := r.Tags[tagID]
+	if !ok || stored.UserID != userID {
+		return store.ErrRecordNotFound
+	}
+
+	r.renameOnTasks(userID, stored.Name, "")
+	delete(r.Tags, tagID)
+
+	return nil
+}
+
+func (r *TagRepository) exists(t *model.Tag) bool {
+	for _, stored := range r.Tags {
+		if stored.UserID == t.UserID && stored.Name == t.Name && stored.ID != t.ID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// renameOnTasks replaces the tag on the tasks of the user, an empty name
+// detaches it.
+func (r *TagRepository) renameOnTasks(userID int, from, to string) {
+	for _, task := range r.Tasks {
+		if task.UserID != userID {
+			continue
+		}
+
+		tags := []string{}
+		for _, name := range task.Tags {
+			if name == from {
+				name = to
+			}
+			if name != "" {
+				tags = append(tags, name)
+			}
+		}
+		task.Tags = model.NormalizeTags(tags)
+	}
+}
+
+// nextID returns an identifier that is not used in the map yet.
+func nextID[T any](m map[int]T) int {
+	id := 1
+	for k := range m {
+		if k >= id {
+			id = k + 1
+		}
+	}
+
+	return id
+}
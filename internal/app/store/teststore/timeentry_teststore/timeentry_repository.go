@@ -0,0 +1,255 @@
+package timeentry_teststore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/timeentry"
+)
+
+type TimeEntryRepository struct {
+	TimeEntries map[int]*model.TimeEntry
+	Tasks       map[int]*model.Task
+	Lists       map[int]*model.List
+
+	// workspace is the workspace given to InWorkspace. Such a repository
+	// keeps the last ID in root, the repository it was returned by.
+	workspace int
+	root      *TimeEntryRepository
+	lastID    int
+}
+
+func (r *TimeEntryRepository) Start(e *model.TimeEntry) error {
+	if !r.taskExists(e.UserID, e.TaskID) {
+		return store.ErrRecordNotFound
+	}
+
+	for _, other := range r.TimeEntries {
+		if other.TrackerID == e.TrackerID && other.Running() {
+			return store.ErrTimerRunning
+		}
+	}
+
+	now := time.Now().UTC()
+	e.StartedAt = now
+	e.StoppedAt = nil
+
+	return r.create(e, now)
+}
+
+func (r *TimeEntryRepository) Stop(userID int, taskID int, trackerID int) (*model.TimeEntry, error) {
+	if !r.taskExists(userID, taskID) {
+		return nil, store.ErrRecordNotFound
+	}
+
+	for _, e := range r.TimeEntries {
+		if e.UserID == userID && e.TaskID == taskID && e.TrackerID == trackerID && e.Running() {
+			now := time.Now().UTC()
+			e.StoppedAt = &now
+			e.SetDuration(now)
+
+			stored := *e
+
+			return &stored, nil
+		}
+	}
+
+	return nil, store.ErrRecordNotFound
+}
+
+func (r *TimeEntryRepository) Create(e *model.TimeEntry) error {
+	if err := e.Validation(); err != nil {
+		return err
+	}
+
+	if !r.taskExists(e.UserID, e.TaskID) {
+		return store.ErrRecordNotFound
+	}
+
+	return r.create(e, time.Now().UTC())
+}
+
+func (r *TimeEntryRepository) FindByTask(userID int, taskID int) ([]*model.TimeEntry, error) {
+	entries := []*model.TimeEntry{}
+	if !r.taskExists(userID, taskID) {
+		return entries, nil
+	}
+
+	now := time.Now().UTC()
+	for _, e := range r.TimeEntries {
+		if e.UserID == userID && e.TaskID == taskID {
+			stored := *e
+			stored.SetDuration(now)
+			entries = append(entries, &stored)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].StartedAt.Equal(entries[j].StartedAt) {
+			return entries[i].StartedAt.Before(entries[j].StartedAt)
+		}
+		return entries[i].ID < entries[j].ID
+	})
+
+	return entries, nil
+}
+
+func (r *TimeEntryRepository) FindByID(userID int, taskID int, entryID int) (*model.TimeEntry, error) {
+	e, ok := r.TimeEntries[entryID]
+	if !ok || e.UserID != userID || e.TaskID != taskID || !r.taskExists(userID, taskID) {
+		return nil, store.ErrRecordNotFound
+	}
+
+	stored := *e
+	stored.SetDuration(time.Now().UTC())
+
+	return &stored, nil
+}
+
+func (r *TimeEntryRepository) Update(e *model.TimeEntry) error {
+	if err := e.Validation(); err != nil {
+		return err
+	}
+
+	stored, ok := r.TimeEntries[e.ID]
+	if !ok || stored.UserID != e.UserID || stored.TaskID != e.TaskID {
+		return store.ErrRecordNotFound
+	}
+
+	stored.StartedAt = e.StartedAt
+	stored.StoppedAt = e.StoppedAt
+	stored.Note = e.Note
+	stored.SetDuration(time.Now().UTC())
+
+	*e = *stored
+
+	return nil
+}
+
+func (r *TimeEntryRepository) Delete(userID int, taskID int, entryID int) error {
+	e, ok := r.TimeEntries[entryID]
+	if !ok || e.UserID != userID || e.TaskID != taskID {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.TimeEntries, entryID)
+
+	return nil
+}
+
+// Report groups the entries the same way the postgres repository does, the
+// rows by list are kept apart by the ID of the list.
+func (r *TimeEntryRepository) Report(userID int, q *model.TimeReportQuery) (*model.TimeReport, error) {
+	if err := q.Validation(); err != nil {
+		return nil, err
+	}
+
+	type key struct {
+		group  string
+		listID int
+	}
+
+	durations := map[key]int64{}
+	now := time.Now().UTC()
+
+	for _, e := range r.TimeEntries {
+		if e.UserID != userID || e.StartedAt.Before(q.From) || !e.StartedAt.Before(q.To) || !r.taskExists(userID, e.TaskID) {
+			continue
+		}
+
+		c := *e
+		c.SetDuration(now)
+		t := r.Tasks[e.TaskID]
+
+		switch q.GroupBy {
+		case model.TimeReportByDay:
+			durations[key{group: e.StartedAt.Format("2006-01-02")}] += c.Duration
+		case model.TimeReportByList:
+			k := key{}
+			if l, ok := r.Lists[derefID(t.ListID)]; ok {
+				k = key{group: l.Name, listID: l.ID}
+			}
+			durations[k] += c.Duration
+		case model.TimeReportByTag:
+			if len(t.Tags) == 0 {
+				durations[key{}] += c.Duration
+			}
+			for _, tag := range t.Tags {
+				durations[key{group: tag}] += c.Duration
+			}
+		}
+	}
+
+	keys := make([]key, 0, len(durations))
+	for k := range durations {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].group != keys[j].group {
+			return keys[i].group < keys[j].group
+		}
+		return keys[i].listID < keys[j].listID
+	})
+
+	rows := make([]*model.TimeReportRow, 0, len(keys))
+	for _, k := range keys {
+		row := &model.TimeReportRow{Group: k.group, Duration: durations[k]}
+		if k.listID != 0 {
+			listID := k.listID
+			row.ListID = &listID
+		}
+		rows = append(rows, row)
+	}
+
+	return model.NewTimeReport(q, rows), nil
+}
+
+func (r *TimeEntryRepository) InWorkspace(workspaceID int) timeentry.TimeEntryRepository {
+	return &TimeEntryRepository{
+		TimeEntries: r.TimeEntries,
+		Tasks:       r.Tasks,
+		Lists:       r.Lists,
+		workspace:   workspaceID,
+		root:        r.base(),
+	}
+}
+
+func (r *TimeEntryRepository) create(e *model.TimeEntry, now time.Time) error {
+	r.base().lastID++
+	e.ID = r.base().lastID
+	e.CreatedAt = now
+	e.SetDuration(now)
+
+	stored := *e
+	r.TimeEntries[e.ID] = &stored
+
+	return nil
+}
+
+// base is the repository that keeps the last ID.
+func (r *TimeEntryRepository) base() *TimeEntryRepository {
+	if r.root != nil {
+		return r.root
+	}
+
+	return r
+}
+
+// taskExists reports whether the task of the user is in the workspace of
+// the repository and not in the trash.
+func (r *TimeEntryRepository) taskExists(userID int, taskID int) bool {
+	t, ok := r.Tasks[taskID]
+
+	return ok && t.UserID == userID && derefID(t.WorkspaceID) == r.workspace && t.DeletedAt == nil
+}
+
+func derefID(id *int) int {
+	if id == nil {
+		return 0
+	}
+
+	return *id
+}
@@ -1,25 +1,1154 @@
 package todo_teststore
 
-import "github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/repository/todo"
+)
 
 type TodoRepository struct {
-	Tasks map[int]map[string]string
+	Tasks  map[int]*model.Task
+	Tags   map[int]*model.Tag
+	Shares map[int]*model.Share
+	Events []*model.TaskEvent
+	// TimeEntries are the entries of the time tracking, their durations
+	// add up to the time spent on the tasks.
+	TimeEntries map[int]*model.TimeEntry
+	// Lists are the lists of the tasks, their workflows give the status
+	// of the tasks that have not been given one.
+	Lists map[int]*model.List
+	// Reminders are the reminders of the tasks, the next occurrence of a
+	// recurring task takes the offsets of the completed one.
+	Reminders map[int]*model.Reminder
+	// Attachments are the files of the tasks, they are purged with them.
+	Attachments map[int]*model.Attachment
+	// LastReminderID is the last ID given to a reminder, shared with the
+	// reminder repository.
+	LastReminderID *int
+	lastID         int
+
+	// workspace is the workspace given to InWorkspace. Such a repository
+	// keeps the history and the last ID in root, the repository it was
+	// returned by.
+	workspace int
+	root      *TodoRepository
 }
 
-func (r *TodoRepository) Get(int) (*model.Task, error) {
-	t := &model.Task{}
+// Get includes the tasks that other users share with the user.
+func (r *TodoRepository) Get(userID int, q *model.TaskQuery) (*model.TaskPage, error) {
+	now := time.Now().UTC()
+	tasks := []*model.Task{}
+	for _, t := range r.Tasks {
+		if (t.UserID == userID || r.sharedWith(t, userID)) && r.inWorkspace(t) && t.DeletedAt == nil && q.Filter.Match(t, now) {
+			tasks = append(tasks, t)
+		}
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return q.Less(tasks[i], tasks[j])
+	})
+
+	page := &model.TaskPage{Tasks: []*model.Task{}}
+
+	for _, t := range tasks {
+		if q.Cursor != nil && !q.Less(q.Cursor.Task(), t) {
+			continue
+		}
 
-	return t, nil
+		if len(page.Tasks) == q.Limit {
+			page.NextCursor = q.NewCursor(page.Tasks[q.Limit-1]).Encode()
+			break
+		}
+
+		page.Tasks = append(page.Tasks, r.output(t))
+	}
+
+	return page, nil
 }
 
-func (r *TodoRepository) Create(*model.Task) error {
+// Search is a substring fallback for the postgres full-text search: matches
+// in the title rank higher than matches in the description.
+func (r *TodoRepository) Search(userID int, text string, limit int) ([]*model.TaskSearchResult, error) {
+	needle := strings.ToLower(text)
+	results := []*model.TaskSearchResult{}
+
+	for _, t := range r.Tasks {
+		if t.UserID != userID || !r.inWorkspace(t) || t.DeletedAt != nil {
+			continue
+		}
+
+		var rank float64
+		switch {
+		case t.Title != nil && strings.Contains(strings.ToLower(*t.Title), needle):
+			rank = 1
+		case t.Description != nil && strings.Contains(strings.ToLower(*t.Description), needle):
+			rank = 0.5
+		default:
+			continue
+		}
+
+		doc := []string{}
+		for _, s := range []*string{t.Title, t.Description} {
+			if s != nil && *s != "" {
+				doc = append(doc, *s)
+			}
+		}
+
+		results = append(results, &model.TaskSearchResult{
+			Task:    r.output(t),
+			Rank:    rank,
+			Snippet: model.NewSnippet(highlight(strings.Join(doc, " "), needle)),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].TaskID < results[j].TaskID
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+func (r *TodoRepository) FindByID(userID int, taskID int) (*model.Task, error) {
+	t, ok := r.find(userID, taskID)
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	return r.output(t), nil
+}
+
+func (r *TodoRepository) FindTree(userID int, taskID int) (*model.Task, error) {
+	if _, ok := r.find(userID, taskID); !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	tasks := []*model.Task{}
+	for _, id := range r.subtree(taskID, nil) {
+		tasks = append(tasks, r.output(r.Tasks[id]))
+	}
+
+	return model.NewTaskTree(tasks, taskID), nil
+}
+
+func (r *TodoRepository) FindByICalUID(userID int, uid string) (*model.Task, error) {
+	for _, t := range r.Tasks {
+		if t.UserID == userID && r.inWorkspace(t) && t.DeletedAt == nil && t.ICalUID != nil && *t.ICalUID == uid {
+			return r.output(t), nil
+		}
+	}
+
+	return nil, store.ErrRecordNotFound
+}
+
+func (r *TodoRepository) Depth(userID int, taskID int) (int, error) {
+	t, ok := r.find(userID, taskID)
+	if !ok {
+		return 0, store.ErrRecordNotFound
+	}
+
+	depth := 1
+	for t.ParentTaskID != nil {
+		t = r.Tasks[*t.ParentTaskID]
+		depth++
+	}
+
+	return depth, nil
+}
+
+func (r *TodoRepository) Create(t *model.Task) error {
+	if err := t.BeforeCreate(); err != nil {
+		return err
+	}
+
+	if t.ICalUID != nil && r.uidTaken(t.UserID, *t.ICalUID) {
+		return store.ErrUIDConflict
+	}
+
+	r.base().lastID++
+	t.TaskID = r.base().lastID
+	t.WorkspaceID = r.workspaceID()
+	t.Version = 1
+	t.CreatedAt = time.Now().UTC()
+	t.Tags = r.attachTags(t.UserID, t.Tags)
+	t.RRule = nilIfEmpty(t.RRule)
+	t.BlockedBy, t.Blocking = []int{}, []int{}
+	t.CompletedAt = completedAt(t.Complete, nil)
+	r.Tasks[t.TaskID] = copyTask(t)
+	r.recordEvent(model.NewTaskEvent(t.ActorID, nil, r.withStatus(t)))
+
 	return nil
 }
 
 func (r *TodoRepository) Update(t *model.Task) error {
+	stored, ok := r.find(t.UserID, t.TaskID)
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	if err := checkVersion(t, stored); err != nil {
+		return err
+	}
+
+	if r.blocked(t, stored) {
+		return store.ErrTaskBlocked
+	}
+
+	if t.CompleteSubtasks && !t.ForceComplete && r.subtreeBlocked(t.TaskID) {
+		return store.ErrTaskBlocked
+	}
+
+	before := r.withStatus(stored)
+
+	if t.ListID != nil {
+		stored.ListID = t.ListID
+	}
+
+	if t.ParentTaskID != nil || t.ClearParent {
+		stored.ParentTaskID = t.ParentTaskID
+	}
+
+	if t.Title != nil {
+		stored.Title = t.Title
+	}
+
+	if t.Description != nil {
+		stored.Description = t.Description
+	}
+
+	if t.Deadline != nil {
+		stored.Deadline = t.Deadline
+	}
+
+	if t.Complete != nil {
+		if t.Status == nil && !sameFlag(t.Complete, stored.Complete) {
+			stored.Status = nil
+		}
+		stored.Complete = t.Complete
+		stored.CompletedAt = completedAt(t.Complete, stored.CompletedAt)
+	}
+
+	if t.Status != nil {
+		stored.Status = t.Status
+	}
+
+	if t.RRule != nil {
+		if err := t.BeforeCreate(); err != nil {
+			return err
+		}
+
+		stored.RRule = nilIfEmpty(t.RRule)
+		if stored.SeriesID == nil {
+			stored.SeriesID = t.SeriesID
+		}
+	}
+
+	if t.Tags != nil {
+		stored.Tags = r.attachTags(stored.UserID, t.Tags)
+	}
+
+	if t.CompleteSubtasks {
+		for _, id := range r.subtree(t.TaskID, nil)[1:] {
+			sub := r.Tasks[id]
+			if sub.Complete != nil && *sub.Complete {
+				continue
+			}
+
+			subBefore := copyTask(sub)
+			complete := true
+			sub.Complete = &complete
+			sub.CompletedAt = completedAt(sub.Complete, nil)
+			sub.Status = nil
+			sub.Version++
+			r.recordEvent(model.NewTaskEvent(t.ActorID, subBefore, sub))
+			t.CompletedSubtaskIDs = append(t.CompletedSubtaskIDs, id)
+		}
+	}
+
+	after := r.withStatus(stored)
+	if len(model.DiffTasks(before, after)) > 0 {
+		stored.Version++
+		after.Version++
+	}
+	t.Version = stored.Version
+
+	return r.afterChange(t, before, after)
+}
+
+func (r *TodoRepository) Replace(t *model.Task) error {
+	stored, ok := r.find(t.UserID, t.TaskID)
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	if err := checkVersion(t, stored); err != nil {
+		return err
+	}
+
+	if r.blocked(t, stored) {
+		return store.ErrTaskBlocked
+	}
+
+	if err := t.BeforeCreate(); err != nil {
+		return err
+	}
+
+	if stored.SeriesID != nil {
+		t.SeriesID = stored.SeriesID
+	}
+
+	t.ICalUID = stored.ICalUID
+	t.WorkspaceID = stored.WorkspaceID
+	t.CreatedAt = stored.CreatedAt
+	t.Version = stored.Version + 1
+	t.Tags = r.attachTags(t.UserID, t.Tags)
+	t.RRule = nilIfEmpty(t.RRule)
+	t.BlockedBy = stored.BlockedBy
+	t.CompletedAt = completedAt(t.Complete, stored.CompletedAt)
+	if t.Status == nil && sameFlag(t.Complete, stored.Complete) {
+		t.Status = stored.Status
+	}
+	r.Tasks[t.TaskID] = copyTask(t)
+
+	out := r.output(t)
+	t.BlockedBy, t.Blocking, t.Status = out.BlockedBy, out.Blocking, out.Status
+
+	return r.afterChange(t, r.withStatus(stored), out)
+}
+
+func (r *TodoRepository) Delete(userID int, taskIDs []int, actorID int) (int64, error) {
+	var count int64
+
+	now := time.Now().UTC()
+
+	for _, id := range taskIDs {
+		if _, ok := r.find(userID, id); ok {
+			// subtasks go to the trash together with their parent
+			for _, subID := range r.subtree(id, nil) {
+				r.recordEvent(model.NewTaskEvent(actorID, r.Tasks[subID], nil))
+				r.Tasks[subID].DeletedAt = &now
+				r.Tasks[subID].Version++
+			}
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (r *TodoRepository) DeleteVersion(userID int, taskID int, version int, actorID int) (int64, error) {
+	if t, ok := r.find(userID, taskID); ok {
+		if err := checkVersion(&model.Task{IfVersion: &version}, t); err != nil {
+			return 0, err
+		}
+	}
+
+	return r.Delete(userID, []int{taskID}, actorID)
+}
+
+func (r *TodoRepository) Trash(userID int) ([]*model.Task, error) {
+	tasks := []*model.Task{}
+	for _, t := range r.Tasks {
+		if t.UserID == userID && r.inWorkspace(t) && t.DeletedAt != nil {
+			tasks = append(tasks, r.output(t))
+		}
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].DeletedAt.Equal(*tasks[j].DeletedAt) {
+			return tasks[i].DeletedAt.After(*tasks[j].DeletedAt)
+		}
+		return tasks[i].TaskID < tasks[j].TaskID
+	})
+
+	return tasks, nil
+}
+
+func (r *TodoRepository) Restore(userID int, taskID int, actorID int) error {
+	t, ok := r.Tasks[taskID]
+	if !ok || t.UserID != userID || !r.inWorkspace(t) {
+		return store.ErrRecordNotFound
+	}
+
+	if t.DeletedAt == nil {
+		return errors.New("the task is not in the trash")
+	}
+
+	if parent, ok := r.Tasks[derefID(t.ParentTaskID)]; ok && parent.DeletedAt != nil {
+		return errors.New("the parent task is in the trash, restore it first")
+	}
+
+	ids := r.subtree(taskID, t.DeletedAt)
+	for _, id := range ids {
+		if uid := r.Tasks[id].ICalUID; uid != nil && r.uidTaken(userID, *uid) {
+			return store.ErrUIDConflict
+		}
+	}
+
+	for _, id := range ids {
+		r.Tasks[id].DeletedAt = nil
+		r.Tasks[id].Version++
+		r.recordEvent(model.NewTaskRestoredEvent(actorID, r.Tasks[id]))
+	}
+
+	return nil
+}
+
+func (r *TodoRepository) Purge(before time.Time) (int64, []string, error) {
+	var count int64
+	keys := []string{}
+
+	for id, t := range r.Tasks {
+		if t.DeletedAt != nil && t.DeletedAt.Before(before) {
+			for shareID, s := range r.Shares {
+				if derefID(s.TaskID) == id {
+					delete(r.Shares, shareID)
+				}
+			}
+
+			for entryID, e := range r.TimeEntries {
+				if e.TaskID == id {
+					delete(r.TimeEntries, entryID)
+				}
+			}
+
+			for attachmentID, a := range r.Attachments {
+				if a.TaskID == id {
+					keys = append(keys, a.Key)
+					delete(r.Attachments, attachmentID)
+				}
+			}
+
+			delete(r.Tasks, id)
+			count++
+		}
+	}
+
+	// the dependencies on the purged tasks go away with them
+	for _, t := range r.Tasks {
+		blockedBy := []int{}
+		for _, id := range t.BlockedBy {
+			if _, ok := r.Tasks[id]; ok {
+				blockedBy = append(blockedBy, id)
+			}
+		}
+		t.BlockedBy = blockedBy
+	}
+
+	sort.Strings(keys)
+
+	return count, keys, nil
+}
+
+// AddDependency makes the task blocked by another task of the workspace. An
+// edge that would close a cycle fails with store.ErrDependencyCycle.
+func (r *TodoRepository) AddDependency(userID int, taskID int, blockedByID int) error {
+	t, ok := r.find(userID, taskID)
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	if _, ok := r.find(userID, blockedByID); !ok {
+		return store.ErrRecordNotFound
+	}
+
+	if r.blockedBy(blockedByID, taskID) {
+		return store.ErrDependencyCycle
+	}
+
+	for _, id := range t.BlockedBy {
+		if id == blockedByID {
+			return nil
+		}
+	}
+
+	t.BlockedBy = append(t.BlockedBy, blockedByID)
+
+	return nil
+}
+
+func (r *TodoRepository) RemoveDependency(userID int, taskID int, blockedByID int) error {
+	t, ok := r.find(userID, taskID)
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	for i, id := range t.BlockedBy {
+		if id == blockedByID {
+			t.BlockedBy = append(t.BlockedBy[:i:i], t.BlockedBy[i+1:]...)
+			return nil
+		}
+	}
+
+	return store.ErrRecordNotFound
+}
+
+func (r *TodoRepository) Stats(userID int, q *model.TaskStatsQuery) (*model.TaskStats, error) {
+	if err := q.Validation(); err != nil {
+		return nil, err
+	}
+
+	stats := &model.TaskStats{Windows: []*model.TaskStatsWindow{}}
+	tasks := []*model.Task{}
+	var leadTime float64
+
+	for _, t := range r.Tasks {
+		if t.UserID != userID || !r.inWorkspace(t) {
+			continue
+		}
+
+		if t.DeletedAt != nil {
+			stats.Trashed++
+			continue
+		}
+
+		tasks = append(tasks, t)
+		stats.Total++
+
+		if t.Complete == nil || !*t.Complete {
+			stats.Open++
+			if t.Deadline != nil && t.Deadline.Before(q.Now) {
+				stats.Overdue++
+			}
+			continue
+		}
+
+		stats.Complete++
+		if t.CompletedAt != nil {
+			leadTime += t.CompletedAt.Sub(t.CreatedAt).Seconds()
+		}
+	}
+
+	completedWithTime := 0
+	days := map[string]bool{}
+	for _, t := range tasks {
+		if t.CompletedAt != nil {
+			days[t.CompletedAt.Format("2006-01-02")] = true
+			if t.Complete != nil && *t.Complete {
+				completedWithTime++
+			}
+		}
+	}
+
+	if completedWithTime > 0 {
+		avg := leadTime / float64(completedWithTime)
+		stats.AverageLeadTime = &avg
+	}
+
+	windows := append([]int{}, q.Windows...)
+	sort.Ints(windows)
+
+	for _, n := range windows {
+		since := q.Now.AddDate(0, 0, -n)
+		var created, createdComplete, completed int
+
+		for _, t := range tasks {
+			if !t.CreatedAt.Before(since) {
+				created++
+				if t.Complete != nil && *t.Complete {
+					createdComplete++
+				}
+			}
+
+			if t.CompletedAt != nil && !t.CompletedAt.Before(since) {
+				completed++
+			}
+		}
+
+		stats.Windows = append(stats.Windows, model.NewTaskStatsWindow(n, created, createdComplete, completed))
+	}
+
+	// the current streak may end yesterday, while nothing is completed yet
+	// today
+	day := q.Now.Truncate(24 * time.Hour)
+	if !days[day.Format("2006-01-02")] {
+		day = day.AddDate(0, 0, -1)
+	}
+	for days[day.Format("2006-01-02")] {
+		stats.Streak.Current++
+		day = day.AddDate(0, 0, -1)
+	}
+
+	for d := range days {
+		start, _ := time.Parse("2006-01-02", d)
+		if days[start.AddDate(0, 0, -1).Format("2006-01-02")] {
+			continue
+		}
+
+		length := 0
+		for day := start; days[day.Format("2006-01-02")]; day = day.AddDate(0, 0, 1) {
+			length++
+		}
+
+		if length > stats.Streak.Longest {
+			stats.Streak.Longest = length
+		}
+	}
+
+	return stats, nil
+}
+
+// blockedBy reports whether the task is the other task or waits for it,
+// directly or through the tasks that block it.
+func (r *TodoRepository) blockedBy(taskID int, otherID int) bool {
+	seen := map[int]bool{}
+	ids := []int{taskID}
+
+	for len(ids) > 0 {
+		id := ids[len(ids)-1]
+		ids = ids[:len(ids)-1]
+
+		if id == otherID {
+			return true
+		}
+
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if t, ok := r.Tasks[id]; ok {
+			ids = append(ids, t.BlockedBy...)
+		}
+	}
+
+	return false
+}
+
+// uidTaken reports whether a task of the user that is not in the trash has
+// the UID, the unique index of the postgres repository.
+func (r *TodoRepository) uidTaken(userID int, uid string) bool {
+	for _, t := range r.Tasks {
+		if t.UserID == userID && t.DeletedAt == nil && t.ICalUID != nil && *t.ICalUID == uid {
+			return true
+		}
+	}
+
+	return false
+}
+
+// blocked reports whether t completes the stored task while an open task
+// blocks it and the completion is not forced.
+func (r *TodoRepository) blocked(t *model.Task, stored *model.Task) bool {
+	if t.Complete == nil || !*t.Complete || (stored.Complete != nil && *stored.Complete) || t.ForceComplete {
+		return false
+	}
+
+	for _, id := range stored.BlockedBy {
+		if blocker, ok := r.Tasks[id]; ok && blocker.DeletedAt == nil && (blocker.Complete == nil || !*blocker.Complete) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// subtreeBlocked reports whether an open descendant of the task is blocked
+// by an open task outside of the subtree.
+func (r *TodoRepository) subtreeBlocked(taskID int) bool {
+	ids := r.subtree(taskID, nil)
+
+	inTree := map[int]bool{}
+	for _, id := range ids {
+		inTree[id] = true
+	}
+
+	for _, id := range ids[1:] {
+		sub := r.Tasks[id]
+		if sub.Complete != nil && *sub.Complete {
+			continue
+		}
+
+		for _, blockerID := range sub.BlockedBy {
+			blocker, ok := r.Tasks[blockerID]
+			if ok && !inTree[blockerID] && blocker.DeletedAt == nil && (blocker.Complete == nil || !*blocker.Complete) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// output copies the task the way the postgres repository reads it: the
+// tasks in the trash neither block it nor are blocked by it.
+func (r *TodoRepository) output(t *model.Task) *model.Task {
+	c := r.withStatus(t)
+	c.BlockedBy, c.Blocking = []int{}, []int{}
+
+	c.ParentICalUID = nil
+	if parent, ok := r.Tasks[derefID(t.ParentTaskID)]; ok {
+		c.ParentICalUID = parent.ICalUID
+	}
+
+	for _, id := range t.BlockedBy {
+		if blocker, ok := r.Tasks[id]; ok && blocker.DeletedAt == nil {
+			c.BlockedBy = append(c.BlockedBy, id)
+		}
+	}
+
+	for _, other := range r.Tasks {
+		if other.DeletedAt != nil {
+			continue
+		}
+
+		for _, id := range other.BlockedBy {
+			if id == t.TaskID {
+				c.Blocking = append(c.Blocking, other.TaskID)
+			}
+		}
+	}
+
+	sort.Ints(c.BlockedBy)
+	sort.Ints(c.Blocking)
+
+	now := time.Now().UTC()
+	for _, e := range r.TimeEntries {
+		if e.TaskID == t.TaskID {
+			e.SetDuration(now)
+			c.TimeSpent += e.Duration
+		}
+	}
+
+	return c
+}
+
+// sharedWith reports whether the task, one of its parents or its list is
+// shared with the user.
+func (r *TodoRepository) sharedWith(t *model.Task, userID int) bool {
+	for ; t != nil; t = r.Tasks[derefID(t.ParentTaskID)] {
+		for _, s := range r.Shares {
+			if s.GranteeID != userID || s.OwnerID != t.UserID || s.Status != model.ShareStatusAccepted {
+				continue
+			}
+
+			if derefID(s.TaskID) == t.TaskID || (s.ListID != nil && derefID(t.ListID) == *s.ListID) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// checkVersion fails when the writer of t expects another version than the
+// one of the stored task.
+func checkVersion(t *model.Task, stored *model.Task) error {
+	if t.IfVersion != nil && *t.IfVersion != stored.Version {
+		return store.ErrVersionConflict
+	}
+
+	return nil
+}
+
+// find returns the task unless it is in the trash.
+func (r *TodoRepository) find(userID int, taskID int) (*model.Task, bool) {
+	t, ok := r.Tasks[taskID]
+	if !ok || t.UserID != userID || !r.inWorkspace(t) || t.DeletedAt != nil {
+		return nil, false
+	}
+
+	return t, true
+}
+
+// subtree returns the id of the task followed by the ids of its descendants
+// deleted at the given moment, or of the ones not deleted when it is nil.
+func (r *TodoRepository) subtree(taskID int, deletedAt *time.Time) []int {
+	ids := []int{taskID}
+
+	for i := 0; i < len(ids); i++ {
+		for _, t := range r.Tasks {
+			if t.ParentTaskID == nil || *t.ParentTaskID != ids[i] {
+				continue
+			}
+
+			if (deletedAt == nil && t.DeletedAt == nil) || (deletedAt != nil && t.DeletedAt != nil && t.DeletedAt.Equal(*deletedAt)) {
+				ids = append(ids, t.TaskID)
+			}
+		}
+	}
+
+	return ids
+}
+
+// attachTags creates the tags of the user that do not exist yet, the same
+// way the postgres repository does.
+func (r *TodoRepository) attachTags(userID int, names []string) []string {
+	names = model.NormalizeTags(names)
+
+	for _, name := range names {
+		exists := false
+		for _, tag := range r.Tags {
+			if tag.UserID == userID && tag.Name == name {
+				exists = true
+				break
+			}
+		}
+
+		if !exists {
+			id := len(r.Tags) + 1
+			for r.Tags[id] != nil {
+				id++
+			}
+			r.Tags[id] = &model.Tag{ID: id, UserID: userID, Name: name}
+		}
+	}
+
+	return names
+}
+
+// highlight encloses the matches of the needle in the markers of the
+// snippets. The text is compared rune by rune, lowering a string may change
+// its length.
+func highlight(s, needle string) string {
+	text := []rune(strings.NewReplacer(model.SnippetStart, "", model.SnippetStop, "").Replace(s))
+	pattern := []rune(needle)
+	if len(pattern) == 0 {
+		return string(text)
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(text); {
+		if i+len(pattern) <= len(text) && matchFold(text[i:i+len(pattern)], pattern) {
+			b.WriteString(model.SnippetStart + string(text[i:i+len(pattern)]) + model.SnippetStop)
+			i += len(pattern)
+			continue
+		}
+
+		b.WriteRune(text[i])
+		i++
+	}
+
+	return b.String()
+}
+
+func matchFold(text, pattern []rune) bool {
+	for i := range pattern {
+		if unicode.ToLower(text[i]) != unicode.ToLower(pattern[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *TodoRepository) Export(userID int, fn func(*model.Task) error) error {
+	ids := []int{}
+	for id, t := range r.Tasks {
+		if t.UserID == userID && r.inWorkspace(t) && t.DeletedAt == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		if err := fn(r.output(r.Tasks[id])); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-func (r *TodoRepository) Delete(userID int, taskID []int) (int64, error) {
-	return 0, nil
-}
\ No newline at end of file
+func (r *TodoRepository) SyncToken(userID int) (int, error) {
+	token := 0
+	for _, e := range r.base().Events {
+		if e.UserID == userID && derefID(e.WorkspaceID) == r.workspace {
+			token = e.ID
+		}
+	}
+
+	return token, nil
+}
+
+func (r *TodoRepository) Changes(userID int, since int) (*model.TaskChanges, error) {
+	token, _ := r.SyncToken(userID)
+	changes := &model.TaskChanges{Token: token, Changed: []*model.Task{}, Deleted: []*model.Task{}}
+
+	ids := []int{}
+	seen := map[int]bool{}
+	for _, e := range r.base().Events {
+		if e.UserID == userID && derefID(e.WorkspaceID) == r.workspace && e.ID > since && e.ID <= token && !seen[e.TaskID] {
+			seen[e.TaskID] = true
+			ids = append(ids, e.TaskID)
+		}
+	}
+
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		t, ok := r.Tasks[id]
+		switch {
+		case ok && !r.inWorkspace(t):
+			continue
+		case !ok:
+			changes.Deleted = append(changes.Deleted, &model.Task{UserID: userID, TaskID: id})
+		case t.DeletedAt != nil:
+			changes.Deleted = append(changes.Deleted, &model.Task{UserID: userID, TaskID: id, ICalUID: t.ICalUID})
+		default:
+			changes.Changed = append(changes.Changed, r.output(t))
+		}
+	}
+
+	return changes, nil
+}
+
+func (r *TodoRepository) History(userID int, taskID int) ([]*model.TaskEvent, error) {
+	if t, ok := r.Tasks[taskID]; !ok || t.UserID != userID || !r.inWorkspace(t) {
+		return nil, store.ErrRecordNotFound
+	}
+
+	events := []*model.TaskEvent{}
+	for _, e := range r.base().Events {
+		if e.UserID == userID && e.TaskID == taskID {
+			c := *e
+			events = append(events, &c)
+		}
+	}
+
+	return events, nil
+}
+
+// InTx restores the tasks, tags and history as they were before fn when it
+// fails. Unlike the postgres repository a failed write is not undone on its
+// own.
+func (r *TodoRepository) InTx(fn func(todo.TodoRepository) error) error {
+	tasks := make(map[int]*model.Task, len(r.Tasks))
+	for id, t := range r.Tasks {
+		tasks[id] = copyTask(t)
+	}
+
+	tags := make(map[int]*model.Tag, len(r.Tags))
+	for id, tag := range r.Tags {
+		c := *tag
+		tags[id] = &c
+	}
+
+	reminders := make(map[int]bool, len(r.Reminders))
+	for id := range r.Reminders {
+		reminders[id] = true
+	}
+
+	events, lastID := len(r.base().Events), r.base().lastID
+
+	if err := fn(r); err != nil {
+		// the maps are shared with the other repositories, they are
+		// restored in place
+		for id := range r.Tasks {
+			delete(r.Tasks, id)
+		}
+		for id, t := range tasks {
+			r.Tasks[id] = t
+		}
+
+		for id := range r.Tags {
+			delete(r.Tags, id)
+		}
+		for id, tag := range tags {
+			r.Tags[id] = tag
+		}
+
+		// the reminders are only added, by the next occurrences
+		for id := range r.Reminders {
+			if !reminders[id] {
+				delete(r.Reminders, id)
+			}
+		}
+
+		r.base().Events, r.base().lastID = r.base().Events[:events], lastID
+
+		return err
+	}
+
+	return nil
+}
+
+// afterChange records the change of t in its history and creates the next
+// occurrence of a recurring task that has just been completed, its ID goes
+// to t.NextTaskID.
+func (r *TodoRepository) afterChange(t *model.Task, before *model.Task, after *model.Task) error {
+	r.recordEvent(model.NewTaskEvent(t.ActorID, before, after))
+
+	if (before.Complete != nil && *before.Complete) || after.Complete == nil || !*after.Complete {
+		return nil
+	}
+
+	next, err := after.NextOccurrence(time.Now().UTC())
+	if err != nil || next == nil {
+		return err
+	}
+
+	next.ActorID = t.ActorID
+
+	if err := r.Create(next); err != nil {
+		return err
+	}
+
+	t.NextTaskID = next.TaskID
+
+	for _, rem := range r.copyReminders(after.TaskID) {
+		rem.TaskID = next.TaskID
+		r.Reminders[rem.ID] = rem
+	}
+
+	return nil
+}
+
+// copyReminders returns copies of the reminders of the task with new IDs,
+// they have not been sent.
+func (r *TodoRepository) copyReminders(taskID int) []*model.Reminder {
+	ids := []int{}
+	for other, rem := range r.Reminders {
+		if rem.TaskID == taskID {
+			ids = append(ids, other)
+		}
+	}
+
+	sort.Ints(ids)
+
+	reminders := []*model.Reminder{}
+	for _, other := range ids {
+		*r.LastReminderID++
+		rem := r.Reminders[other]
+		reminders = append(reminders, &model.Reminder{ID: *r.LastReminderID, UserID: rem.UserID, TaskID: rem.TaskID, Offset: rem.Offset})
+	}
+
+	return reminders
+}
+
+func (r *TodoRepository) recordEvent(e *model.TaskEvent) {
+	if e == nil {
+		return
+	}
+
+	e.ID = len(r.base().Events) + 1
+	e.CreatedAt = time.Now().UTC()
+	r.base().Events = append(r.base().Events, e)
+}
+
+func (r *TodoRepository) InWorkspace(workspaceID int) todo.TodoRepository {
+	return &TodoRepository{
+		Tasks:       r.Tasks,
+		Tags:        r.Tags,
+		Shares:      r.Shares,
+		TimeEntries: r.TimeEntries,
+		Lists:       r.Lists,
+		Reminders:   r.Reminders,
+		Attachments: r.Attachments,
+
+		LastReminderID: r.LastReminderID,
+		workspace:      workspaceID,
+		root:           r.base(),
+	}
+}
+
+func (r *TodoRepository) Workspace() int {
+	return r.workspace
+}
+
+// base is the repository that keeps the history and the last ID.
+func (r *TodoRepository) base() *TodoRepository {
+	if r.root != nil {
+		return r.root
+	}
+
+	return r
+}
+
+// inWorkspace reports whether the task is in the workspace of the
+// repository.
+func (r *TodoRepository) inWorkspace(t *model.Task) bool {
+	return derefID(t.WorkspaceID) == r.workspace
+}
+
+func (r *TodoRepository) workspaceID() *int {
+	if r.workspace == model.PersonalWorkspaceID {
+		return nil
+	}
+
+	id := r.workspace
+
+	return &id
+}
+
+func derefID(id *int) int {
+	if id == nil {
+		return 0
+	}
+
+	return *id
+}
+
+// completedAt returns when a task with the complete flag was completed: the
+// moment it was completed before, now if it has just been completed and nil
+// while it is open.
+func completedAt(complete *bool, before *time.Time) *time.Time {
+	if complete == nil || !*complete {
+		return nil
+	}
+
+	if before != nil {
+		return before
+	}
+
+	now := time.Now().UTC()
+
+	return &now
+}
+
+// withStatus copies the task with the status the postgres repository reads:
+// the status it was given, or else the default status of the workflow of its
+// list.
+func (r *TodoRepository) withStatus(t *model.Task) *model.Task {
+	c := copyTask(t)
+
+	if c.Status == nil {
+		w := model.DefaultWorkflow()
+		if l, ok := r.Lists[derefID(t.ListID)]; ok {
+			w = l.TaskWorkflow()
+		}
+
+		status := w.DefaultStatus(t.Complete != nil && *t.Complete)
+		c.Status = &status
+	}
+
+	return c
+}
+
+// sameFlag reports whether the complete flags agree, an unset flag is
+// false.
+func sameFlag(a *bool, b *bool) bool {
+	return (a != nil && *a) == (b != nil && *b)
+}
+
+func nilIfEmpty(s *string) *string {
+	if s == nil || *s == "" {
+		return nil
+	}
+
+	return s
+}
+
+func copyTask(t *model.Task) *model.Task {
+	c := *t
+	c.Tags = append([]string{}, t.Tags...)
+	c.BlockedBy = append([]int{}, t.BlockedBy...)
+	c.Blocking = append([]int{}, t.Blocking...)
+	c.Children = nil
+
+	return &c
+}
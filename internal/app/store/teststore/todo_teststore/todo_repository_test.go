@@ -0,0 +1,93 @@
+package todo_teststore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/teststore"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store/teststore/todo_teststore"
+)
+
+func TestTodoRepository_Get(t *testing.T) {
+	s := teststore.New()
+
+	for i := 3; i > 0; i-- {
+		task := model.TestTask(t, 1)
+		deadline := task.Deadline.Add(time.Duration(i) * time.Hour)
+		task.Deadline = &deadline
+		assert.NoError(t, s.Todo().Create(task))
+	}
+	assert.NoError(t, s.Todo().Create(model.TestTask(t, 2)))
+
+	q := model.NewTaskQuery()
+	q.Limit = 2
+
+	page, err := s.Todo().Get(1, q)
+	assert.NoError(t, err)
+	assert.Len(t, page.Tasks, 2)
+	assert.Equal(t, 3, page.Tasks[0].TaskID)
+	assert.Equal(t, 2, page.Tasks[1].TaskID)
+
+	cursor, err := model.DecodeTaskCursor(page.NextCursor)
+	assert.NoError(t, err)
+
+	q.Cursor = cursor
+
+	page, err = s.Todo().Get(1, q)
+	assert.NoError(t, err)
+	assert.Len(t, page.Tasks, 1)
+	assert.Equal(t, 1, page.Tasks[0].TaskID)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestTodoRepository_Restore(t *testing.T) {
+	s := teststore.New()
+
+	parent := model.TestTask(t, 1)
+	assert.NoError(t, s.Todo().Create(parent))
+	child := model.TestTask(t, 1)
+	child.ParentTaskID = &parent.TaskID
+	assert.NoError(t, s.Todo().Create(child))
+
+	count, err := s.Todo().Delete(1, []int{parent.TaskID}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	trash, err := s.Todo().Trash(1)
+	assert.NoError(t, err)
+	assert.Len(t, trash, 2)
+
+	assert.Error(t, s.Todo().Restore(1, child.TaskID, 1))
+	assert.NoError(t, s.Todo().Restore(1, parent.TaskID, 1))
+	assert.Error(t, s.Todo().Restore(1, parent.TaskID, 1))
+
+	tree, err := s.Todo().FindTree(1, parent.TaskID)
+	assert.NoError(t, err)
+	assert.Len(t, tree.Children, 1)
+}
+
+func TestTodoRepository_History(t *testing.T) {
+	s := teststore.New()
+
+	task := model.TestTask(t, 1)
+	assert.NoError(t, s.Todo().Create(task))
+
+	events, err := s.Todo().History(1, task.TaskID)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	// a task stored before its history was recorded has no events
+	s.Todo().(*todo_teststore.TodoRepository).Events = nil
+
+	events, err = s.Todo().History(1, task.TaskID)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	_, err = s.Todo().History(2, task.TaskID)
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+	_, err = s.Todo().History(1, task.TaskID+1)
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+}
@@ -10,6 +10,7 @@ import (
 type UserRepository struct {
 	Store *store.Store
 	Users map[int]*model.User
+	Lists map[int]*model.List
 }
 
 func (r *UserRepository) Create(u *model.User) error {
@@ -23,6 +24,20 @@ func (r *UserRepository) Create(u *model.User) error {
 
 	u.ID = len(r.Users) + 1
 	r.Users[u.ID] = u
+
+	if r.Lists != nil {
+		id := len(r.Lists) + 1
+		for r.Lists[id] != nil {
+			id++
+		}
+		r.Lists[id] = &model.List{
+			ID:        id,
+			UserID:    u.ID,
+			Name:      model.InboxListName,
+			Inbox:     true,
+			CreatedAt: time.Now().UTC(),
+		}
+	}
 		
 	return nil
 }
@@ -0,0 +1,145 @@
+package webhook_teststore
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+// WebhookRepository is safe for concurrent use, deliveries are recorded by
+// the dispatcher while the handlers serve requests.
+type WebhookRepository struct {
+	Webhooks   map[int]*model.Webhook
+	Deliveries []*model.WebhookDelivery
+
+	mu sync.Mutex
+}
+
+func (r *WebhookRepository) Create(w *model.Webhook) error {
+	if err := w.BeforeCreate(); err != nil {
+		return err
+	}
+
+	if err := w.Validation(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if w.Events == nil {
+		w.Events = []string{}
+	}
+
+	w.ID = len(r.Webhooks) + 1
+	for r.Webhooks[w.ID] != nil {
+		w.ID++
+	}
+	w.CreatedAt = time.Now().UTC()
+
+	r.Webhooks[w.ID] = copyWebhook(w)
+
+	return nil
+}
+
+func (r *WebhookRepository) FindAll(userID int) ([]*model.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.find(func(w *model.Webhook) bool {
+		return w.UserID == userID
+	}), nil
+}
+
+func (r *WebhookRepository) FindByID(userID int, webhookID int) (*model.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.Webhooks[webhookID]
+	if !ok || w.UserID != userID {
+		return nil, store.ErrRecordNotFound
+	}
+
+	return copyWebhook(w), nil
+}
+
+func (r *WebhookRepository) Delete(userID int, webhookID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.Webhooks[webhookID]
+	if !ok || w.UserID != userID {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.Webhooks, webhookID)
+
+	return nil
+}
+
+func (r *WebhookRepository) FindByEvent(userID int, event string) ([]*model.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.find(func(w *model.Webhook) bool {
+		return w.UserID == userID && w.Subscribed(event)
+	}), nil
+}
+
+func (r *WebhookRepository) CreateDelivery(d *model.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d.ID = len(r.Deliveries) + 1
+	d.CreatedAt = time.Now().UTC()
+
+	c := *d
+	r.Deliveries = append(r.Deliveries, &c)
+
+	return nil
+}
+
+func (r *WebhookRepository) FindDeliveries(userID int, webhookID int) ([]*model.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.Webhooks[webhookID]
+	if !ok || w.UserID != userID {
+		return nil, store.ErrRecordNotFound
+	}
+
+	deliveries := []*model.WebhookDelivery{}
+	for i := len(r.Deliveries) - 1; i >= 0; i-- {
+		if r.Deliveries[i].WebhookID == webhookID {
+			c := *r.Deliveries[i]
+			deliveries = append(deliveries, &c)
+		}
+	}
+
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) find(match func(*model.Webhook) bool) []*model.Webhook {
+	webhooks := []*model.Webhook{}
+	for _, w := range r.Webhooks {
+		if match(w) {
+			webhooks = append(webhooks, copyWebhook(w))
+		}
+	}
+
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].ID < webhooks[j].ID
+	})
+
+	return webhooks
+}
+
+func copyWebhook(w *model.Webhook) *model.Webhook {
+	c := *w
+	c.Events = append([]string{}, w.Events...)
+
+	return &c
+}
@@ -0,0 +1,188 @@
+package workspace_teststore
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/store"
+)
+
+var errLastOwner = errors.New("a workspace needs an owner")
+
+type WorkspaceRepository struct {
+	Workspaces map[int]*model.Workspace
+	Members    map[int]map[int]*model.WorkspaceMember
+	Tasks      map[int]*model.Task
+	Lists      map[int]*model.List
+	lastID     int
+}
+
+func (r *WorkspaceRepository) Create(w *model.Workspace) error {
+	if err := w.Validation(); err != nil {
+		return err
+	}
+
+	r.lastID++
+	w.ID = r.lastID
+	w.CreatedAt = time.Now().UTC()
+	w.Role = model.RoleOwner
+
+	c := *w
+	c.Role = ""
+	r.Workspaces[w.ID] = &c
+	r.Members[w.ID] = map[int]*model.WorkspaceMember{
+		w.CreatedBy: {WorkspaceID: w.ID, UserID: w.CreatedBy, Role: model.RoleOwner, CreatedAt: w.CreatedAt},
+	}
+
+	return nil
+}
+
+func (r *WorkspaceRepository) FindByID(id int) (*model.Workspace, error) {
+	w, ok := r.Workspaces[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	c := *w
+
+	return &c, nil
+}
+
+func (r *WorkspaceRepository) FindByUser(userID int) ([]*model.Workspace, error) {
+	workspaces := []*model.Workspace{}
+	for id, w := range r.Workspaces {
+		if m, ok := r.Members[id][userID]; ok {
+			c := *w
+			c.Role = m.Role
+			workspaces = append(workspaces, &c)
+		}
+	}
+
+	sort.Slice(workspaces, func(i, j int) bool {
+		return workspaces[i].ID < workspaces[j].ID
+	})
+
+	return workspaces, nil
+}
+
+func (r *WorkspaceRepository) Update(w *model.Workspace) error {
+	if err := w.Validation(); err != nil {
+		return err
+	}
+
+	stored, ok := r.Workspaces[w.ID]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	stored.Name = w.Name
+	w.CreatedBy, w.CreatedAt = stored.CreatedBy, stored.CreatedAt
+
+	return nil
+}
+
+func (r *WorkspaceRepository) Delete(id int) error {
+	if _, ok := r.Workspaces[id]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	for taskID, t := range r.Tasks {
+		if t.WorkspaceID != nil && *t.WorkspaceID == id {
+			delete(r.Tasks, taskID)
+		}
+	}
+
+	for listID, l := range r.Lists {
+		if l.WorkspaceID != nil && *l.WorkspaceID == id {
+			delete(r.Lists, listID)
+		}
+	}
+
+	delete(r.Members, id)
+	delete(r.Workspaces, id)
+
+	return nil
+}
+
+func (r *WorkspaceRepository) FindMember(workspaceID int, userID int) (*model.WorkspaceMember, error) {
+	m, ok := r.Members[workspaceID][userID]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	c := *m
+
+	return &c, nil
+}
+
+func (r *WorkspaceRepository) FindMembers(workspaceID int) ([]*model.WorkspaceMember, error) {
+	members := []*model.WorkspaceMember{}
+	for _, m := range r.Members[workspaceID] {
+		c := *m
+		members = append(members, &c)
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].UserID < members[j].UserID
+	})
+
+	return members, nil
+}
+
+func (r *WorkspaceRepository) SaveMember(m *model.WorkspaceMember) error {
+	if err := m.Validation(); err != nil {
+		return err
+	}
+
+	members, ok := r.Members[m.WorkspaceID]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	stored, ok := members[m.UserID]
+	if !ok {
+		c := *m
+		c.CreatedAt = time.Now().UTC()
+		members[m.UserID] = &c
+		m.CreatedAt = c.CreatedAt
+
+		return nil
+	}
+
+	if stored.Role == model.RoleOwner && m.Role != model.RoleOwner && r.owners(m.WorkspaceID) == 1 {
+		return errLastOwner
+	}
+
+	stored.Role = m.Role
+	m.CreatedAt = stored.CreatedAt
+
+	return nil
+}
+
+func (r *WorkspaceRepository) DeleteMember(workspaceID int, userID int) error {
+	m, ok := r.Members[workspaceID][userID]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	if m.Role == model.RoleOwner && r.owners(workspaceID) == 1 {
+		return errLastOwner
+	}
+
+	delete(r.Members[workspaceID], userID)
+
+	return nil
+}
+
+func (r *WorkspaceRepository) owners(workspaceID int) int {
+	n := 0
+	for _, m := range r.Members[workspaceID] {
+		if m.Role == model.RoleOwner {
+			n++
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,415 @@
+// Package taskio writes tasks to and reads them from the CSV, JSON and
+// NDJSON files of the import and export endpoints. A CSV file holds the
+// columns of csvHeader, the JSON and NDJSON files hold the tasks as the API
+// returns them. The fields an import reads are in every format, so a file
+// exported in one format can be imported back.
+package taskio
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+var errUnknownFormat = errors.New("unknown format: use csv, json or ndjson")
+
+// csvHeader lists the columns of a CSV file. The tags column holds a JSON
+// array of the tags.
+var csvHeader = []string{"task_id", "list_id", "parent_task_id", "title", "description", "deadline", "complete", "tags", "rrule", "created_at"}
+
+// ContentType returns the media type of the format.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/json"
+	}
+}
+
+// ParseFormat checks the name of a format, an empty name is JSON.
+func ParseFormat(s string) (string, error) {
+	switch s {
+	case "":
+		return FormatJSON, nil
+	case FormatCSV, FormatJSON, FormatNDJSON:
+		return s, nil
+	}
+
+	return "", errUnknownFormat
+}
+
+// Encoder writes the tasks one by one, Close completes the file.
+type Encoder interface {
+	Encode(*model.Task) error
+	Close() error
+}
+
+func NewEncoder(w io.Writer, format string) (Encoder, error) {
+	switch format {
+	case FormatCSV:
+		return &csvEncoder{w: csv.NewWriter(w)}, nil
+	case FormatJSON:
+		return &jsonEncoder{w: w}, nil
+	case FormatNDJSON:
+		return &ndjsonEncoder{enc: json.NewEncoder(w)}, nil
+	}
+
+	return nil, errUnknownFormat
+}
+
+type csvEncoder struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (e *csvEncoder) Encode(t *model.Task) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	tags, err := json.Marshal(t.Tags)
+	if err != nil {
+		return err
+	}
+
+	deadline := ""
+	if t.Deadline != nil {
+		deadline = t.Deadline.UTC().Format(time.RFC3339)
+	}
+
+	complete := t.Complete != nil && *t.Complete
+
+	return e.w.Write([]string{
+		strconv.Itoa(t.TaskID),
+		formatID(t.ListID),
+		formatID(t.ParentTaskID),
+		deref(t.Title),
+		deref(t.Description),
+		deadline,
+		strconv.FormatBool(complete),
+		string(tags),
+		deref(t.RRule),
+		t.CreatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+func (e *csvEncoder) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	e.w.Flush()
+
+	return e.w.Error()
+}
+
+func (e *csvEncoder) writeHeader() error {
+	if e.header {
+		return nil
+	}
+
+	e.header = true
+
+	return e.w.Write(csvHeader)
+}
+
+// jsonEncoder writes a JSON array without holding the tasks in memory.
+type jsonEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonEncoder) Encode(t *model.Task) error {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	sep := ",\n"
+	if e.count == 0 {
+		sep = "[\n"
+	}
+	e.count++
+
+	if _, err := io.WriteString(e.w, sep); err != nil {
+		return err
+	}
+
+	_, err = e.w.Write(b)
+
+	return err
+}
+
+func (e *jsonEncoder) Close() error {
+	end := "\n]\n"
+	if e.count == 0 {
+		end = "[]\n"
+	}
+
+	_, err := io.WriteString(e.w, end)
+
+	return err
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) Encode(t *model.Task) error {
+	return e.enc.Encode(t)
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
+
+// Record is a task read from a file. Row counts the tasks of the file from
+// 1, the header of a CSV file is not counted. TaskID and ParentTaskID of
+// the task are the IDs in the file. Err reports a task that could not be
+// read, the other tasks of the file are still read.
+type Record struct {
+	Row  int
+	Task *model.Task
+	Err  error
+}
+
+// record is a task as it is read from JSON and NDJSON files, the other
+// fields of an exported task are ignored.
+type record struct {
+	TaskID       int      `json:"task_id"`
+	ListID       *int     `json:"list_id"`
+	ParentTaskID *int     `json:"parent_task_id"`
+	Title        *string  `json:"title"`
+	Description  *string  `json:"description"`
+	Deadline     *string  `json:"deadline"`
+	Complete     *bool    `json:"complete"`
+	Tags         []string `json:"tags"`
+	RRule        *string  `json:"rrule"`
+}
+
+func (rec *record) task() (*model.Task, error) {
+	t := &model.Task{
+		TaskID:       rec.TaskID,
+		ListID:       rec.ListID,
+		ParentTaskID: rec.ParentTaskID,
+		Title:        rec.Title,
+		Description:  rec.Description,
+		Complete:     rec.Complete,
+		Tags:         model.NormalizeTags(rec.Tags),
+		RRule:        rec.RRule,
+	}
+
+	if rec.Deadline != nil {
+		deadline, err := model.ParseTime(*rec.Deadline)
+		if err != nil {
+			return nil, err
+		}
+		t.Deadline = &deadline
+	}
+
+	return t, nil
+}
+
+// Decode reads the tasks of a file. The error reports a file that is not in
+// the format at all, the errors of single tasks are in their records.
+func Decode(r io.Reader, format string) ([]*Record, error) {
+	switch format {
+	case FormatCSV:
+		return decodeCSV(r)
+	case FormatJSON:
+		return decodeJSON(r)
+	case FormatNDJSON:
+		return decodeNDJSON(r)
+	}
+
+	return nil, errUnknownFormat
+}
+
+func decodeJSON(r io.Reader) ([]*Record, error) {
+	raw := []json.RawMessage{}
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	records := make([]*Record, 0, len(raw))
+	for i, b := range raw {
+		records = append(records, decodeRecord(i+1, b))
+	}
+
+	return records, nil
+}
+
+func decodeNDJSON(r io.Reader) ([]*Record, error) {
+	dec := json.NewDecoder(r)
+	records := []*Record{}
+
+	for row := 1; ; row++ {
+		var b json.RawMessage
+		if err := dec.Decode(&b); err != nil {
+			if err == io.EOF {
+				return records, nil
+			}
+			return nil, fmt.Errorf("row %d: %w", row, err)
+		}
+
+		records = append(records, decodeRecord(row, b))
+	}
+}
+
+func decodeRecord(row int, b []byte) *Record {
+	rec := &record{}
+	if err := json.Unmarshal(b, rec); err != nil {
+		return &Record{Row: row, Err: err}
+	}
+
+	t, err := rec.task()
+
+	return &Record{Row: row, Task: t, Err: err}
+}
+
+func decodeCSV(r io.Reader) ([]*Record, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("the file has no header")
+		}
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+
+	if _, ok := columns["title"]; !ok {
+		return nil, errors.New("the file has no title column")
+	}
+
+	records := []*Record{}
+
+	for row := 1; ; row++ {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); !ok {
+				return nil, err
+			}
+			records = append(records, &Record{Row: row, Err: err})
+			continue
+		}
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(fields) {
+				return ""
+			}
+			return fields[i]
+		}
+
+		t, err := csvTask(field)
+		records = append(records, &Record{Row: row, Task: t, Err: err})
+	}
+}
+
+// csvTask reads a task from the fields of a CSV row, empty fields are
+// unset.
+func csvTask(field func(string) string) (*model.Task, error) {
+	rec := &record{}
+	var err error
+
+	if s := field("task_id"); s != "" {
+		if rec.TaskID, err = strconv.Atoi(s); err != nil {
+			return nil, fmt.Errorf("invalid task_id %q", s)
+		}
+	}
+
+	if rec.ListID, err = parseID(field, "list_id"); err != nil {
+		return nil, err
+	}
+
+	if rec.ParentTaskID, err = parseID(field, "parent_task_id"); err != nil {
+		return nil, err
+	}
+
+	title := field("title")
+	rec.Title = &title
+
+	if s := field("description"); s != "" {
+		rec.Description = &s
+	}
+
+	if s := field("deadline"); s != "" {
+		rec.Deadline = &s
+	}
+
+	if s := field("complete"); s != "" {
+		complete, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid complete %q", s)
+		}
+		rec.Complete = &complete
+	}
+
+	if s := field("tags"); s != "" {
+		if err := json.Unmarshal([]byte(s), &rec.Tags); err != nil {
+			return nil, errors.New("tags must be a JSON array of strings")
+		}
+	}
+
+	if s := field("rrule"); s != "" {
+		rec.RRule = &s
+	}
+
+	return rec.task()
+}
+
+func parseID(field func(string) string, name string) (*int, error) {
+	s := field(name)
+	if s == "" {
+		return nil, nil
+	}
+
+	id, err := strconv.Atoi(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", name, s)
+	}
+
+	return &id, nil
+}
+
+func formatID(id *int) string {
+	if id == nil {
+		return ""
+	}
+
+	return strconv.Itoa(*id)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
@@ -0,0 +1,79 @@
+package taskio_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/model"
+	"github.com/vo1dFl0w/taskmanager-api/internal/app/taskio"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	parent := model.TestTask(t, 1)
+	parent.TaskID = 1
+	parent.Tags = []string{"home", "a,b"}
+
+	child := model.TestTask(t, 1)
+	child.TaskID = 2
+	child.ParentTaskID = &parent.TaskID
+	description := "line one\nline \"two\""
+	child.Description = &description
+
+	for _, format := range []string{taskio.FormatCSV, taskio.FormatJSON, taskio.FormatNDJSON} {
+		t.Run(format, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			enc, err := taskio.NewEncoder(b, format)
+			assert.NoError(t, err)
+			assert.NoError(t, enc.Encode(parent))
+			assert.NoError(t, enc.Encode(child))
+			assert.NoError(t, enc.Close())
+
+			records, err := taskio.Decode(b, format)
+			assert.NoError(t, err)
+			assert.Len(t, records, 2)
+
+			for _, rec := range records {
+				assert.NoError(t, rec.Err)
+			}
+
+			assert.Equal(t, 1, records[0].Row)
+			assert.Equal(t, []string{"a,b", "home"}, records[0].Task.Tags)
+			assert.Equal(t, *parent.Title, *records[0].Task.Title)
+			assert.True(t, parent.Deadline.Truncate(time.Second).Equal(*records[0].Task.Deadline))
+			assert.Equal(t, 1, *records[1].Task.ParentTaskID)
+			assert.Equal(t, description, *records[1].Task.Description)
+		})
+	}
+}
+
+func TestEncode_Empty(t *testing.T) {
+	b := &bytes.Buffer{}
+	enc, _ := taskio.NewEncoder(b, taskio.FormatJSON)
+	assert.NoError(t, enc.Close())
+
+	records, err := taskio.Decode(b, taskio.FormatJSON)
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestDecode_RowErrors(t *testing.T) {
+	csv := "title,deadline,complete\nok,2030-01-02 10:00:00,false\nbad,tomorrow,false\nworse,2030-01-02 10:00:00,maybe\n"
+
+	records, err := taskio.Decode(strings.NewReader(csv), taskio.FormatCSV)
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.NoError(t, records[0].Err)
+	assert.Error(t, records[1].Err)
+	assert.Error(t, records[2].Err)
+
+	_, err = taskio.Decode(strings.NewReader("name\nx\n"), taskio.FormatCSV)
+	assert.Error(t, err)
+
+	records, err = taskio.Decode(strings.NewReader("{\"title\":\"a\"}\n{\"title\":1}\n"), taskio.FormatNDJSON)
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Error(t, records[1].Err)
+}
@@ -0,0 +1,2 @@
+ALTER TABLE tasks
+DROP COLUMN created_at;
\ No newline at end of file
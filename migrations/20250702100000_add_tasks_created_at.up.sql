@@ -0,0 +1,2 @@
+ALTER TABLE tasks
+ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'utc');
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP INDEX tasks_search_idx;
+
+ALTER TABLE tasks
+DROP COLUMN search;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE tasks
+DROP COLUMN list_id;
+
+DROP TABLE lists;
\ No newline at end of file
@@ -0,0 +1,17 @@
+CREATE TABLE lists (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    name VARCHAR NOT NULL,
+    inbox BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX lists_inbox_idx ON lists (user_id) WHERE inbox;
+
+INSERT INTO lists (user_id, name, inbox)
+SELECT id, 'Inbox', true FROM users;
+
+ALTER TABLE tasks
+ADD COLUMN list_id BIGINT,
+ADD FOREIGN KEY (list_id) REFERENCES lists(id) ON DELETE SET NULL;
\ No newline at end of file
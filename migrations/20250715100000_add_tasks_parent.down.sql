@@ -0,0 +1,4 @@
+DROP INDEX tasks_parent_task_id_idx;
+
+ALTER TABLE tasks
+DROP COLUMN parent_task_id;
\ No newline at end of file
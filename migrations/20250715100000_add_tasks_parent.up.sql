@@ -0,0 +1,5 @@
+ALTER TABLE tasks
+ADD COLUMN parent_task_id BIGINT,
+ADD FOREIGN KEY (parent_task_id) REFERENCES tasks(task_id) ON DELETE CASCADE;
+
+CREATE INDEX tasks_parent_task_id_idx ON tasks (parent_task_id);
\ No newline at end of file
@@ -0,0 +1,5 @@
+DROP INDEX tasks_series_id_idx;
+
+ALTER TABLE tasks
+DROP COLUMN series_id,
+DROP COLUMN rrule;
\ No newline at end of file
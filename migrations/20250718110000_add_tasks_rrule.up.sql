@@ -0,0 +1,5 @@
+ALTER TABLE tasks
+ADD COLUMN rrule VARCHAR,
+ADD COLUMN series_id VARCHAR;
+
+CREATE INDEX tasks_series_id_idx ON tasks (series_id);
\ No newline at end of file
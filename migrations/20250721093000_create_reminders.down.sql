@@ -0,0 +1 @@
+DROP TABLE reminders;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE reminders (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    task_id BIGINT NOT NULL,
+    offset_seconds BIGINT NOT NULL,
+    sent_at TIMESTAMP,
+    sent_deadline TIMESTAMP,
+    UNIQUE (task_id, offset_seconds),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (task_id) REFERENCES tasks(task_id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE task_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    task_id BIGINT NOT NULL,
+    actor_id BIGINT NOT NULL,
+    type VARCHAR NOT NULL,
+    changes JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX task_events_task_id_idx ON task_events (user_id, task_id, id);
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP INDEX tasks_deleted_at_idx;
+
+ALTER TABLE tasks
+DROP COLUMN deleted_at;
\ No newline at end of file
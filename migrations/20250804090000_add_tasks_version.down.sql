@@ -0,0 +1,2 @@
+ALTER TABLE tasks
+DROP COLUMN version;
\ No newline at end of file
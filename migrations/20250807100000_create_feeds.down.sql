@@ -0,0 +1 @@
+DROP TABLE feeds;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE app_passwords;
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP INDEX tasks_ical_uid_idx;
+
+ALTER TABLE tasks
+DROP COLUMN ical_uid;
\ No newline at end of file
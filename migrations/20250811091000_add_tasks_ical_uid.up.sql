@@ -0,0 +1,4 @@
+ALTER TABLE tasks
+ADD COLUMN ical_uid VARCHAR;
+
+CREATE UNIQUE INDEX tasks_ical_uid_idx ON tasks (user_id, ical_uid);
\ No newline at end of file
@@ -0,0 +1,21 @@
+CREATE TABLE shares (
+    id BIGSERIAL PRIMARY KEY,
+    owner_id BIGINT NOT NULL,
+    list_id BIGINT,
+    task_id BIGINT,
+    grantee_id BIGINT NOT NULL,
+    role VARCHAR NOT NULL,
+    status VARCHAR NOT NULL DEFAULT 'pending',
+    invited_by BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    CHECK ((list_id IS NULL) <> (task_id IS NULL)),
+    FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (list_id) REFERENCES lists(id) ON DELETE CASCADE,
+    FOREIGN KEY (task_id) REFERENCES tasks(task_id) ON DELETE CASCADE,
+    FOREIGN KEY (grantee_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX shares_list_idx ON shares (list_id, grantee_id) WHERE list_id IS NOT NULL;
+CREATE UNIQUE INDEX shares_task_idx ON shares (task_id, grantee_id) WHERE task_id IS NOT NULL;
+CREATE INDEX shares_grantee_idx ON shares (grantee_id, owner_id);
\ No newline at end of file
@@ -0,0 +1,14 @@
+DROP INDEX lists_workspace_idx;
+DROP INDEX tasks_workspace_idx;
+
+DELETE FROM tasks WHERE workspace_id IS NOT NULL;
+DELETE FROM lists WHERE workspace_id IS NOT NULL;
+
+ALTER TABLE lists
+DROP COLUMN workspace_id;
+
+ALTER TABLE tasks
+DROP COLUMN workspace_id;
+
+DROP TABLE workspace_members;
+DROP TABLE workspaces;
\ No newline at end of file
@@ -0,0 +1,26 @@
+CREATE TABLE workspaces (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR NOT NULL,
+    created_by BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE TABLE workspace_members (
+    workspace_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    role VARCHAR NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    PRIMARY KEY (workspace_id, user_id),
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX workspace_members_user_idx ON workspace_members (user_id);
+
+-- the tasks and lists without a workspace are in the personal workspace of their user
+ALTER TABLE tasks ADD COLUMN workspace_id BIGINT REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE lists ADD COLUMN workspace_id BIGINT REFERENCES workspaces(id) ON DELETE CASCADE;
+
+CREATE INDEX tasks_workspace_idx ON tasks (workspace_id, user_id);
+CREATE INDEX lists_workspace_idx ON lists (workspace_id, user_id);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE comments;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE attachments;
\ No newline at end of file
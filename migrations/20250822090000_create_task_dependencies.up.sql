@@ -0,0 +1,10 @@
+CREATE TABLE task_dependencies (
+    task_id BIGINT NOT NULL,
+    blocked_by BIGINT NOT NULL,
+    PRIMARY KEY (task_id, blocked_by),
+    CHECK (task_id <> blocked_by),
+    FOREIGN KEY (task_id) REFERENCES tasks(task_id) ON DELETE CASCADE,
+    FOREIGN KEY (blocked_by) REFERENCES tasks(task_id) ON DELETE CASCADE
+);
+
+CREATE INDEX task_dependencies_blocked_by_idx ON task_dependencies (blocked_by);
\ No newline at end of file
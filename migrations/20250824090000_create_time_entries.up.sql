@@ -0,0 +1,20 @@
+CREATE TABLE time_entries (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    task_id BIGINT NOT NULL,
+    tracker_id BIGINT NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    stopped_at TIMESTAMP,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    CHECK (stopped_at IS NULL OR stopped_at >= started_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (task_id) REFERENCES tasks(task_id) ON DELETE CASCADE,
+    FOREIGN KEY (tracker_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX time_entries_task_idx ON time_entries (task_id, started_at);
+CREATE INDEX time_entries_user_started_at_idx ON time_entries (user_id, started_at);
+
+-- a user runs a single timer at a time
+CREATE UNIQUE INDEX time_entries_running_idx ON time_entries (tracker_id) WHERE stopped_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP INDEX tasks_user_completed_at_idx;
+
+ALTER TABLE tasks
+DROP COLUMN completed_at;
\ No newline at end of file
@@ -0,0 +1,12 @@
+ALTER TABLE tasks
+ADD COLUMN completed_at TIMESTAMP;
+
+-- the tasks completed before the column existed are taken as completed the
+-- last time their history shows it, or when they were created
+UPDATE tasks t SET completed_at = COALESCE((
+    SELECT max(e.created_at) FROM task_events e
+    WHERE e.task_id = t.task_id AND e.changes -> 'complete' ->> 'after' = 'true'
+), t.created_at)
+WHERE t.complete;
+
+CREATE INDEX tasks_user_completed_at_idx ON tasks (user_id, completed_at) WHERE completed_at IS NOT NULL;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE tasks
+DROP COLUMN status;
+
+ALTER TABLE lists
+DROP COLUMN workflow;
\ No newline at end of file
@@ -0,0 +1,8 @@
+-- a NULL workflow is the default one: todo and done
+ALTER TABLE lists
+ADD COLUMN workflow JSONB;
+
+-- a NULL status is the first status of the workflow of the list that
+-- agrees with the complete flag
+ALTER TABLE tasks
+ADD COLUMN status VARCHAR(50);
\ No newline at end of file
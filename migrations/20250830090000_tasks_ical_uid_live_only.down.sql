@@ -0,0 +1,3 @@
+DROP INDEX tasks_ical_uid_idx;
+
+CREATE UNIQUE INDEX tasks_ical_uid_idx ON tasks (user_id, ical_uid);
\ No newline at end of file
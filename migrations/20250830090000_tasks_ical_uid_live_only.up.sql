@@ -0,0 +1,5 @@
+DROP INDEX tasks_ical_uid_idx;
+
+-- the UID of a deleted task can be taken by a new task while the deleted
+-- one is in the trash
+CREATE UNIQUE INDEX tasks_ical_uid_idx ON tasks (user_id, ical_uid) WHERE deleted_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP INDEX task_events_workspace_idx;
+
+ALTER TABLE task_events DROP COLUMN workspace_id;
\ No newline at end of file
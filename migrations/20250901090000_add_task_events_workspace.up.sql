@@ -0,0 +1,7 @@
+-- the events of the tasks of a workspace only change its sync token, the
+-- events of the tasks purged from the trash are left in the personal one
+ALTER TABLE task_events ADD COLUMN workspace_id BIGINT REFERENCES workspaces(id) ON DELETE CASCADE;
+
+UPDATE task_events e SET workspace_id = t.workspace_id FROM tasks t WHERE t.task_id = e.task_id;
+
+CREATE INDEX task_events_workspace_idx ON task_events (user_id, workspace_id, id);
\ No newline at end of file